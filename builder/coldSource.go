@@ -0,0 +1,299 @@
+package builder
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"cksr/logger"
+
+	mlcommon "example.com/migrationLib/common"
+	ckc "example.com/migrationLib/convert"
+	ckf "example.com/migrationLib/factory"
+	mp "example.com/migrationLib/parser"
+	"example.com/migrationLib/retry"
+)
+
+// ColdColumn 冷数据源中的一列，Type 为源端类型
+type ColdColumn struct {
+	Name string
+	Type string
+}
+
+// ColdSource 视图中冷数据一侧（CK 分支或更早的数据层）的数据源：经 SR 的 Catalog 访问，
+// 列按名称对齐到 SR 表，源端缺少的列以默认值补齐
+type ColdSource interface {
+	// Ref 该表在 SR 中的三段式引用
+	Ref() string
+	// Tables 列出源端同库中的表名
+	Tables() ([]string, error)
+	// Columns 列出该表的列
+	Columns() ([]ColdColumn, error)
+	// SRType 将源端类型映射为 SR 类型
+	SRType(sourceType string) string
+	// SelectClauses 按 srFields 的顺序生成该表的 select 子句，每个子句以 SR 列名输出
+	SelectClauses(srFields []SRField) ([]string, error)
+	// PrepareDDL 创建视图前需在源端执行的 DDL（如 CK 新增别名列），无需准备时为空
+	PrepareDDL() []string
+}
+
+// ClickHouseSource 经 JDBC Catalog 访问的 CK 表，列按 CK 字段转换器生成
+type ClickHouseSource struct {
+	catalog    string
+	dbName     string
+	name       string
+	converters []ckc.FieldConverter
+	tables     map[string]mp.Table // 同库全部表结构，为空时 Tables 返回空
+}
+
+// NewClickHouseSource 以导出的 CK 表结构（ExportClickHouseTablesAsParserTables 的结果）创建 CK 数据源
+func NewClickHouseSource(tables map[string]mp.Table, catalog, table string) (*ClickHouseSource, error) {
+	t, ok := tables[table]
+	if !ok {
+		return nil, fmt.Errorf("未找到ClickHouse表 %s 的结构", table)
+	}
+	converters, err := ckc.NewConverters(t, mlcommon.ScenarioView)
+	if err != nil {
+		return nil, fmt.Errorf("创建字段转换器失败(表 %s): %w", table, err)
+	}
+	return &ClickHouseSource{
+		catalog:    catalog,
+		dbName:     t.DDL.DBName,
+		name:       t.DDL.TableName,
+		converters: converters,
+		tables:     tables,
+	}, nil
+}
+
+// Converters 返回该表的字段转换器，供 NewBuilder 生成 CK 分支
+func (s *ClickHouseSource) Converters() []ckc.FieldConverter {
+	return s.converters
+}
+
+func (s *ClickHouseSource) Ref() string {
+	return fmt.Sprintf("`%s`.`%s`.`%s`", s.catalog, s.dbName, s.name)
+}
+
+func (s *ClickHouseSource) Tables() ([]string, error) {
+	names := make([]string, 0, len(s.tables))
+	for name := range s.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *ClickHouseSource) Columns() ([]ColdColumn, error) {
+	cols := make([]ColdColumn, 0, len(s.converters))
+	for _, c := range s.converters {
+		cols = append(cols, ColdColumn{Name: c.OriginName(), Type: c.OriginType()})
+	}
+	return cols, nil
+}
+
+// SRType 按 CK 类型给出对应的 SR 类型；Nullable、LowCardinality 取内部类型，未知类型按 STRING
+func (s *ClickHouseSource) SRType(sourceType string) string {
+	t := strings.TrimSpace(sourceType)
+	for _, wrapper := range []string{"Nullable(", "LowCardinality("} {
+		if strings.HasPrefix(t, wrapper) && strings.HasSuffix(t, ")") {
+			return s.SRType(t[len(wrapper) : len(t)-1])
+		}
+	}
+	if strings.HasPrefix(t, "Array(") && strings.HasSuffix(t, ")") {
+		return "ARRAY<" + s.SRType(t[len("Array("):len(t)-1]) + ">"
+	}
+	base, args := t, ""
+	if i := strings.Index(t, "("); i >= 0 {
+		base, args = t[:i], t[i:]
+	}
+	switch base {
+	case "Int8":
+		return "TINYINT"
+	case "UInt8", "Int16":
+		return "SMALLINT"
+	case "UInt16", "Int32":
+		return "INT"
+	case "UInt32", "Int64", "IPv4":
+		return "BIGINT"
+	case "UInt64", "Int128", "IPv6":
+		return "LARGEINT"
+	case "Float32":
+		return "FLOAT"
+	case "Float64":
+		return "DOUBLE"
+	case "Decimal":
+		return "DECIMAL" + args
+	case "Bool":
+		return "BOOLEAN"
+	case "Date", "Date32":
+		return "DATE"
+	case "DateTime", "DateTime64":
+		return "DATETIME"
+	default:
+		return "STRING"
+	}
+}
+
+// SelectClauses 按字段转换器生成子句（与 CK 分支规则一致），CK 中缺少的 SR 列以默认值补齐
+func (s *ClickHouseSource) SelectClauses(srFields []SRField) ([]string, error) {
+	nameMap := make(map[string]SRField, len(srFields))
+	for _, sf := range srFields {
+		nameMap[sf.Name] = sf
+	}
+	byName := make(map[string]string, len(s.converters))
+	for _, c := range s.converters {
+		srField, err := mapSRField(c, nameMap)
+		if err != nil {
+			logger.Debug("%s 的字段 '%s' 在StarRocks中不存在，跳过该字段", s.Ref(), c.OriginName())
+			continue
+		}
+		f := NewCKField(c)
+		f.SetSRField(srField)
+		f.GenClause()
+		byName[srField.Name] = f.Clause
+	}
+	clauses := make([]string, len(srFields))
+	for i, sf := range srFields {
+		if c, ok := byName[sf.Name]; ok {
+			clauses[i] = c
+		} else {
+			clauses[i] = defaultClauseForSRField(sf)
+		}
+	}
+	return clauses, nil
+}
+
+// PrepareDDL CK 侧为 IP 等列新增 SR 可直接读取的别名列
+func (s *ClickHouseSource) PrepareDDL() []string {
+	stmt := ckf.NewAddColumnsBuilder(mlcommon.ScenarioView, s.converters, s.dbName, s.name).Build()
+	if strings.TrimSpace(stmt) == "" {
+		return nil
+	}
+	return []string{stmt}
+}
+
+// ExternalCatalogSource SR 外部 Catalog（Hive、Iceberg、Paimon 等）中的表，
+// 列信息经该 Catalog 的 information_schema 读取，列类型已是 SR 类型（比较前统一写法，见 normalizeSRType）
+type ExternalCatalogSource struct {
+	db      *sql.DB
+	retry   retry.Config
+	catalog string
+	dbName  string
+	name    string
+	columns []ColdColumn // Columns 的缓存
+}
+
+// NewExternalCatalogSource 创建外部 Catalog 数据源，db 为 SR 连接
+func NewExternalCatalogSource(db *sql.DB, retryConfig retry.Config, catalog, dbName, table string) *ExternalCatalogSource {
+	return &ExternalCatalogSource{db: db, retry: retryConfig, catalog: catalog, dbName: dbName, name: table}
+}
+
+func (s *ExternalCatalogSource) Ref() string {
+	return fmt.Sprintf("`%s`.`%s`.`%s`", s.catalog, s.dbName, s.name)
+}
+
+// Tables 经该 Catalog 的 information_schema 列出同库中的表
+func (s *ExternalCatalogSource) Tables() ([]string, error) {
+	q := fmt.Sprintf("SELECT table_name FROM `%s`.information_schema.tables WHERE table_schema = ? ORDER BY table_name", s.catalog)
+	rows, err := retry.QueryWithRetry(s.db, s.retry, q, s.dbName)
+	if err != nil {
+		return nil, fmt.Errorf("查询 Catalog %s 库 %s 的表失败: %w", s.catalog, s.dbName, err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("扫描表名失败: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历表名失败: %w", err)
+	}
+	return names, nil
+}
+
+// Columns 经该 Catalog 的 information_schema 读取表的列，结果缓存
+func (s *ExternalCatalogSource) Columns() ([]ColdColumn, error) {
+	if s.columns != nil {
+		return s.columns, nil
+	}
+	q := fmt.Sprintf("SELECT column_name, column_type FROM `%s`.information_schema.columns WHERE table_schema = ? AND table_name = ? ORDER BY ordinal_position", s.catalog)
+	rows, err := retry.QueryWithRetry(s.db, s.retry, q, s.dbName, s.name)
+	if err != nil {
+		return nil, fmt.Errorf("查询 %s 的列失败: %w", s.Ref(), err)
+	}
+	defer rows.Close()
+	var cols []ColdColumn
+	for rows.Next() {
+		var c ColdColumn
+		if err := rows.Scan(&c.Name, &c.Type); err != nil {
+			return nil, fmt.Errorf("扫描列信息失败: %w", err)
+		}
+		cols = append(cols, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历列信息失败: %w", err)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("%s 不存在或没有列", s.Ref())
+	}
+	s.columns = cols
+	return cols, nil
+}
+
+// SRType 外部 Catalog 的 information_schema 已给出 SR 类型，只统一写法（见 normalizeSRType）
+func (s *ExternalCatalogSource) SRType(sourceType string) string {
+	return normalizeSRType(sourceType)
+}
+
+// SelectClauses 按列名（不区分大小写）选取，类型与 SR 列不一致时 CAST 为 SR 类型，缺少的列以默认值补齐
+func (s *ExternalCatalogSource) SelectClauses(srFields []SRField) ([]string, error) {
+	cols, err := s.Columns()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]ColdColumn, len(cols))
+	for _, c := range cols {
+		byName[strings.ToLower(c.Name)] = c
+	}
+	clauses := make([]string, len(srFields))
+	for i, sf := range srFields {
+		c, ok := byName[strings.ToLower(sf.Name)]
+		switch {
+		case !ok:
+			logger.Debug("%s 中不存在列 '%s'，使用默认值补列", s.Ref(), sf.Name)
+			clauses[i] = defaultClauseForSRField(sf)
+		case s.SRType(c.Type) != normalizeSRType(sf.Type):
+			clauses[i] = fmt.Sprintf("CAST(`%s` AS %s) as `%s`", c.Name, strings.TrimSpace(sf.Type), sf.Name)
+		case c.Name != sf.Name:
+			clauses[i] = fmt.Sprintf("`%s` as `%s`", c.Name, sf.Name)
+		default:
+			clauses[i] = fmt.Sprintf("`%s`", sf.Name)
+		}
+	}
+	return clauses, nil
+}
+
+// PrepareDDL 外部 Catalog 只读，无需准备
+func (s *ExternalCatalogSource) PrepareDDL() []string {
+	return nil
+}
+
+// normalizeSRType 统一 SR 类型的写法用于比较：大写、去空白，整数类型去掉显示宽度，字符串类型统一为 STRING
+func normalizeSRType(t string) string {
+	t = strings.ToUpper(strings.Join(strings.Fields(t), ""))
+	base := t
+	if i := strings.Index(t, "("); i >= 0 {
+		base = t[:i]
+	}
+	switch base {
+	case "TINYINT", "SMALLINT", "INT", "BIGINT", "LARGEINT":
+		return base
+	case "VARCHAR", "CHAR", "STRING":
+		return "STRING"
+	}
+	return t
+}
@@ -0,0 +1,108 @@
+package builder
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	mp "example.com/migrationLib/parser"
+)
+
+// fakeSource 以固定的列生成查询，按列名对齐 SR 列，缺少的列以默认值补齐
+type fakeSource struct {
+	ref     string
+	columns []ColdColumn
+	asked   []string // 最近一次 SelectClauses 收到的 SR 列名
+}
+
+func (s *fakeSource) Ref() string                     { return s.ref }
+func (s *fakeSource) Tables() ([]string, error)       { return []string{"orders"}, nil }
+func (s *fakeSource) Columns() ([]ColdColumn, error)  { return s.columns, nil }
+func (s *fakeSource) SRType(sourceType string) string { return strings.ToUpper(sourceType) }
+func (s *fakeSource) PrepareDDL() []string            { return nil }
+
+func (s *fakeSource) SelectClauses(srFields []SRField) ([]string, error) {
+	s.asked = nil
+	byName := make(map[string]ColdColumn, len(s.columns))
+	for _, c := range s.columns {
+		byName[c.Name] = c
+	}
+	clauses := make([]string, len(srFields))
+	for i, sf := range srFields {
+		s.asked = append(s.asked, sf.Name)
+		if c, ok := byName[sf.Name]; ok {
+			clauses[i] = fmt.Sprintf("`%s` as `%s`", c.Name, sf.Name)
+		} else {
+			clauses[i] = defaultClauseForSRField(sf)
+		}
+	}
+	return clauses, nil
+}
+
+func TestColdSourceInjection(t *testing.T) {
+	srFields := []mp.Field{{Name: "id", Type: "BIGINT"}, {Name: "ts", Type: "DATETIME"}, {Name: "extra", Type: "VARCHAR(16)"}}
+	v := NewBuilder(nil, srFields, "ck_db", "orders", "ck_catalog", "sr_db", "orders_local", nil, nil)
+	for _, f := range srFields {
+		sf := SRField{Field: f}
+		sf.GenClause()
+		v.sr.addClauseField(sf)
+	}
+
+	cold := &fakeSource{ref: "`iceberg`.`archive`.`orders`", columns: []ColdColumn{{Name: "id", Type: "bigint"}, {Name: "ts", Type: "datetime"}}}
+	older := &fakeSource{ref: "`hive`.`archive`.`orders_2019`", columns: []ColdColumn{{Name: "id", Type: "bigint"}}}
+	v.SetColdSource(cold)
+	v.SetTiers([]Tier{{Kind: TierExternal, Catalog: "hive", DBName: "archive", Name: "orders_2019", Source: older}})
+
+	queries, err := v.branchQueries()
+	if err != nil {
+		t.Fatalf("生成分支查询失败: %v", err)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("分支数 = %d，期望 3（数据层、CK、SR）", len(queries))
+	}
+	want := []string{
+		"select \n \t`id` as `id`, \n\tCAST(NULL AS DATETIME) as `ts`, \n\tCAST(NULL AS VARCHAR(16)) as `extra` \nfrom `hive`.`archive`.`orders_2019`",
+		"select \n \t`id` as `id`, \n\t`ts` as `ts`, \n\tCAST(NULL AS VARCHAR(16)) as `extra` \nfrom `iceberg`.`archive`.`orders`",
+	}
+	if !reflect.DeepEqual(queries[:2], want) {
+		t.Fatalf("冷数据分支查询 =\n%q\n期望\n%q", queries[:2], want)
+	}
+	if !reflect.DeepEqual(cold.asked, []string{"id", "ts", "extra"}) {
+		t.Fatalf("注入的数据源收到的 SR 列 = %v", cold.asked)
+	}
+	if !strings.Contains(queries[2], "from `sr_db`.`orders_local`") {
+		t.Fatalf("SR 分支不应受注入的数据源影响:\n%s", queries[2])
+	}
+
+	types, err := srColumnTypes(cold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(types, map[string]string{"id": "BIGINT", "ts": "DATETIME"}) {
+		t.Fatalf("srColumnTypes = %v", types)
+	}
+}
+
+func TestClickHouseSourceSRType(t *testing.T) {
+	s := &ClickHouseSource{}
+	tests := map[string]string{
+		"Int8":                             "TINYINT",
+		"UInt8":                            "SMALLINT",
+		"UInt32":                           "BIGINT",
+		"UInt64":                           "LARGEINT",
+		"Nullable(Float64)":                "DOUBLE",
+		"LowCardinality(Nullable(String))": "STRING",
+		"Decimal(18, 2)":                   "DECIMAL(18, 2)",
+		"DateTime64(3)":                    "DATETIME",
+		"Date32":                           "DATE",
+		"Array(Nullable(Int32))":           "ARRAY<INT>",
+		"IPv4":                             "BIGINT",
+		"Map(String, UInt8)":               "STRING",
+	}
+	for in, want := range tests {
+		if got := s.SRType(in); got != want {
+			t.Errorf("SRType(%q) = %q，期望 %q", in, got, want)
+		}
+	}
+}
@@ -22,6 +22,30 @@ const (
 	SQLTypeAlter  = "ALTER"
 )
 
+// ViewOwnerComment init 创建视图时写入的注释，标记视图由 cksr 管理
+const ViewOwnerComment = "managed-by:cksr"
+
+// 数据层类型（多层视图中比 CK 分支更早的数据源）
+const (
+	TierClickHouse = "clickhouse" // 经 SR JDBC Catalog 访问的 CK 表，见 ClickHouseSource
+	TierExternal   = "external"   // SR 外部 Catalog（Hive、Iceberg、Paimon 等）中的表，见 ExternalCatalogSource
+)
+
+// Tier 多层视图中比 CK 分支更早的一层数据源；视图按从早到晚依次为各层、CK、SR 分支
+type Tier struct {
+	Kind    string
+	Catalog string
+	DBName  string
+	Name    string
+	// Source 生成该层查询的数据源，为空时按 Kind 选择（见 tierSource）
+	Source ColdSource
+}
+
+// Ref 返回该层在 SR 中的三段式引用
+func (t Tier) Ref() string {
+	return fmt.Sprintf("`%s`.`%s`.`%s`", t.Catalog, t.DBName, t.Name)
+}
+
 // DatabaseManager 定义数据库管理器接口（简化版，只需要获取连接）
 type DatabaseManager interface {
 	GetStarRocksConnection() (*sql.DB, error)
@@ -29,11 +53,20 @@ type DatabaseManager interface {
 
 type ViewBuilder struct {
 	ck        CKTableBuilder
+	cold      ColdSource // CK 分支的数据源，由 ck 的字段转换器生成查询，与数据层共用同一套生成逻辑
 	sr        SRTableBuilder
 	viewName  string          // view的名称，就是ck中的name名称
 	dbName    string          // 数据库名称，应该就是sr中的db
 	dbManager DatabaseManager // 数据库管理器，用于执行查询
 	config    *mcfg.Config    // 配置对象，用于获取时间戳列配置
+	// boundaryTable 计算时间边界时查询的SR表名，为空时使用 sr.Name
+	// 初始化在重命名前生成SQL时，后缀表尚不存在，需从基础名读取
+	boundaryTable string
+	// tiers 比 CK 分支更早的数据层（从早到晚），为空时视图只有 CK、SR 两个分支
+	tiers []Tier
+	// 最近一次 PrepareAndValidate 中被跳过的CK字段与以默认值补齐的SR独有字段，供映射说明使用
+	skipped   []ckc.FieldConverter
+	defaulted []SRField
 }
 
 type CKField struct {
@@ -66,7 +99,7 @@ type TableBuilder struct {
 	Name   string
 }
 
-// 构建ck的select
+// ck 分支的字段映射，供映射说明使用；查询由 ViewBuilder.cold 生成
 type CKTableBuilder struct {
 	TableBuilder
 	catalogName string
@@ -142,21 +175,6 @@ func NewSRTableBuilder(fields []mp.Field, tableName, dbName string) SRTableBuild
 	}
 }
 
-func (ct *CKTableBuilder) GenQuerySQL() string {
-	var fieldsClause []string
-	for i, f := range ct.fields {
-		var clause string
-		if i == len(ct.fields)-1 {
-			clause = fmt.Sprintf("\t%s \n", f.Clause)
-		} else {
-			clause = fmt.Sprintf("\t%s, \n", f.Clause)
-		}
-		fieldsClause = append(fieldsClause, clause)
-	}
-	clauses := strings.Join(fieldsClause, "")
-	return fmt.Sprintf("select \n %sfrom `%s`.`%s`.`%s`", clauses, ct.catalogName, ct.DBName, ct.Name)
-}
-
 func (st *SRTableBuilder) GenQuerySQL() string {
 	var fieldsClause []string
 	for i, f := range st.fields {
@@ -181,7 +199,13 @@ func NewBuilder(
 	ckTb := NewCKTableBuilder(fieldConverters, ckTableName, ckDBName, ckCatalogName)
 	srTb := NewSRTableBuilder(srFields, srTableName, srDBName)
 	return ViewBuilder{
-		ck:        ckTb,
+		ck: ckTb,
+		cold: &ClickHouseSource{
+			catalog:    ckCatalogName,
+			dbName:     ckDBName,
+			name:       ckTableName,
+			converters: fieldConverters,
+		},
 		sr:        srTb,
 		viewName:  ckTableName,
 		dbName:    srDBName,
@@ -190,6 +214,35 @@ func NewBuilder(
 	}
 }
 
+// SetBoundarySourceTable 指定计算时间边界时查询的SR表（不影响视图中引用的表名）
+func (v *ViewBuilder) SetBoundarySourceTable(tableName string) {
+	v.boundaryTable = tableName
+}
+
+// SetColdSource 替换生成 CK 分支查询的数据源（默认为按字段转换器生成的 ClickHouseSource）；
+// 字段映射与校验仍以字段转换器为准，src 按 SR 列生成 select 子句
+func (v *ViewBuilder) SetColdSource(src ColdSource) {
+	v.cold = src
+}
+
+// SetTiers 设置比 CK 分支更早的数据层（从早到晚）
+func (v *ViewBuilder) SetTiers(tiers []Tier) {
+	v.tiers = tiers
+}
+
+// BoundaryCount 返回视图中边界的个数（分支数减一）
+func (v *ViewBuilder) BoundaryCount() int {
+	return len(v.tiers) + 1
+}
+
+// boundarySourceTable 返回计算时间边界时查询的SR表名
+func (v *ViewBuilder) boundarySourceTable() string {
+	if strings.TrimSpace(v.boundaryTable) != "" {
+		return v.boundaryTable
+	}
+	return v.sr.Name
+}
+
 // 遍历ck的fields，根据type，决定要不要，如果不要，继续下一个，如果要，如下
 // 获取重定向字段，构造出clause，写入tablebuilder；找到sr中对应的字段，同样构造出clause，写入tablebuilder
 func (v *ViewBuilder) Build() (string, error) {
@@ -208,12 +261,11 @@ func (v *ViewBuilder) BuildWithType(sqlType string) (string, error) {
 		return "", err
 	}
 
-	ckQ := v.ck.GenQuerySQL()
-	srQ := v.sr.GenQuerySQL()
-	logger.Debug("生成的ClickHouse查询SQL:\n%s", ckQ)
-	logger.Debug("生成的StarRocks查询SQL:\n%s", srQ)
-
-	viewSQL, err := v.GenViewSQLWithType(ckQ, srQ, sqlType)
+	queries, err := v.branchQueries()
+	if err != nil {
+		return "", err
+	}
+	viewSQL, err := v.GenViewSQLWithType(queries, sqlType)
 	if err != nil {
 		return "", fmt.Errorf("生成视图SQL失败: %w", err)
 	}
@@ -227,6 +279,8 @@ func (v *ViewBuilder) PrepareAndValidate() error {
 	// 重置已生成的字段，避免重复构建
 	v.ck.fields = nil
 	v.sr.fields = nil
+	v.skipped = nil
+	v.defaulted = nil
 
 	processedFields := 0
 	skippedFields := 0
@@ -245,6 +299,7 @@ func (v *ViewBuilder) PrepareAndValidate() error {
 		if err != nil {
 			// 如果ClickHouse字段在StarRocks中不存在，跳过该字段而不是报错
 			logger.Warn("ClickHouse字段 '%s' 在StarRocks中不存在，跳过该字段", fieldConverter.OriginName())
+			v.skipped = append(v.skipped, fieldConverter)
 			skippedFields++
 			continue
 		}
@@ -285,11 +340,12 @@ func (v *ViewBuilder) PrepareAndValidate() error {
 			v.sr.addClauseField(sf)
 
 			// CK 子句补充默认值占位，并别名为 SR 字段名
-			defaultClause := v.defaultCKClauseForSRField(sf)
+			defaultClause := defaultClauseForSRField(sf)
 			ckField := CKField{}
 			ckField.SRField = sf
 			ckField.Clause = defaultClause
 			v.ck.addClauseField(ckField)
+			v.defaulted = append(v.defaulted, sf)
 		}
 	}
 
@@ -370,9 +426,9 @@ func (v *ViewBuilder) PrepareAndValidate() error {
 	return nil
 }
 
-// defaultCKClauseForSRField 为 SR 独有列生成 CK 子查询中的默认值占位表达式
+// defaultClauseForSRField 为 SR 独有列生成 CK 子查询（或数据层查询）中的默认值占位表达式
 // 语义：将 CK 侧该列视为 SR 列的“默认空值”（统一使用 CAST NULL 保持类型一致；数组使用空数组字面量）
-func (v *ViewBuilder) defaultCKClauseForSRField(sf SRField) string {
+func defaultClauseForSRField(sf SRField) string {
 	t := strings.TrimSpace(sf.Type)
 	//upper := strings.ToUpper(t)
 
@@ -390,56 +446,199 @@ func (v *ViewBuilder) BuildAlter() (string, error) {
 	return v.BuildWithType(SQLTypeAlter)
 }
 
-// BuildAlterWithPartition 使用提供的分区时间值生成 ALTER VIEW SQL
-// partitionValue: 原始字符串形式的时间值
-// isNumeric: 为true则按数值直接使用；为false则按字符串加引号
-func (v *ViewBuilder) BuildAlterWithPartition(partitionValue string) (string, error) {
-	logger.Debug("开始构建带分区值的ALTER VIEW，值: %s", partitionValue)
+// BuildAlterWithBoundaries 使用提供的边界值（从早到晚，个数为 BoundaryCount）生成 ALTER VIEW SQL
+// 最后一个边界为 CK 与 SR 之间的边界；datetime/date 值以单引号包裹，bigint 直接使用数值
+func (v *ViewBuilder) BuildAlterWithBoundaries(values []string) (string, error) {
+	logger.Debug("开始构建带边界值的ALTER VIEW，值: %v", values)
+	if len(values) != v.BoundaryCount() {
+		return "", fmt.Errorf("视图 %s 共 %d 个分支，需要 %d 个边界值，实际 %d 个", v.viewName, len(v.tiers)+2, v.BoundaryCount(), len(values))
+	}
 	// 强制执行完整的字段映射与校验逻辑，保持与 BuildWithType 一致
 	if err := v.PrepareAndValidate(); err != nil {
 		return "", err
 	}
 
-	// 在完成校验后再生成查询SQL，避免绕过校验
-	ckQ := v.ck.GenQuerySQL()
-	srQ := v.sr.GenQuerySQL()
-	logger.Debug("生成的ClickHouse查询SQL:\n%s", ckQ)
-	logger.Debug("生成的StarRocks查询SQL:\n%s", srQ)
-
 	// 获取时间戳列信息
 	timestampColumn := v.getTimestampColumnName(v.sr.Name)
 	timestampType := strings.ToLower(v.getTimestampColumnType(v.sr.Name))
 
-	// 严格校验并规范化分区值：
-	// - datetime/date 类型：必须为可解析的时间字符串（不接受纯数字）；最终以单引号包裹
-	// - bigint 类型：必须为纯数字；直接使用数值
-	// 规范化原始输入
-	trimmed := strings.Trim(partitionValue, "'")
+	boundaries := make([]string, len(values))
+	for i, value := range values {
+		b, err := normalizeBoundary(value, timestampType)
+		if err != nil {
+			return "", err
+		}
+		if i > 0 && compareBoundary(boundaries[i-1], b) > 0 {
+			return "", fmt.Errorf("边界值需按从早到晚排列：第 %d 个边界 %s 晚于第 %d 个边界 %s", i, boundaries[i-1], i+1, b)
+		}
+		boundaries[i] = b
+	}
 
-	var minTimestamp string
+	// 在完成校验后再生成查询SQL，避免绕过校验
+	queries, err := v.branchQueries()
+	if err != nil {
+		return "", err
+	}
+	sql := v.ComposeFinalSQL(SQLTypeAlter, queries, timestampColumn, boundaries)
+	logger.Debug("最终视图SQL(带边界值):\n%s", sql)
+	return sql, nil
+}
+
+// normalizeBoundary 严格校验并规范化边界值：
+// - datetime/date 类型：必须为可解析的时间字符串（不接受纯数字）；最终以单引号包裹
+// - bigint 类型：必须为纯数字；直接使用数值
+func normalizeBoundary(value, timestampType string) (string, error) {
+	trimmed := strings.Trim(strings.TrimSpace(value), "'")
 	switch timestampType {
 	case "datetime":
 		if _, err := time.Parse("2006-01-02 15:04:05", trimmed); err != nil {
 			return "", fmt.Errorf("分区值解析失败：%v（期望格式 YYYY-MM-DD HH:MM:SS）", err)
 		}
-		minTimestamp = "'" + trimmed + "'"
+		return "'" + trimmed + "'", nil
 	case "date":
 		if _, err := time.Parse("2006-01-02", trimmed); err != nil {
 			return "", fmt.Errorf("分区值解析失败：%v（期望格式 YYYY-MM-DD）", err)
 		}
-		minTimestamp = "'" + trimmed + "'"
+		return "'" + trimmed + "'", nil
 	case "bigint":
 		if _, err := strconv.ParseInt(trimmed, 10, 64); err != nil {
 			return "", fmt.Errorf("分区值类型不匹配：列类型为 bigint，分区值必须为纯数字")
 		}
-		minTimestamp = trimmed
+		return trimmed, nil
 	default:
 		return "", fmt.Errorf("不支持的时间戳列类型：%s，仅支持 date、datetime、bigint", timestampType)
 	}
+}
 
-	sql := v.ComposeFinalSQL(SQLTypeAlter, ckQ, srQ, timestampColumn, minTimestamp)
-	logger.Debug("最终视图SQL(带分区值):\n%s", sql)
-	return sql, nil
+// compareBoundary 比较两个同类型的规范化边界值：bigint 按数值，日期时间按字符串（格式固定，字典序即时间先后）
+func compareBoundary(a, b string) int {
+	ai, aerr := strconv.ParseInt(a, 10, 64)
+	bi, berr := strconv.ParseInt(b, 10, 64)
+	if aerr == nil && berr == nil {
+		switch {
+		case ai < bi:
+			return -1
+		case ai > bi:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(strings.Trim(a, "'"), strings.Trim(b, "'"))
+}
+
+// branchQueries 返回从早到晚各分支的查询：各数据层、CK、SR；需在 PrepareAndValidate 之后调用
+func (v *ViewBuilder) branchQueries() ([]string, error) {
+	queries := make([]string, 0, len(v.tiers)+2)
+	for _, t := range v.tiers {
+		q, err := v.tierQuerySQL(t)
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, q)
+	}
+	ckQ, err := v.coldQuerySQL(v.cold)
+	if err != nil {
+		return nil, err
+	}
+	srQ := v.sr.GenQuerySQL()
+	logger.Debug("生成的ClickHouse查询SQL:\n%s", ckQ)
+	logger.Debug("生成的StarRocks查询SQL:\n%s", srQ)
+	return append(queries, ckQ, srQ), nil
+}
+
+// tierSource 返回数据层的数据源：未指定 Source 时，clickhouse 层沿用 CK 表的字段转换器（需与 CK 表结构一致，见 checkTierSchema），
+// external 层经 SR 读取该 Catalog 的 information_schema
+func (v *ViewBuilder) tierSource(t Tier) (ColdSource, error) {
+	if t.Source != nil {
+		return t.Source, nil
+	}
+	db, err := v.dbManager.GetStarRocksConnection()
+	if err != nil {
+		return nil, fmt.Errorf("获取StarRocks连接失败: %w", err)
+	}
+	retryConfig := retry.Config{MaxRetries: v.config.Retry.MaxRetries, Delay: time.Duration(v.config.Retry.DelayMs) * time.Millisecond}
+	if t.Kind != TierExternal {
+		if err := v.checkTierSchema(db, retryConfig, t); err != nil {
+			return nil, err
+		}
+		return &ClickHouseSource{catalog: t.Catalog, dbName: t.DBName, name: t.Name, converters: v.ck.converters}, nil
+	}
+	return NewExternalCatalogSource(db, retryConfig, t.Catalog, t.DBName, t.Name), nil
+}
+
+// checkTierSchema 校验 clickhouse 数据层能否沿用 CK 表的字段转换器：该层位于另一个 CK 集群，无法导出其表结构，
+// 因此经 SR 的 Catalog 读取两表的列，CK 分支引用的列在该层缺失或类型不同时拒绝生成视图；需在 PrepareAndValidate 之后调用
+func (v *ViewBuilder) checkTierSchema(db *sql.DB, retryConfig retry.Config, t Tier) error {
+	ckTypes, err := srColumnTypes(NewExternalCatalogSource(db, retryConfig, v.ck.catalogName, v.ck.DBName, v.ck.Name))
+	if err != nil {
+		return fmt.Errorf("读取 CK 表结构失败: %w", err)
+	}
+	tierTypes, err := srColumnTypes(NewExternalCatalogSource(db, retryConfig, t.Catalog, t.DBName, t.Name))
+	if err != nil {
+		return fmt.Errorf("读取数据层结构失败: %w", err)
+	}
+
+	var diffs []string
+	for _, f := range v.ck.fields {
+		// SR 独有列在 CK 分支以默认值补齐，不引用 CK 的列
+		name := f.Field.Name
+		if name == "" {
+			continue
+		}
+		tierType, ok := tierTypes[strings.ToLower(name)]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("缺少列 %s", name))
+			continue
+		}
+		if ckType, ok := ckTypes[strings.ToLower(name)]; ok && ckType != tierType {
+			diffs = append(diffs, fmt.Sprintf("列 %s 的类型为 %s，CK 表中为 %s", name, tierType, ckType))
+		}
+	}
+	if len(diffs) > 0 {
+		return fmt.Errorf("数据层 %s 与 CK 表 %s 的结构不一致（%s），clickhouse 数据层沿用 CK 表的字段映射，请保持两表结构一致或将该层配置为 kind=external 按列名对齐",
+			t.Ref(), v.cold.Ref(), strings.Join(diffs, "；"))
+	}
+	return nil
+}
+
+// srColumnTypes 经数据源列出列并映射为 SR 类型，键为小写列名
+func srColumnTypes(src ColdSource) (map[string]string, error) {
+	cols, err := src.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string, len(cols))
+	for _, c := range cols {
+		types[strings.ToLower(c.Name)] = src.SRType(c.Type)
+	}
+	return types, nil
+}
+
+// tierQuerySQL 生成数据层的查询，列顺序与 SR 分支一致，该层缺少的列以默认值补齐
+func (v *ViewBuilder) tierQuerySQL(t Tier) (string, error) {
+	src, err := v.tierSource(t)
+	if err != nil {
+		return "", err
+	}
+	return v.coldQuerySQL(src)
+}
+
+// coldQuerySQL 按 SR 分支的列顺序生成冷数据源（CK 分支或数据层）的查询
+func (v *ViewBuilder) coldQuerySQL(src ColdSource) (string, error) {
+	clauses, err := src.SelectClauses(v.sr.fields)
+	if err != nil {
+		return "", fmt.Errorf("生成 %s 的查询失败: %w", src.Ref(), err)
+	}
+	for i := range clauses {
+		if i == len(clauses)-1 {
+			clauses[i] = fmt.Sprintf("\t%s \n", clauses[i])
+		} else {
+			clauses[i] = fmt.Sprintf("\t%s, \n", clauses[i])
+		}
+	}
+	q := fmt.Sprintf("select \n %sfrom %s", strings.Join(clauses, ""), src.Ref())
+	logger.Debug("生成的 %s 查询SQL:\n%s", src.Ref(), q)
+	return q, nil
 }
 
 // getTimestampColumnName 根据配置获取指定表的时间戳列名，如果没有配置则返回默认的recordTimestamp
@@ -493,6 +692,11 @@ func (v *ViewBuilder) getTimestampColumnType(tableName string) string {
 
 // getDefaultTimestampValue 根据数据类型获取默认的最大时间戳值
 func (v *ViewBuilder) getDefaultTimestampValue(dataType string) (string, error) {
+	return MaxTimestampValue(dataType)
+}
+
+// MaxTimestampValue 返回边界的哨兵最大值：以此为边界时全部数据走CK分支
+func MaxTimestampValue(dataType string) (string, error) {
 	switch strings.ToLower(dataType) {
 	case "date":
 		return "'9999-12-31'", nil
@@ -535,10 +739,10 @@ func (v *ViewBuilder) formatTimestampValue(value string, dataType string) (strin
 // 	var nullableTimestamp *int64
 // 	var minTimestamp string
 
-func (v *ViewBuilder) GenViewSQLWithType(ckQ, srQ string, sqlType string) (string, error) {
+// GenViewSQLWithType 计算各边界并拼接视图SQL；queries 为 branchQueries 返回的从早到晚各分支查询。
+// CK 与 SR 之间的边界取 SR 表的最小时间戳；各数据层与下一层之间的边界取下一层的最小时间戳，不晚于其后的边界
+func (v *ViewBuilder) GenViewSQLWithType(queries []string, sqlType string) (string, error) {
 	logger.Debug("开始生成视图SQL (类型: %s)", sqlType)
-	logger.Debug("ClickHouse查询SQL: %s", ckQ)
-	logger.Debug("StarRocks查询SQL: %s", srQ)
 
 	// 获取时间戳列名和数据类型
 	timestampColumn := v.getTimestampColumnName(v.sr.Name)
@@ -558,78 +762,111 @@ func (v *ViewBuilder) GenViewSQLWithType(ckQ, srQ string, sqlType string) (strin
 		if perr != nil {
 			logger.Warn("分区路径获取最小时间戳失败，回退全表聚合: %v", perr)
 		}
-		q := fmt.Sprintf("select min(`%s`) from `%s`.`%s`", timestampColumn, v.sr.DBName, v.sr.Name)
-		switch strings.ToLower(timestampType) {
-		case "datetime", "date":
-			var nullableTimestamp *string
-			retryConfig := retry.Config{MaxRetries: v.config.Retry.MaxRetries, Delay: time.Duration(v.config.Retry.DelayMs) * time.Millisecond}
-			err = retry.QueryRowAndScanWithRetry(db, retryConfig, q, []interface{}{&nullableTimestamp})
-			if err != nil {
-				if err == sql.ErrNoRows {
-					minTimestamp, err = v.getDefaultTimestampValue(timestampType)
-					if err != nil {
-						return "", fmt.Errorf("获取默认时间戳值失败: %w", err)
-					}
-				} else {
-					return "", fmt.Errorf("查询最小时间戳失败: %w", err)
-				}
-			} else if nullableTimestamp == nil {
-				minTimestamp, err = v.getDefaultTimestampValue(timestampType)
-				if err != nil {
-					return "", fmt.Errorf("获取默认时间戳值失败: %w", err)
-				}
-			} else {
-				minTimestamp, err = v.formatTimestampValue(*nullableTimestamp, timestampType)
-				if err != nil {
-					return "", fmt.Errorf("格式化时间戳值失败: %w", err)
-				}
-			}
-		case "bigint":
-			var nullableTimestamp *int64
-			retryConfig := retry.Config{MaxRetries: v.config.Retry.MaxRetries, Delay: time.Duration(v.config.Retry.DelayMs) * time.Millisecond}
-			err = retry.QueryRowAndScanWithRetry(db, retryConfig, q, []interface{}{&nullableTimestamp})
-			if err != nil {
-				if err == sql.ErrNoRows {
-					minTimestamp, err = v.getDefaultTimestampValue(timestampType)
-					if err != nil {
-						return "", fmt.Errorf("获取默认时间戳值失败: %w", err)
-					}
-				} else {
-					return "", fmt.Errorf("查询最小时间戳失败: %w", err)
-				}
-			} else if nullableTimestamp == nil {
-				minTimestamp, err = v.getDefaultTimestampValue(timestampType)
-				if err != nil {
-					return "", fmt.Errorf("获取默认时间戳值失败: %w", err)
-				}
-			} else {
-				minTimestamp = fmt.Sprintf("%d", *nullableTimestamp)
-			}
-		default:
-			return "", fmt.Errorf("未知的时间戳数据类型: %s", timestampType)
+		minTimestamp, err = v.minTimestampFrom(db, fmt.Sprintf("`%s`.`%s`", v.sr.DBName, v.boundarySourceTable()), timestampColumn, timestampType)
+		if err != nil {
+			return "", err
 		}
 	}
+
+	// 各数据层的边界从后往前计算：下一层为空或最小值晚于其后的边界时取其后的边界，保证各区间首尾相接
+	boundaries := make([]string, len(v.tiers)+1)
+	boundaries[len(v.tiers)] = minTimestamp
+	for i := len(v.tiers) - 1; i >= 0; i-- {
+		next := v.cold.Ref()
+		if i+1 < len(v.tiers) {
+			next = v.tiers[i+1].Ref()
+		}
+		b, err := v.minTimestampFrom(db, next, timestampColumn, timestampType)
+		if err != nil {
+			return "", fmt.Errorf("计算数据层 %s 的边界失败: %w", v.tiers[i].Ref(), err)
+		}
+		if compareBoundary(b, boundaries[i+1]) > 0 {
+			b = boundaries[i+1]
+		}
+		boundaries[i] = b
+	}
+
 	// 统一封装最终SQL拼接
-	sql := v.ComposeFinalSQL(sqlType, ckQ, srQ, timestampColumn, minTimestamp)
+	sql := v.ComposeFinalSQL(sqlType, queries, timestampColumn, boundaries)
 
 	logger.Debug("最终视图SQL:\n%s", sql)
 	return sql, nil
 }
 
-// ComposeFinalSQL 封装最终的 CREATE/ALTER 视图SQL拼接
-func (v *ViewBuilder) ComposeFinalSQL(sqlType, ckQ, srQ, timestampColumn, minTimestamp string) string {
-	body := fmt.Sprintf("`%s`.`%s` as \n%s \nwhere `%s` < %s \nunion all \n%s \nwhere `%s` >= %s; \n",
-		v.dbName, v.viewName, ckQ, timestampColumn, minTimestamp, srQ, timestampColumn, minTimestamp)
+// minTimestampFrom 查询 ref（已带反引号的库表或 Catalog 三段式引用）中时间戳列的最小值；表为空时返回哨兵最大值
+func (v *ViewBuilder) minTimestampFrom(db *sql.DB, ref, timestampColumn, timestampType string) (string, error) {
+	q := fmt.Sprintf("select min(`%s`) from %s", timestampColumn, ref)
+	retryConfig := retry.Config{MaxRetries: v.config.Retry.MaxRetries, Delay: time.Duration(v.config.Retry.DelayMs) * time.Millisecond}
+	switch strings.ToLower(timestampType) {
+	case "datetime", "date":
+		var nullableTimestamp *string
+		err := retry.QueryRowAndScanWithRetry(db, retryConfig, q, []interface{}{&nullableTimestamp})
+		if err != nil && err != sql.ErrNoRows {
+			return "", fmt.Errorf("查询最小时间戳失败: %w", err)
+		}
+		if err == sql.ErrNoRows || nullableTimestamp == nil {
+			return v.defaultTimestamp(timestampType)
+		}
+		minTimestamp, err := v.formatTimestampValue(*nullableTimestamp, timestampType)
+		if err != nil {
+			return "", fmt.Errorf("格式化时间戳值失败: %w", err)
+		}
+		return minTimestamp, nil
+	case "bigint":
+		var nullableTimestamp *int64
+		err := retry.QueryRowAndScanWithRetry(db, retryConfig, q, []interface{}{&nullableTimestamp})
+		if err != nil && err != sql.ErrNoRows {
+			return "", fmt.Errorf("查询最小时间戳失败: %w", err)
+		}
+		if err == sql.ErrNoRows || nullableTimestamp == nil {
+			return v.defaultTimestamp(timestampType)
+		}
+		return fmt.Sprintf("%d", *nullableTimestamp), nil
+	default:
+		return "", fmt.Errorf("未知的时间戳数据类型: %s", timestampType)
+	}
+}
+
+// defaultTimestamp 表为空时的边界：哨兵最大值
+func (v *ViewBuilder) defaultTimestamp(timestampType string) (string, error) {
+	minTimestamp, err := v.getDefaultTimestampValue(timestampType)
+	if err != nil {
+		return "", fmt.Errorf("获取默认时间戳值失败: %w", err)
+	}
+	return minTimestamp, nil
+}
+
+// ComposeFinalSQL 封装最终的 CREATE/ALTER 视图SQL拼接：queries 为从早到晚的各分支查询，
+// boundaries 为相邻分支之间的边界（比 queries 少一个），第 i 个分支取 [boundaries[i-1], boundaries[i])
+func (v *ViewBuilder) ComposeFinalSQL(sqlType string, queries []string, timestampColumn string, boundaries []string) string {
+	var sb strings.Builder
+	sb.WriteString("as \n")
+	for i, q := range queries {
+		if i > 0 {
+			sb.WriteString(" \nunion all \n")
+		}
+		var conds []string
+		if i > 0 {
+			conds = append(conds, fmt.Sprintf("`%s` >= %s", timestampColumn, boundaries[i-1]))
+		}
+		if i < len(boundaries) {
+			conds = append(conds, fmt.Sprintf("`%s` < %s", timestampColumn, boundaries[i]))
+		}
+		fmt.Fprintf(&sb, "%s \nwhere %s", q, strings.Join(conds, " and "))
+	}
+	sb.WriteString("; \n")
+	query := sb.String()
 	if sqlType == SQLTypeAlter {
-		return "alter view " + body
+		return fmt.Sprintf("alter view `%s`.`%s` %s", v.dbName, v.viewName, query)
 	}
-	return "create view if not exists " + body
+	// 创建时写入归属注释，供 auto-update 识别由 cksr 管理的视图
+	return fmt.Sprintf("create view if not exists `%s`.`%s` comment '%s' %s", v.dbName, v.viewName, ViewOwnerComment, query)
 }
 
 func (v *ViewBuilder) tryMinTimestampViaPartitions(db *sql.DB, timestampColumn, timestampType string) (string, error) {
 	retryConfig := retry.Config{MaxRetries: v.config.Retry.MaxRetries, Delay: time.Duration(v.config.Retry.DelayMs) * time.Millisecond}
 	q := "SELECT partition_name FROM information_schema.partitions WHERE table_schema = ? AND table_name = ? ORDER BY partition_name ASC"
-	rows, err := retry.QueryWithRetry(db, retryConfig, q, v.sr.DBName, v.sr.Name)
+	rows, err := retry.QueryWithRetry(db, retryConfig, q, v.sr.DBName, v.boundarySourceTable())
 	if err != nil {
 		return "", fmt.Errorf("查询分区列表失败: %w", err)
 	}
@@ -653,7 +890,7 @@ func (v *ViewBuilder) tryMinTimestampViaPartitions(db *sql.DB, timestampColumn,
 	}
 
 	for _, pn := range parts {
-		pq := fmt.Sprintf("SELECT MIN(`%s`) FROM `%s`.`%s` PARTITION (`%s`)", timestampColumn, v.sr.DBName, v.sr.Name, pn)
+		pq := fmt.Sprintf("SELECT MIN(`%s`) FROM `%s`.`%s` PARTITION (`%s`)", timestampColumn, v.sr.DBName, v.boundarySourceTable(), pn)
 		switch strings.ToLower(timestampType) {
 		case "datetime", "date":
 			var nullableTimestamp *string
@@ -727,12 +964,12 @@ func (f *CKField) SRBasicType() string {
 
 // 映射到sr字段
 func (v *ViewBuilder) MapSRField(field ckc.FieldConverter, srNameFieldMap map[string]SRField) (SRField, error) {
-	name := field.OriginName()
-	if ckc.IsArrayIPV6(field.OriginType()) || ckc.IsArrayIPV4(field.OriginType()) {
-		name = fmt.Sprintf("%s_int", field.OriginName())
-	} else if ckc.IsIPV6(field.OriginType()) || ckc.IsIPV4(field.OriginType()) {
-		name = fmt.Sprintf("%s_int", field.OriginName())
-	}
+	return mapSRField(field, srNameFieldMap)
+}
+
+// mapSRField 按 srFieldName 在 SR 字段中查找 CK 字段对应的列
+func mapSRField(field ckc.FieldConverter, srNameFieldMap map[string]SRField) (SRField, error) {
+	name := srFieldName(field)
 
 	if v, ok := srNameFieldMap[name]; ok {
 		return v, nil
@@ -740,3 +977,14 @@ func (v *ViewBuilder) MapSRField(field ckc.FieldConverter, srNameFieldMap map[st
 		return SRField{}, fmt.Errorf("map failed, column %s not exists in sr", name)
 	}
 }
+
+// srFieldName 返回CK字段对应的SR列名：IPv4/IPv6 及其数组映射到 <name>_int
+func srFieldName(field ckc.FieldConverter) string {
+	name := field.OriginName()
+	if ckc.IsArrayIPV6(field.OriginType()) || ckc.IsArrayIPV4(field.OriginType()) {
+		name = fmt.Sprintf("%s_int", field.OriginName())
+	} else if ckc.IsIPV6(field.OriginType()) || ckc.IsIPV4(field.OriginType()) {
+		name = fmt.Sprintf("%s_int", field.OriginName())
+	}
+	return name
+}
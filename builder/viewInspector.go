@@ -0,0 +1,337 @@
+package builder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ViewBoundary 视图中 SR 分支的时间边界（`ts` >= value）
+type ViewBoundary struct {
+	Column string `json:"column"`
+	Value  string `json:"value"` // 原样保留：日期时间类型带单引号，bigint 为纯数字
+}
+
+// boundaryPattern 匹配 `col` >= value，列名可带库表限定符；SR 回显的定义可能带括号或大写关键字
+var boundaryPattern = regexp.MustCompile("(?is)(?:`[^`]+`\\.)*`?([A-Za-z_][A-Za-z0-9_]*)`?\\s*\\)?\\s*>=\\s*\\(?\\s*('[^']*'|-?\\d+)")
+
+// ParseViewBoundary 从视图定义中解析时间边界，取最后一个 >= 条件（即 SR 分支）
+func ParseViewBoundary(viewDef string) (ViewBoundary, bool) {
+	matches := boundaryPattern.FindAllStringSubmatch(viewDef, -1)
+	if len(matches) == 0 {
+		return ViewBoundary{}, false
+	}
+	m := matches[len(matches)-1]
+	return ViewBoundary{Column: m[1], Value: strings.TrimSpace(m[2])}, true
+}
+
+// ParseViewBoundaries 从视图定义中按出现顺序解析全部边界（从早到晚）；
+// 每个分支（除最早的一个）都以 >= 条件开始，普通视图只有一个边界，最后一个即 SR 分支的边界
+func ParseViewBoundaries(viewDef string) []ViewBoundary {
+	var out []ViewBoundary
+	for _, m := range boundaryPattern.FindAllStringSubmatch(viewDef, -1) {
+		out = append(out, ViewBoundary{Column: m[1], Value: strings.TrimSpace(m[2])})
+	}
+	return out
+}
+
+// ViewBranch 视图中 UNION ALL 的单个分支
+type ViewBranch struct {
+	Columns []string          // 输出列名（别名），按出现顺序
+	Exprs   map[string]string // 列名 -> 规范化后的表达式
+	From    []string          // FROM 引用（catalog/db/table 各部分，不含反引号）
+	Where   string            // 规范化后的 WHERE 条件
+}
+
+// ViewStructure 视图定义的结构化表示
+type ViewStructure struct {
+	Branches []ViewBranch
+}
+
+var (
+	unionAllPattern = regexp.MustCompile(`(?i)\bunion\s+all\b`)
+	selectPattern   = regexp.MustCompile(`(?i)\bselect\b`)
+	fromPattern     = regexp.MustCompile(`(?i)\bfrom\b`)
+	wherePattern    = regexp.MustCompile(`(?i)\bwhere\b`)
+	asPattern       = regexp.MustCompile(`(?i)\bas\b`)
+	commaPattern    = regexp.MustCompile(`,`)
+	qualifierPrefix = regexp.MustCompile(`\b(?:[A-Za-z_][A-Za-z0-9_]*\.)+([A-Za-z_])`)
+	spacesPattern   = regexp.MustCompile(`\s+`)
+	punctSpaces     = regexp.MustCompile(`\s*([(),<>=\[\]])\s*`)
+)
+
+// ParseViewStructure 将视图SQL（本工具生成的 CREATE/ALTER VIEW，或 SR 回显的视图定义）解析为分支结构
+// 解析只依赖顶层关键字（UNION ALL/SELECT/FROM/WHERE/AS），忽略引号、反引号与括号内部内容
+func ParseViewStructure(viewSQL string) (ViewStructure, error) {
+	var vs ViewStructure
+	body := strings.TrimSpace(viewSQL)
+	body = strings.TrimSuffix(body, ";")
+
+	masked := maskNested(body)
+	// 去掉 CREATE/ALTER VIEW ... AS 头部：从第一个顶层 SELECT 开始
+	loc := selectPattern.FindStringIndex(masked)
+	if loc == nil {
+		return vs, fmt.Errorf("视图定义中未找到 SELECT")
+	}
+	body, masked = body[loc[0]:], masked[loc[0]:]
+
+	for _, part := range splitByPattern(body, masked, unionAllPattern) {
+		branch, err := parseBranch(part.text, part.masked)
+		if err != nil {
+			return vs, err
+		}
+		vs.Branches = append(vs.Branches, branch)
+	}
+	return vs, nil
+}
+
+type maskedPart struct {
+	text   string
+	masked string
+}
+
+// splitByPattern 按顶层分隔符切分，text 与 masked 等长且位置一一对应
+func splitByPattern(text, masked string, sep *regexp.Regexp) []maskedPart {
+	var parts []maskedPart
+	start := 0
+	for _, loc := range sep.FindAllStringIndex(masked, -1) {
+		parts = append(parts, maskedPart{text: text[start:loc[0]], masked: masked[start:loc[0]]})
+		start = loc[1]
+	}
+	parts = append(parts, maskedPart{text: text[start:], masked: masked[start:]})
+	return parts
+}
+
+func parseBranch(text, masked string) (ViewBranch, error) {
+	b := ViewBranch{Exprs: make(map[string]string)}
+	sel := selectPattern.FindStringIndex(masked)
+	from := fromPattern.FindStringIndex(masked)
+	if sel == nil || from == nil || from[0] < sel[1] {
+		return b, fmt.Errorf("无法解析视图分支: %s", strings.TrimSpace(text))
+	}
+	selectText, selectMasked := text[sel[1]:from[0]], masked[sel[1]:from[0]]
+	rest, restMasked := text[from[1]:], masked[from[1]:]
+	if w := wherePattern.FindStringIndex(restMasked); w != nil {
+		b.Where = NormalizeExpr(rest[w[1]:])
+		rest = rest[:w[0]]
+	}
+
+	// FROM 引用：取第一个标识符链，忽略可能的表别名
+	ref := strings.Fields(strings.TrimSpace(strings.Trim(strings.TrimSpace(rest), "()")))
+	if len(ref) > 0 {
+		for _, p := range strings.Split(ref[0], ".") {
+			b.From = append(b.From, strings.Trim(p, "`"))
+		}
+	}
+
+	for _, item := range splitByPattern(selectText, selectMasked, commaPattern) {
+		expr, alias := splitAlias(item.text, item.masked)
+		if alias == "" {
+			continue
+		}
+		if _, dup := b.Exprs[alias]; !dup {
+			b.Columns = append(b.Columns, alias)
+		}
+		b.Exprs[alias] = NormalizeExpr(expr)
+	}
+	return b, nil
+}
+
+// splitAlias 拆分 select 项的表达式与别名；无 AS 时别名取表达式末尾的标识符
+func splitAlias(text, masked string) (string, string) {
+	locs := asPattern.FindAllStringIndex(masked, -1)
+	if len(locs) > 0 {
+		last := locs[len(locs)-1]
+		return text[:last[0]], strings.Trim(strings.TrimSpace(text[last[1]:]), "`")
+	}
+	expr := strings.TrimSpace(text)
+	if expr == "" {
+		return "", ""
+	}
+	parts := strings.Split(expr, ".")
+	return expr, strings.Trim(strings.TrimSpace(parts[len(parts)-1]), "`")
+}
+
+// NormalizeExpr 规范化表达式以便比较：去反引号与库表限定符、统一小写与空白、去除外层冗余括号
+func NormalizeExpr(expr string) string {
+	s := strings.ReplaceAll(expr, "`", "")
+	s = qualifierPrefix.ReplaceAllString(s, "$1")
+	s = spacesPattern.ReplaceAllString(strings.TrimSpace(s), " ")
+	s = punctSpaces.ReplaceAllString(s, "$1")
+	s = strings.ToLower(s)
+	for strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") && wrappedByOuterParens(s) {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// wrappedByOuterParens 判断首尾括号是否相互匹配（而非 "(a) and (b)" 的情况）
+func wrappedByOuterParens(s string) bool {
+	depth := 0
+	for i, c := range maskQuotes(s) {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i != len(s)-1 {
+				return false
+			}
+		}
+	}
+	return depth == 0
+}
+
+// maskNested 将引号、反引号及括号内部的字符替换为下划线，仅保留顶层结构，长度不变
+func maskNested(s string) string {
+	m := []byte(maskQuotes(s))
+	depth := 0
+	for i, c := range m {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+			continue
+		}
+		if depth > 0 {
+			m[i] = '_'
+		}
+	}
+	return string(m)
+}
+
+// maskQuotes 将单引号字符串与反引号标识符的内容替换为下划线，长度不变
+func maskQuotes(s string) string {
+	m := []byte(s)
+	var quote byte
+	for i := 0; i < len(m); i++ {
+		c := s[i]
+		if quote == 0 {
+			if c == '\'' || c == '`' {
+				quote = c
+				m[i] = '_'
+			}
+			continue
+		}
+		m[i] = '_'
+		if c == '\\' && quote == '\'' && i+1 < len(m) {
+			i++
+			m[i] = '_'
+			continue
+		}
+		if c == quote {
+			quote = 0
+		}
+	}
+	return string(m)
+}
+
+// ExprChange 单列表达式差异
+type ExprChange struct {
+	Branch   int    `json:"branch"` // 分支序号，从最早的数据层开始（普通视图 0 为 CK 分支，1 为 SR 分支）
+	Column   string `json:"column"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ValueChange 单值差异
+type ValueChange struct {
+	Branch   int    `json:"branch"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ViewDrift 线上视图与期望视图的结构差异
+type ViewDrift struct {
+	BranchCount        *ValueChange  `json:"branch_count,omitempty"`
+	MissingColumns     []string      `json:"missing_columns,omitempty"` // 期望存在但线上视图缺失
+	ExtraColumns       []string      `json:"extra_columns,omitempty"`   // 线上视图多出
+	ChangedExpressions []ExprChange  `json:"changed_expressions,omitempty"`
+	CatalogChange      *ValueChange  `json:"catalog_change,omitempty"`
+	ReferenceChanges   []ValueChange `json:"reference_changes,omitempty"`
+	PredicateChanges   []ValueChange `json:"predicate_changes,omitempty"`
+}
+
+// HasDrift 是否存在任何差异
+func (d ViewDrift) HasDrift() bool {
+	return d.BranchCount != nil || len(d.MissingColumns) > 0 || len(d.ExtraColumns) > 0 ||
+		len(d.ChangedExpressions) > 0 || d.CatalogChange != nil ||
+		len(d.ReferenceChanges) > 0 || len(d.PredicateChanges) > 0
+}
+
+var stringTypePattern = regexp.MustCompile(`\bstring\b`)
+
+// exprKey 表达式比较键：在规范化基础上去掉括号与空白，并统一 SR 回显时改写的类型名
+func exprKey(normalized string) string {
+	s := stringTypePattern.ReplaceAllString(normalized, "varchar")
+	return strings.NewReplacer("(", "", ")", "", " ", "").Replace(s)
+}
+
+// CompareViews 结构化比较期望视图与线上视图：列清单、各分支表达式、FROM 引用与时间边界谓词
+func CompareViews(expected, actual ViewStructure) ViewDrift {
+	var d ViewDrift
+	if len(expected.Branches) != len(actual.Branches) {
+		d.BranchCount = &ValueChange{
+			Branch:   -1,
+			Expected: fmt.Sprintf("%d", len(expected.Branches)),
+			Actual:   fmt.Sprintf("%d", len(actual.Branches)),
+		}
+	}
+	if len(expected.Branches) == 0 || len(actual.Branches) == 0 {
+		return d
+	}
+
+	// 列清单以首个分支为准（各分支列一致由 UNION ALL 保证）
+	expCols := expected.Branches[0].Columns
+	actCols := actual.Branches[0].Columns
+	actSet := make(map[string]bool, len(actCols))
+	for _, c := range actCols {
+		actSet[c] = true
+	}
+	expSet := make(map[string]bool, len(expCols))
+	for _, c := range expCols {
+		expSet[c] = true
+		if !actSet[c] {
+			d.MissingColumns = append(d.MissingColumns, c)
+		}
+	}
+	for _, c := range actCols {
+		if !expSet[c] {
+			d.ExtraColumns = append(d.ExtraColumns, c)
+		}
+	}
+
+	n := len(expected.Branches)
+	if len(actual.Branches) < n {
+		n = len(actual.Branches)
+	}
+	for i := 0; i < n; i++ {
+		eb, ab := expected.Branches[i], actual.Branches[i]
+		for _, c := range eb.Columns {
+			ae, ok := ab.Exprs[c]
+			if !ok {
+				continue
+			}
+			if exprKey(eb.Exprs[c]) != exprKey(ae) {
+				d.ChangedExpressions = append(d.ChangedExpressions, ExprChange{Branch: i, Column: c, Expected: eb.Exprs[c], Actual: ae})
+			}
+		}
+
+		// 三段式引用（catalog.db.table）单独报告 Catalog 变化
+		if len(eb.From) == 3 && len(ab.From) == 3 && eb.From[0] != ab.From[0] {
+			d.CatalogChange = &ValueChange{Branch: i, Expected: eb.From[0], Actual: ab.From[0]}
+		}
+		if strings.Join(eb.From, ".") != strings.Join(ab.From, ".") &&
+			!(len(eb.From) == 3 && len(ab.From) == 3 && strings.Join(eb.From[1:], ".") == strings.Join(ab.From[1:], ".")) {
+			d.ReferenceChanges = append(d.ReferenceChanges, ValueChange{Branch: i, Expected: strings.Join(eb.From, "."), Actual: strings.Join(ab.From, ".")})
+		}
+
+		if exprKey(eb.Where) != exprKey(ab.Where) {
+			d.PredicateChanges = append(d.PredicateChanges, ValueChange{Branch: i, Expected: eb.Where, Actual: ab.Where})
+		}
+	}
+	return d
+}
@@ -0,0 +1,287 @@
+package builder
+
+import (
+	"reflect"
+	"testing"
+)
+
+// generatedView 本工具生成的普通视图：CK 分支走 external catalog，SR 分支以 >= 边界开始
+const generatedView = "CREATE VIEW `sr_db`.`orders` AS " +
+	"SELECT `id` AS `id`, CAST(`amount` AS DECIMAL(18, 2)) AS `amount`, `ts` AS `ts` " +
+	"FROM `ck_catalog`.`ck_db`.`orders` WHERE `ts` < '2025-03-01 00:00:00' " +
+	"UNION ALL " +
+	"SELECT `id` AS `id`, `amount` AS `amount`, `ts` AS `ts` " +
+	"FROM `sr_db`.`orders_local` WHERE `ts` >= '2025-03-01 00:00:00';"
+
+// echoedView SR 回显的同一视图：带库表限定符、括号、换行与大写关键字，字符串类型改写为 varchar
+const echoedView = "SELECT `ck_catalog`.`ck_db`.`orders`.`id` AS `id`, " +
+	"CAST(`ck_catalog`.`ck_db`.`orders`.`amount` AS DECIMAL(18,2)) AS `amount`, `ck_catalog`.`ck_db`.`orders`.`ts` AS `ts`\n" +
+	"FROM `ck_catalog`.`ck_db`.`orders`\nWHERE (`ck_catalog`.`ck_db`.`orders`.`ts` < '2025-03-01 00:00:00')\n" +
+	"UNION ALL\n" +
+	"SELECT `sr_db`.`orders_local`.`id` AS `id`, `sr_db`.`orders_local`.`amount` AS `amount`, `sr_db`.`orders_local`.`ts` AS `ts`\n" +
+	"FROM `sr_db`.`orders_local`\nWHERE (`sr_db`.`orders_local`.`ts` >= '2025-03-01 00:00:00')"
+
+func TestNormalizeExpr(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"去反引号与库表限定符", "`db`.`t`.`col`", "col"},
+		{"统一空白与大小写", "  CAST( `ts`  AS  DATETIME )", "cast(ts as datetime)"},
+		{"去除多层外层括号", "((`a` + `b`))", "a + b"},
+		{"首尾括号不匹配时保留", "(`a` > 1) AND (`b` < 2)", "(a>1)and(b<2)"},
+		{"引号内的括号不影响外层判断", "(`x` = ')')", "x=')'"},
+		{"比较运算符两侧空白", "`ts` >= '2025-03-01 00:00:00'", "ts>='2025-03-01 00:00:00'"},
+		{"数字中的小数点不视为限定符", "`t`.`price` * 1.5", "price * 1.5"},
+		{"空表达式", "   ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeExpr(tt.in); got != tt.want {
+				t.Fatalf("NormalizeExpr(%q) = %q，期望 %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseViewBoundary(t *testing.T) {
+	tests := []struct {
+		name   string
+		def    string
+		want   ViewBoundary
+		wantOK bool
+	}{
+		{"生成的视图取 SR 分支边界", generatedView, ViewBoundary{Column: "ts", Value: "'2025-03-01 00:00:00'"}, true},
+		{"SR 回显带限定符与括号", echoedView, ViewBoundary{Column: "ts", Value: "'2025-03-01 00:00:00'"}, true},
+		{"bigint 边界", "SELECT * FROM t WHERE event_time >= 1740787200", ViewBoundary{Column: "event_time", Value: "1740787200"}, true},
+		{"负数边界", "SELECT * FROM t WHERE `seq` >= -1", ViewBoundary{Column: "seq", Value: "-1"}, true},
+		{"值带括号", "SELECT * FROM t WHERE `ts` >= ( '2025-03-01' )", ViewBoundary{Column: "ts", Value: "'2025-03-01'"}, true},
+		{"小写关键字跨行", "select *\nfrom t\nwhere `dt`\n>=\n'2025-03-01'", ViewBoundary{Column: "dt", Value: "'2025-03-01'"}, true},
+		{"没有 >= 条件", "SELECT * FROM t WHERE `ts` < '2025-03-01'", ViewBoundary{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseViewBoundary(tt.def)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("ParseViewBoundary = %+v, %v，期望 %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseViewBoundaries(t *testing.T) {
+	tiered := "SELECT * FROM `ck_catalog`.`ck_db`.`orders` WHERE `ts` < '2024-01-01' " +
+		"UNION ALL SELECT * FROM `archive_catalog`.`db`.`orders` WHERE `ts` >= '2024-01-01' AND `ts` < '2025-01-01' " +
+		"UNION ALL SELECT * FROM `sr_db`.`orders_local` WHERE `ts` >= '2025-01-01'"
+	tests := []struct {
+		name string
+		def  string
+		want []ViewBoundary
+	}{
+		{"普通视图一个边界", generatedView, []ViewBoundary{{Column: "ts", Value: "'2025-03-01 00:00:00'"}}},
+		{"多层视图按出现顺序从早到晚", tiered, []ViewBoundary{
+			{Column: "ts", Value: "'2024-01-01'"},
+			{Column: "ts", Value: "'2025-01-01'"},
+		}},
+		{"SR 回显", echoedView, []ViewBoundary{{Column: "ts", Value: "'2025-03-01 00:00:00'"}}},
+		{"没有边界", "SELECT 1 AS `x` FROM t", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseViewBoundaries(tt.def); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseViewBoundaries = %+v，期望 %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseViewStructure(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		want    []ViewBranch
+		wantErr bool
+	}{
+		{
+			name: "生成的视图",
+			sql:  generatedView,
+			want: []ViewBranch{
+				{
+					Columns: []string{"id", "amount", "ts"},
+					Exprs:   map[string]string{"id": "id", "amount": "cast(amount as decimal(18,2))", "ts": "ts"},
+					From:    []string{"ck_catalog", "ck_db", "orders"},
+					Where:   "ts<'2025-03-01 00:00:00'",
+				},
+				{
+					Columns: []string{"id", "amount", "ts"},
+					Exprs:   map[string]string{"id": "id", "amount": "amount", "ts": "ts"},
+					From:    []string{"sr_db", "orders_local"},
+					Where:   "ts>='2025-03-01 00:00:00'",
+				},
+			},
+		},
+		{
+			name: "无 AS 时别名取末尾标识符，子查询内的关键字不参与切分",
+			sql: "ALTER VIEW v AS SELECT t.`a`, `b`, (SELECT max(x) FROM y WHERE z = 1) AS `m` " +
+				"FROM (`db`.`t`) WHERE `a` IN (SELECT a FROM w UNION ALL SELECT a FROM u)",
+			want: []ViewBranch{
+				{
+					Columns: []string{"a", "b", "m"},
+					Exprs:   map[string]string{"a": "a", "b": "b", "m": "select max(x)from y where z=1"},
+					From:    []string{"db", "t"},
+					Where:   "a in(select a from w union all select a from u)",
+				},
+			},
+		},
+		{
+			name: "引号内的关键字不参与切分",
+			sql:  "SELECT 'a UNION ALL b' AS `s`, `from` AS `f` FROM t WHERE `s` <> 'x where y'",
+			want: []ViewBranch{
+				{
+					Columns: []string{"s", "f"},
+					Exprs:   map[string]string{"s": "'a union all b'", "f": "from"},
+					From:    []string{"t"},
+					Where:   "s<>'x where y'",
+				},
+			},
+		},
+		{name: "没有 SELECT", sql: "CREATE VIEW v", wantErr: true},
+		{name: "分支缺少 FROM", sql: "SELECT 1 AS `x` UNION ALL SELECT 2 AS `x`", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseViewStructure(tt.sql)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("期望解析失败，实际得到 %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析视图失败: %v", err)
+			}
+			if !reflect.DeepEqual(got.Branches, tt.want) {
+				t.Fatalf("ParseViewStructure =\n%+v\n期望\n%+v", got.Branches, tt.want)
+			}
+		})
+	}
+}
+
+func mustStructure(t *testing.T, sql string) ViewStructure {
+	t.Helper()
+	vs, err := ParseViewStructure(sql)
+	if err != nil {
+		t.Fatalf("解析视图失败: %v", err)
+	}
+	return vs
+}
+
+func TestCompareViews(t *testing.T) {
+	branch := func(from, where string, exprs ...string) string {
+		sql := "SELECT "
+		for i := 0; i < len(exprs); i += 2 {
+			if i > 0 {
+				sql += ", "
+			}
+			sql += exprs[i] + " AS `" + exprs[i+1] + "`"
+		}
+		return sql + " FROM " + from + " WHERE " + where
+	}
+	view := func(branches ...string) string {
+		sql := "CREATE VIEW `sr_db`.`orders` AS "
+		for i, b := range branches {
+			if i > 0 {
+				sql += " UNION ALL "
+			}
+			sql += b
+		}
+		return sql
+	}
+	ck := branch("`ck_catalog`.`ck_db`.`orders`", "`ts` < '2025-03-01 00:00:00'", "`id`", "id", "CAST(`name` AS STRING)", "name")
+	sr := branch("`sr_db`.`orders_local`", "`ts` >= '2025-03-01 00:00:00'", "`id`", "id", "`name`", "name")
+	expected := view(ck, sr)
+
+	tests := []struct {
+		name   string
+		actual string
+		want   ViewDrift
+	}{
+		{name: "生成的视图与自身一致", actual: expected},
+		{
+			name: "SR 回显的括号、限定符与类型名改写不算差异",
+			actual: view(
+				branch("`ck_catalog`.`ck_db`.`orders`", "(`ck_db`.`orders`.`ts` < '2025-03-01 00:00:00')",
+					"`ck_db`.`orders`.`id`", "id", "CAST(`ck_db`.`orders`.`name` AS VARCHAR)", "name"),
+				branch("`sr_db`.`orders_local`", "(`sr_db`.`orders_local`.`ts` >= '2025-03-01 00:00:00')",
+					"`sr_db`.`orders_local`.`id`", "id", "`sr_db`.`orders_local`.`name`", "name"),
+			),
+		},
+		{
+			name:   "分支数不同",
+			actual: view(sr),
+			want: ViewDrift{
+				BranchCount:      &ValueChange{Branch: -1, Expected: "2", Actual: "1"},
+				ReferenceChanges: []ValueChange{{Branch: 0, Expected: "ck_catalog.ck_db.orders", Actual: "sr_db.orders_local"}},
+				ChangedExpressions: []ExprChange{
+					{Branch: 0, Column: "name", Expected: "cast(name as string)", Actual: "name"},
+				},
+				PredicateChanges: []ValueChange{{Branch: 0, Expected: "ts<'2025-03-01 00:00:00'", Actual: "ts>='2025-03-01 00:00:00'"}},
+			},
+		},
+		{
+			name: "列缺失与多出",
+			actual: view(
+				branch("`ck_catalog`.`ck_db`.`orders`", "`ts` < '2025-03-01 00:00:00'", "`id`", "id", "`extra`", "extra"),
+				branch("`sr_db`.`orders_local`", "`ts` >= '2025-03-01 00:00:00'", "`id`", "id", "`extra`", "extra"),
+			),
+			want: ViewDrift{MissingColumns: []string{"name"}, ExtraColumns: []string{"extra"}},
+		},
+		{
+			name: "Catalog 变化单独报告",
+			actual: view(
+				branch("`old_catalog`.`ck_db`.`orders`", "`ts` < '2025-03-01 00:00:00'", "`id`", "id", "CAST(`name` AS STRING)", "name"),
+				sr,
+			),
+			want: ViewDrift{CatalogChange: &ValueChange{Branch: 0, Expected: "ck_catalog", Actual: "old_catalog"}},
+		},
+		{
+			name: "引用表与边界变化",
+			actual: view(
+				branch("`ck_catalog`.`ck_db`.`orders_v2`", "`ts` < '2025-02-01 00:00:00'", "`id`", "id", "CAST(`name` AS STRING)", "name"),
+				branch("`sr_db`.`orders_local`", "`ts` >= '2025-02-01 00:00:00'", "`id`", "id", "`name`", "name"),
+			),
+			want: ViewDrift{
+				ReferenceChanges: []ValueChange{{Branch: 0, Expected: "ck_catalog.ck_db.orders", Actual: "ck_catalog.ck_db.orders_v2"}},
+				PredicateChanges: []ValueChange{
+					{Branch: 0, Expected: "ts<'2025-03-01 00:00:00'", Actual: "ts<'2025-02-01 00:00:00'"},
+					{Branch: 1, Expected: "ts>='2025-03-01 00:00:00'", Actual: "ts>='2025-02-01 00:00:00'"},
+				},
+			},
+		},
+		{
+			name: "表达式变化",
+			actual: view(
+				branch("`ck_catalog`.`ck_db`.`orders`", "`ts` < '2025-03-01 00:00:00'", "`id`", "id", "`name`", "name"),
+				sr,
+			),
+			want: ViewDrift{ChangedExpressions: []ExprChange{{Branch: 0, Column: "name", Expected: "cast(name as string)", Actual: "name"}}},
+		},
+	}
+	exp := mustStructure(t, expected)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CompareViews(exp, mustStructure(t, tt.actual))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("CompareViews =\n%+v\n期望\n%+v", got, tt.want)
+			}
+			if got.HasDrift() != !reflect.DeepEqual(tt.want, ViewDrift{}) {
+				t.Fatalf("HasDrift = %v 与差异内容不符", got.HasDrift())
+			}
+		})
+	}
+
+	if d := CompareViews(ViewStructure{}, exp); d.BranchCount == nil || !d.HasDrift() {
+		t.Fatalf("线上视图无法解析出分支时应报告分支数差异: %+v", d)
+	}
+}
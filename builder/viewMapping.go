@@ -0,0 +1,136 @@
+package builder
+
+import (
+	"sort"
+	"strings"
+
+	ckc "example.com/migrationLib/convert"
+)
+
+// 视图列映射规则，与 CKField.GenClause / defaultClauseForSRField 的分支一一对应
+const (
+	RuleDirect      = "direct"             // 同名直接引用
+	RuleAddedColumn = "added_column"       // 引用 init 时在CK新增的列，并别名为SR列名
+	RuleIPInt       = "ip_to_int"          // IPv4/IPv6 映射到SR的 <name>_int 列
+	RuleArrayIPv6   = "array_ipv6_split"   // Array(IPv6)：split 后逐个 CAST 为 LARGEINT
+	RuleStringArray = "string_array_split" // 字符串数组：split 为 ARRAY<String>
+	RuleArrayCast   = "array_split_cast"   // 其他数组：split 后按SR元素类型 CAST
+	RuleSRDefault   = "sr_only_default"    // SR 独有列：CK 侧以列的 DEFAULT 表达式补齐
+	RuleSRNull      = "sr_only_null"       // SR 独有列：CK 侧以 CAST(NULL AS type) 补齐
+)
+
+// ColumnMapping 视图中一列的映射说明
+type ColumnMapping struct {
+	Column   string `json:"column"`
+	CKColumn string `json:"ck_column,omitempty"`
+	CKType   string `json:"ck_type,omitempty"`
+	SRColumn string `json:"sr_column"`
+	SRType   string `json:"sr_type"`
+	CKClause string `json:"ck_clause"`
+	SRClause string `json:"sr_clause"`
+	Rule     string `json:"rule"`
+}
+
+// SkippedColumn 未进入视图的CK列
+type SkippedColumn struct {
+	CKColumn string `json:"ck_column"`
+	CKType   string `json:"ck_type"`
+	SRColumn string `json:"sr_column"`
+	Reason   string `json:"reason"`
+}
+
+// DefaultedColumn 在CK侧以默认值补齐的SR独有列
+type DefaultedColumn struct {
+	SRColumn string `json:"sr_column"`
+	SRType   string `json:"sr_type"`
+	Default  string `json:"default"`
+}
+
+// MappingExplanation 一张表的 CK→SR 列映射说明
+type MappingExplanation struct {
+	Columns         []ColumnMapping   `json:"columns"`
+	SkippedCK       []SkippedColumn   `json:"skipped_ck_columns"`
+	DefaultedSR     []DefaultedColumn `json:"defaulted_sr_columns"`
+	ValidationError string            `json:"validation_error,omitempty"`
+}
+
+// ExplainMapping 执行与建视图相同的字段映射，返回逐列的映射说明（不访问数据库）
+// 映射校验失败时不返回错误，而是记录在 ValidationError 中，便于排查
+func (v *ViewBuilder) ExplainMapping() *MappingExplanation {
+	exp := &MappingExplanation{}
+	if err := v.PrepareAndValidate(); err != nil {
+		exp.ValidationError = err.Error()
+	}
+
+	// ck.fields 与 sr.fields 按相同顺序追加，下标一一对应；SR 独有列补在末尾
+	mappedCount := len(v.ck.fields) - len(v.defaulted)
+	for i, cf := range v.ck.fields {
+		if i >= len(v.sr.fields) {
+			break
+		}
+		sf := v.sr.fields[i]
+		m := ColumnMapping{
+			Column:   sf.Name,
+			SRColumn: sf.Name,
+			SRType:   sf.Type,
+			CKClause: cf.Clause,
+			SRClause: sf.Clause,
+		}
+		if i < mappedCount {
+			m.CKColumn = cf.OriginName()
+			m.CKType = cf.OriginType()
+			m.Rule = ckFieldRule(cf)
+		} else {
+			m.Rule = srOnlyRule(sf)
+		}
+		exp.Columns = append(exp.Columns, m)
+	}
+
+	for _, fc := range v.skipped {
+		exp.SkippedCK = append(exp.SkippedCK, SkippedColumn{
+			CKColumn: fc.OriginName(),
+			CKType:   fc.OriginType(),
+			SRColumn: srFieldName(fc),
+			Reason:   "StarRocks 中不存在对应列",
+		})
+	}
+
+	for _, sf := range v.defaulted {
+		d := DefaultedColumn{SRColumn: sf.Name, SRType: sf.Type, Default: "NULL"}
+		if srOnlyRule(sf) == RuleSRDefault {
+			d.Default = strings.TrimSpace(sf.DefaultExpr)
+		}
+		exp.DefaultedSR = append(exp.DefaultedSR, d)
+	}
+	// SR 独有列来自 map 遍历，排序保证输出稳定
+	sort.Slice(exp.DefaultedSR, func(i, j int) bool { return exp.DefaultedSR[i].SRColumn < exp.DefaultedSR[j].SRColumn })
+
+	return exp
+}
+
+// ckFieldRule 返回CK字段生成子句时命中的规则
+func ckFieldRule(f CKField) string {
+	t := f.OriginType()
+	switch {
+	case ckc.IsArrayIPV6(t):
+		return RuleArrayIPv6
+	case ckc.IsStringArray(t):
+		return RuleStringArray
+	case ckc.IsArray(t):
+		return RuleArrayCast
+	case ckc.IsIPV4(t) || ckc.IsIPV6(t):
+		return RuleIPInt
+	case f.IsAddedColumn():
+		return RuleAddedColumn
+	default:
+		return RuleDirect
+	}
+}
+
+// srOnlyRule 返回SR独有列在CK侧补齐时命中的规则
+func srOnlyRule(sf SRField) string {
+	if strings.EqualFold(sf.DefaultKind, "DEFAULT") && strings.TrimSpace(sf.DefaultExpr) != "" {
+		return RuleSRDefault
+	}
+	return RuleSRNull
+}
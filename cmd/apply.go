@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"cksr/internal/applyrun"
+	"cksr/internal/planrun"
+	"cksr/logger"
+
+	mdb "example.com/migrationLib/database"
+	"github.com/spf13/cobra"
+)
+
+// NewApplyCmd 按 plan --out 保存的计划文件执行，执行前校验元数据指纹
+func NewApplyCmd() *cobra.Command {
+	var blackoutArgs blackoutFlag
+	cmd := &cobra.Command{
+		Use:   "apply <plan.json>",
+		Short: "按已审核的计划文件执行 init/rollback（环境变化时拒绝执行）",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			doc, err := planrun.ReadFile(args[0])
+			if err != nil {
+				return WrapConfigErr(err)
+			}
+			if doc.Kind == planrun.KindRollback {
+				logger.SetLogMode(logger.ModeRollback)
+			} else {
+				logger.SetLogMode(logger.ModeInit)
+			}
+			cfg, err := LoadConfigAndInitLogging(cmd)
+			if err != nil {
+				return err
+			}
+			defer logger.CloseLogFile()
+			// 统一在退出前关闭连接池
+			defer mdb.CloseAll()
+
+			if err := blackoutArgs.check(cfg); err != nil {
+				return err
+			}
+
+			logger.Info("开始按计划文件执行: %s", args[0])
+			return applyrun.Run(cfg, doc)
+		},
+	}
+	blackoutArgs.register(cmd)
+	return cmd
+}
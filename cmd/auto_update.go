@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"errors"
+
 	"cksr/internal/autoupdaterun"
 	"cksr/logger"
 
@@ -10,9 +12,12 @@ import (
 
 // NewAutoUpdateCmd 启动常驻视图更新器（auto-update）
 func NewAutoUpdateCmd() *cobra.Command {
-	return &cobra.Command{
+	var scopeArgs scopeFlags
+	var once bool
+	var blackoutArgs blackoutFlag
+	cmd := &cobra.Command{
 		Use:   "auto-update",
-		Short: "常驻：启动按Cron的视图自动更新器",
+		Short: "常驻：启动按Cron的视图自动更新器（--once 执行一个周期后退出）",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// 设置日志模式为 UPDATE
 			cfg, err := LoadConfigAndInitLogging(cmd)
@@ -20,11 +25,31 @@ func NewAutoUpdateCmd() *cobra.Command {
 				return err
 			}
 			defer logger.CloseLogFile()
+			scope, err := scopeArgs.resolve(cfg)
+			if err != nil {
+				return err
+			}
 			// 统一在退出前关闭连接池
 			defer mdb.CloseAll()
 
-			logger.Info("启动常驻视图更新器 (auto-update)...")
-			return autoupdaterun.Run(cfg)
+			if once {
+				if err := blackoutArgs.check(cfg); err != nil {
+					return err
+				}
+				logger.Info("单次运行视图更新器 (auto-update --once)...")
+				err = autoupdaterun.RunOnce(cfg, scope)
+			} else {
+				logger.Info("启动常驻视图更新器 (auto-update)...")
+				err = autoupdaterun.Run(cfg, scope)
+			}
+			if errors.Is(err, autoupdaterun.ErrInvalidSchedule) {
+				return WrapConfigErr(err)
+			}
+			return err
 		},
 	}
+	scopeArgs.register(cmd)
+	blackoutArgs.register(cmd)
+	cmd.Flags().BoolVar(&once, "once", false, "执行一个完整周期（全局与各表级调度各一次）后退出，任一失败时退出码为 1，处于维护窗口时退出码为 4（--ignore-blackout 仅对 --once 生效），适用于 Kubernetes CronJob")
+	return cmd
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cksr/internal/blackout"
+	"cksr/internal/settings"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+	"github.com/spf13/cobra"
+)
+
+// blackoutFlag init/update/rollback/apply 与 auto-update --once 共用的维护窗口参数
+type blackoutFlag struct {
+	ignore bool
+}
+
+// register 注册 --ignore-blackout
+func (f *blackoutFlag) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.ignore, "ignore-blackout", false, "在 blackout 维护窗口内仍然执行（会执行 DDL，请与 DBA 确认）")
+}
+
+// check 处于维护窗口时拒绝执行（包装 ErrBlackout，退出码 4），指定 --ignore-blackout 时仅输出警告；窗口配置非法时视为配置错误
+func (f *blackoutFlag) check(cfg *mcfg.Config) error {
+	windows, err := blackout.New(settings.Of(cfg).Blackout)
+	if err != nil {
+		return WrapConfigErr(err)
+	}
+	if err := windows.Check(); err != nil {
+		if f.ignore {
+			logger.Warn("%v，已指定 --ignore-blackout，继续执行", err)
+			return nil
+		}
+		return fmt.Errorf("%w: %w，禁止执行 DDL；确需执行请指定 --ignore-blackout", ErrBlackout, err)
+	}
+	return nil
+}
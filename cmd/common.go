@@ -6,6 +6,7 @@ import (
 	"log"
 	"strings"
 
+	"cksr/internal/configload"
 	"cksr/logger"
 
 	"github.com/spf13/cobra"
@@ -19,14 +20,22 @@ import (
 type ExitCode int
 
 const (
-	ExitOK      ExitCode = 0
-	ExitRuntime ExitCode = 1
-	ExitConfig  ExitCode = 2
+	ExitOK       ExitCode = 0
+	ExitRuntime  ExitCode = 1
+	ExitConfig   ExitCode = 2
+	ExitDrift    ExitCode = 3
+	ExitBlackout ExitCode = 4
 )
 
 // ErrConfig 标识配置相关错误的哨兵错误
 var ErrConfig = errors.New("CONFIG_ERROR")
 
+// ErrDrift 标识检测到视图漂移的哨兵错误
+var ErrDrift = errors.New("DRIFT_DETECTED")
+
+// ErrBlackout 标识因处于维护窗口而未执行的哨兵错误
+var ErrBlackout = errors.New("BLACKOUT_ACTIVE")
+
 // WrapConfigErr 包装配置相关错误以便统一解析退出码
 func WrapConfigErr(err error) error {
 	if err == nil {
@@ -43,46 +52,82 @@ func ResolveExitCode(err error) int {
 	if errors.Is(err, ErrConfig) {
 		return int(ExitConfig)
 	}
+	if errors.Is(err, ErrDrift) {
+		return int(ExitDrift)
+	}
+	if errors.Is(err, ErrBlackout) {
+		return int(ExitBlackout)
+	}
 	return int(ExitRuntime)
 }
 
-// ApplyEffectiveLogLevel 统一日志等级来源优先级：CONFIG > FLAG
+// applyEffectiveLogLevel 按合并后的配置设置日志等级（来源优先级：flag > env > file > default）
 // 要求：
 // - 当提供的日志级别非法时，必须返回错误，不允许静默降级
-// - 若非法来源为配置文件，返回的错误会包装为配置错误，参与统一退出码解析
-func applyEffectiveLogLevel(flagLevel string) error {
-	levelStr := flagLevel
-	normalized := strings.ToUpper(strings.TrimSpace(levelStr))
+// - 返回的错误会包装为配置错误，参与统一退出码解析
+func applyEffectiveLogLevel(level string, source string) error {
+	normalized := strings.ToUpper(strings.TrimSpace(level))
 	switch normalized {
 	case "SILENT", "ERROR", "WARN", "WARNING", "INFO", "DEBUG":
 		logger.SetLogLevel(logger.ParseLogLevel(normalized))
-		logger.Info("日志级别设置为: %s (来源: %s)", logger.LogLevelString(logger.GetCurrentLevel()), "cmd.log_level")
+		logger.Info("日志级别设置为: %s (来源: %s)", logger.LogLevelString(logger.GetCurrentLevel()), source)
 		return nil
 	default:
-		return fmt.Errorf("非法日志级别: %q (来源: %s)，允许值: SILENT, ERROR, WARN, INFO, DEBUG", levelStr, "cmd.log_level")
+		return fmt.Errorf("非法日志级别: %q (来源: %s)，允许值: SILENT, ERROR, WARN, INFO, DEBUG", level, source)
 	}
 }
+
+// LoadConfigAndInitLogging 读取全局参数，加载配置并初始化日志等级
 func LoadConfigAndInitLogging(cmd *cobra.Command) (*mcfg.Config, error) {
-	flagLevel, flagErr := cmd.Root().PersistentFlags().GetString("log-level")
-	if flagErr != nil {
-		return nil, flagErr
+	opts, err := configOptionsFromFlags(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return LoadConfigAndInitLog(opts)
+}
+
+// configOptionsFromFlags 从全局参数构建配置加载选项，仅显式设置的参数参与覆盖
+func configOptionsFromFlags(cmd *cobra.Command) (configload.Options, error) {
+	flags := cmd.Root().PersistentFlags()
+	var opts configload.Options
+	var err error
+	if opts.Path, err = flags.GetString("config"); err != nil {
+		return opts, err
+	}
+	if opts.InlineJSON, err = flags.GetString("config-json"); err != nil {
+		return opts, err
+	}
+	if flags.Changed("log-level") {
+		level, err := flags.GetString("log-level")
+		if err != nil {
+			return opts, err
+		}
+		opts.Flags = append(opts.Flags, configload.Override{Path: "log.log_level", Value: level, Source: configload.SourceFlag + ":--log-level"})
 	}
-	inlineJSON, jsonErr := cmd.Root().PersistentFlags().GetString("config-json")
-	if jsonErr != nil {
-		return nil, jsonErr
+	return opts, nil
+}
+
+// LoadConfigAndInitLog 按 flag > env > file > default 合并配置，设置日志等级并在 DEBUG 级别输出各配置项来源
+func LoadConfigAndInitLog(opts configload.Options) (*mcfg.Config, error) {
+	res, err := loadConfigResult(opts)
+	if err != nil {
+		return nil, err
 	}
-	return LoadInlineConfigAndInitLog(flagLevel, inlineJSON)
+	return res.Config, nil
 }
 
-func LoadInlineConfigAndInitLog(flagLevel string, inlineJSON string) (*mcfg.Config, error) {
-	cfg, err := mcfg.ParseConfigBytes([]byte(inlineJSON))
+// loadConfigResult 同 LoadConfigAndInitLog，但返回包含来源与合并文档的完整加载结果
+func loadConfigResult(opts configload.Options) (*configload.Result, error) {
+	res, err := configload.Load(opts)
 	if err != nil {
 		return nil, WrapConfigErr(err)
 	}
-	if err := applyEffectiveLogLevel(flagLevel); err != nil {
+	cfg := res.Config
+	if err := applyEffectiveLogLevel(cfg.Log.LogLevel, res.Sources["log.log_level"]); err != nil {
 		return nil, WrapConfigErr(err)
 	}
+	res.LogSources()
 	// 忽略配置中的文件日志设置，统一使用标准输出
-	log.Printf("配置加载完成，数据库对数量: %d", len(cfg.DatabasePairs))
-	return cfg, nil
+	log.Printf("配置加载完成（来源: %s），数据库对数量: %d", res.Origin, len(cfg.DatabasePairs))
+	return res, nil
 }
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cksr/internal/configrun"
+	"cksr/logger"
+
+	mdb "example.com/migrationLib/database"
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCmd 配置相关子命令
+func NewConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "配置校验与 JSON Schema",
+	}
+	cmd.AddCommand(newConfigValidateCmd())
+	cmd.AddCommand(newConfigSchemaCmd())
+	return cmd
+}
+
+func newConfigValidateCmd() *cobra.Command {
+	var (
+		format  string
+		offline bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "按与实际命令相同的方式加载配置，并执行语义校验（存在错误时以配置错误退出）",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != configrun.FormatText && format != configrun.FormatJSON {
+				return WrapConfigErr(fmt.Errorf("未知的输出格式: %s，仅支持 text、json", format))
+			}
+			// 日志输出到标准错误，标准输出只承载校验结果
+			logger.RedirectToStderr()
+			opts, err := configOptionsFromFlags(cmd)
+			if err != nil {
+				return err
+			}
+			res, err := loadConfigResult(opts)
+			if err != nil {
+				return err
+			}
+			defer logger.CloseLogFile()
+			// 统一在退出前关闭连接池
+			defer mdb.CloseAll()
+
+			report := configrun.Validate(res, configrun.Options{Offline: offline})
+			if err := configrun.Render(os.Stdout, report, format); err != nil {
+				return err
+			}
+			if !report.Valid {
+				return WrapConfigErr(fmt.Errorf("配置校验未通过: %d 个错误", report.Errors()))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", configrun.FormatText, "输出格式 (text, json)")
+	cmd.Flags().BoolVar(&offline, "offline", false, "跳过需要连接数据库的检查（ignore_tables 是否存在）")
+
+	return cmd
+}
+
+func newConfigSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "输出配置文件的 JSON Schema（不读取配置）",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return configrun.WriteSchema(os.Stdout)
+		},
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cksr/internal/doctorrun"
+	"cksr/logger"
+
+	mdb "example.com/migrationLib/database"
+	"github.com/spf13/cobra"
+)
+
+// NewDoctorCmd 预检：连接、Catalog、lease 权限与时间戳列配置
+func NewDoctorCmd() *cobra.Command {
+	var (
+		format string
+		probe  bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "预检：逐项检查连接、Catalog、经 Catalog 查询、lease 权限与时间戳列配置，并给出修复建议",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != doctorrun.FormatTable && format != doctorrun.FormatJSON {
+				return WrapConfigErr(fmt.Errorf("未知的输出格式: %s，仅支持 table、json", format))
+			}
+			// 日志输出到标准错误，标准输出只承载检查结果
+			logger.RedirectToStderr()
+			cfg, err := LoadConfigAndInitLogging(cmd)
+			if err != nil {
+				return err
+			}
+			defer logger.CloseLogFile()
+			// 统一在退出前关闭连接池
+			defer mdb.CloseAll()
+
+			report := doctorrun.Collect(cfg, doctorrun.Options{ProbeCatalog: probe})
+			if err := doctorrun.Render(os.Stdout, report, format); err != nil {
+				return err
+			}
+			if report.HasFailure() {
+				return fmt.Errorf("预检未通过: %d 项失败", report.Failed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", doctorrun.FormatTable, "输出格式 (table, json)")
+	cmd.Flags().BoolVar(&probe, "probe", false, "Catalog 不存在时创建临时 Catalog 验证可创建性，检查结束后删除（会在 StarRocks 中执行 DDL）")
+
+	return cmd
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cksr/internal/driftrun"
+	"cksr/logger"
+
+	mdb "example.com/migrationLib/database"
+	"github.com/spf13/cobra"
+)
+
+// NewDriftCmd 只读：比较线上视图定义与按当前表结构生成的期望定义
+func NewDriftCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "drift",
+		Short: "只读：检测线上视图与当前应生成视图之间的结构漂移（存在漂移时非零退出）",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != driftrun.FormatText && format != driftrun.FormatJSON {
+				return WrapConfigErr(fmt.Errorf("未知的输出格式: %s，仅支持 text、json", format))
+			}
+			// 日志输出到标准错误，标准输出只承载检测报告
+			logger.RedirectToStderr()
+			cfg, err := LoadConfigAndInitLogging(cmd)
+			if err != nil {
+				return err
+			}
+			defer logger.CloseLogFile()
+			// 统一在退出前关闭连接池
+			defer mdb.CloseAll()
+
+			report, err := driftrun.Collect(cfg)
+			if err != nil {
+				return err
+			}
+			if err := driftrun.Render(os.Stdout, report, format); err != nil {
+				return err
+			}
+			if report.HasDrift() {
+				return fmt.Errorf("%w: 漂移 %d 个视图，无法比较 %d 个视图", ErrDrift, report.Drifted, report.Errors)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", driftrun.FormatText, "输出格式 (text, json)")
+
+	return cmd
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cksr/internal/explainrun"
+	"cksr/logger"
+
+	mdb "example.com/migrationLib/database"
+	"github.com/spf13/cobra"
+)
+
+// NewExplainMappingCmd 只读：展示指定表的 CK→SR 列映射
+func NewExplainMappingCmd() *cobra.Command {
+	var (
+		pairName string
+		format   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "explain-mapping <table>",
+		Short: "只读：逐列展示指定表在视图中的 CK→SR 列映射、子句与命中规则",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != explainrun.FormatTable && format != explainrun.FormatJSON {
+				return WrapConfigErr(fmt.Errorf("未知的输出格式: %s，仅支持 table、json", format))
+			}
+			// 日志输出到标准错误，标准输出只承载映射说明
+			logger.RedirectToStderr()
+			cfg, err := LoadConfigAndInitLogging(cmd)
+			if err != nil {
+				return err
+			}
+			defer logger.CloseLogFile()
+			// 统一在退出前关闭连接池
+			defer mdb.CloseAll()
+
+			pairIndex, err := explainrun.ResolvePair(cfg, pairName)
+			if err != nil {
+				return WrapConfigErr(err)
+			}
+			return explainrun.Run(cfg, pairIndex, args[0], format, os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVar(&pairName, "pair", "", "数据库对名称（仅配置一个数据库对时可省略）")
+	cmd.Flags().StringVar(&format, "format", explainrun.FormatTable, "输出格式 (table, json)")
+
+	return cmd
+}
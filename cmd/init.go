@@ -10,7 +10,9 @@ import (
 
 // NewInitCmd 仅初始化并创建视图
 func NewInitCmd() *cobra.Command {
-	return &cobra.Command{
+	var scopeArgs scopeFlags
+	var blackoutArgs blackoutFlag
+	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "初始化并创建视图",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -21,9 +23,19 @@ func NewInitCmd() *cobra.Command {
 				return err
 			}
 			defer logger.CloseLogFile()
+			scope, err := scopeArgs.resolve(cfg)
+			if err != nil {
+				return err
+			}
+			if err := blackoutArgs.check(cfg); err != nil {
+				return err
+			}
 			// 统一在退出前关闭连接池
 			defer mdb.CloseAll()
-			return initrun.Run(cfg)
+			return initrun.Run(cfg, scope)
 		},
 	}
+	scopeArgs.register(cmd)
+	blackoutArgs.register(cmd)
+	return cmd
 }
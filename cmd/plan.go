@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cksr/internal/planrun"
+	"cksr/logger"
+
+	mdb "example.com/migrationLib/database"
+	"github.com/spf13/cobra"
+)
+
+// NewPlanCmd 只读：打印 init/rollback 的执行计划与将要执行的SQL，不做任何变更
+func NewPlanCmd() *cobra.Command {
+	var format string
+	var outPath string
+
+	cmd := &cobra.Command{
+		Use:       "plan <init|rollback>",
+		Short:     "只读：打印 init/rollback 的执行计划与SQL (dry-run)",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{planrun.KindInit, planrun.KindRollback},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			logger.SetLogMode(logger.ModePlan)
+			kind := args[0]
+			if kind != planrun.KindInit && kind != planrun.KindRollback {
+				return WrapConfigErr(fmt.Errorf("未知的计划类型: %s，仅支持 init、rollback", kind))
+			}
+			if format != planrun.FormatText && format != planrun.FormatJSON {
+				return WrapConfigErr(fmt.Errorf("未知的输出格式: %s，仅支持 text、json", format))
+			}
+			// 日志输出到标准错误，标准输出只承载计划内容
+			logger.RedirectToStderr()
+			cfg, err := LoadConfigAndInitLogging(cmd)
+			if err != nil {
+				return err
+			}
+			defer logger.CloseLogFile()
+			// 统一在退出前关闭连接池
+			defer mdb.CloseAll()
+
+			return planrun.Run(cfg, kind, format, outPath, os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", planrun.FormatText, "输出格式 (text, json)")
+	cmd.Flags().StringVar(&outPath, "out", "", "保存计划文件（JSON，含元数据指纹），供 apply 使用")
+
+	return cmd
+}
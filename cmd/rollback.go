@@ -10,7 +10,9 @@ import (
 
 // NewRollbackCmd 回滚删除视图及相关变更
 func NewRollbackCmd() *cobra.Command {
-	return &cobra.Command{
+	var scopeArgs scopeFlags
+	var blackoutArgs blackoutFlag
+	cmd := &cobra.Command{
 		Use:   "rollback",
 		Short: "回滚删除视图及相关变更",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -21,15 +23,25 @@ func NewRollbackCmd() *cobra.Command {
 				return err
 			}
 			defer logger.CloseLogFile()
+			scope, err := scopeArgs.resolve(cfg)
+			if err != nil {
+				return err
+			}
+			if err := blackoutArgs.check(cfg); err != nil {
+				return err
+			}
 			// 统一在退出前关闭连接池
 			defer mdb.CloseAll()
 
 			logger.Info("开始执行回退操作...")
-			if err := rollbackrun.Run(cfg); err != nil {
+			if err := rollbackrun.Run(cfg, scope); err != nil {
 				return err
 			}
 			logger.Info("回退操作完成")
 			return nil
 		},
 	}
+	scopeArgs.register(cmd)
+	blackoutArgs.register(cmd)
+	return cmd
 }
@@ -1,6 +1,10 @@
 package cmd
 
 import (
+	"os"
+
+	"cksr/logger"
+
 	"github.com/spf13/cobra"
 )
 
@@ -10,16 +14,26 @@ func NewRootCmd() *cobra.Command {
 		Use:   "cksr",
 		Short: "StarRocks ClickHouse catalog视图构建工具",
 	}
+	// 命令行框架输出的错误信息同样需要脱敏
+	rootCmd.SetErr(logger.RedactWriter(os.Stderr))
 
 	// 持久化参数（所有子命令可用）
-	rootCmd.PersistentFlags().String("config-json", "", "配置JSON字符串")
-	rootCmd.PersistentFlags().String("log-level", "INFO", "日志级别 (SILENT, ERROR, WARN, INFO, DEBUG)")
+	rootCmd.PersistentFlags().StringP("config", "c", "", "配置文件路径（JSON 或 YAML），默认使用可执行文件同目录的 config.json")
+	rootCmd.PersistentFlags().String("config-json", "", "配置JSON字符串（与 --config 互斥）")
+	rootCmd.PersistentFlags().String("log-level", "INFO", "日志级别 (SILENT, ERROR, WARN, INFO, DEBUG)，显式指定时覆盖配置")
 
 	// 注册子命令
 	rootCmd.AddCommand(NewInitCmd())
 	rootCmd.AddCommand(NewAutoUpdateCmd())
 	rootCmd.AddCommand(NewUpdateCmd())
 	rootCmd.AddCommand(NewRollbackCmd())
+	rootCmd.AddCommand(NewPlanCmd())
+	rootCmd.AddCommand(NewApplyCmd())
+	rootCmd.AddCommand(NewStatusCmd())
+	rootCmd.AddCommand(NewDriftCmd())
+	rootCmd.AddCommand(NewExplainMappingCmd())
+	rootCmd.AddCommand(NewDoctorCmd())
+	rootCmd.AddCommand(NewConfigCmd())
 
 	return rootCmd
 }
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"cksr/internal/common"
+
+	mcfg "example.com/migrationLib/config"
+	"github.com/spf13/cobra"
+)
+
+// scopeFlags init/rollback/auto-update 共用的处理范围参数
+type scopeFlags struct {
+	pairs         []string
+	tables        []string
+	excludeTables []string
+}
+
+// register 注册 --pair/--table/--exclude-table，均可重复传入并支持 glob 通配符
+func (f *scopeFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.pairs, "pair", nil, "只处理匹配的数据库对，可重复传入，支持 glob（如 prod-*）")
+	cmd.Flags().StringArrayVar(&f.tables, "table", nil, "只处理匹配的表，可重复传入，支持 glob（如 order_*）")
+	cmd.Flags().StringArrayVar(&f.excludeTables, "exclude-table", nil, "排除匹配的表，可重复传入，支持 glob，优先于 --table")
+}
+
+// resolve 校验参数并构建处理范围；--pair 未匹配任何已配置的数据库对时视为配置错误
+func (f *scopeFlags) resolve(cfg *mcfg.Config) (common.Scope, error) {
+	scope := common.Scope{Pairs: f.pairs, Tables: f.tables, ExcludeTables: f.excludeTables}
+	if err := scope.Validate(); err != nil {
+		return scope, WrapConfigErr(err)
+	}
+	for _, pair := range cfg.DatabasePairs {
+		if scope.MatchPair(pair.Name) {
+			return scope, nil
+		}
+	}
+	return scope, WrapConfigErr(fmt.Errorf("--pair %v 未匹配任何已配置的数据库对", f.pairs))
+}
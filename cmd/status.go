@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"cksr/internal/statusrun"
+	"cksr/logger"
+
+	mdb "example.com/migrationLib/database"
+	"github.com/spf13/cobra"
+)
+
+// NewStatusCmd 只读：盘点每个数据库对中各表所处的状态
+func NewStatusCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "只读：查看各数据库对中每张表的初始化状态、Catalog 与视图边界",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != statusrun.FormatTable && format != statusrun.FormatJSON {
+				return WrapConfigErr(fmt.Errorf("未知的输出格式: %s，仅支持 table、json", format))
+			}
+			// 日志输出到标准错误，标准输出只承载状态报告
+			logger.RedirectToStderr()
+			cfg, err := LoadConfigAndInitLogging(cmd)
+			if err != nil {
+				return err
+			}
+			defer logger.CloseLogFile()
+			// 统一在退出前关闭连接池
+			defer mdb.CloseAll()
+
+			return statusrun.Run(cfg, format, os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVar(&format, "format", statusrun.FormatTable, "输出格式 (table, json)")
+
+	return cmd
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 
 	"cksr/internal/updaterun"
@@ -16,6 +17,8 @@ func NewUpdateCmd() *cobra.Command {
 	var pairName string
 	var tableArgs []string
 	var partitionArgs []string
+	var force bool
+	var blackoutArgs blackoutFlag
 
 	cmd := &cobra.Command{
 		Use:   "update",
@@ -50,21 +53,34 @@ func NewUpdateCmd() *cobra.Command {
 				if view == "" || part == "" {
 					return WrapConfigErr(errors.New("存在空的视图名或分区值"))
 				}
+				// 多层视图以逗号分隔各边界（从早到晚）
+				var boundaries []string
+				for _, b := range strings.Split(part, ",") {
+					if b = strings.TrimSpace(b); b == "" {
+						return WrapConfigErr(fmt.Errorf("视图 %s 的分区值 %q 中存在空的边界", view, part))
+					}
+					boundaries = append(boundaries, b)
+				}
 				targets = append(targets, updaterun.UpdateTarget{
-					ViewName:     view,
-					Partition:    part,
-					HasPartition: true,
+					ViewName:   view,
+					Boundaries: boundaries,
 				})
 			}
 
+			if err := blackoutArgs.check(cfg); err != nil {
+				return err
+			}
+
 			logger.Info("开始一次性更新 (update)，数据库对: %s，目标视图数: %d", pairName, len(targets))
-			return updaterun.RunOnceForTargets(cfg, pairName, targets)
+			return updaterun.RunOnceForTargets(cfg, pairName, targets, force)
 		},
 	}
 
 	cmd.Flags().StringVar(&pairName, "pair", "", "数据库对名称")
 	cmd.Flags().StringArrayVar(&tableArgs, "table", nil, "目标视图名，可重复传入，与 --partition 成对")
-	cmd.Flags().StringArrayVar(&partitionArgs, "partition", nil, "分区值，可重复传入，与 --table 成对")
+	cmd.Flags().StringArrayVar(&partitionArgs, "partition", nil, "分区值，可重复传入，与 --table 成对；多层视图以逗号分隔各边界（从早到晚）")
+	cmd.Flags().BoolVar(&force, "force", false, "允许新边界早于视图当前边界（跳过单调性保护）")
+	blackoutArgs.register(cmd)
 
 	return cmd
 }
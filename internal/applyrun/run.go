@@ -0,0 +1,67 @@
+package applyrun
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"cksr/internal/initrun"
+	"cksr/internal/planrun"
+	"cksr/internal/rollbackrun"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+)
+
+// ErrPlanStale 计划生成后环境元数据已变化
+var ErrPlanStale = errors.New("计划已过期")
+
+// Run 统一入口：校验指纹后按计划文件执行
+func Run(cfg *mcfg.Config, doc *planrun.Document) error {
+	logger.Info("校验计划指纹 (类型: %s，生成时间: %s)...", doc.Kind, doc.GeneratedAt.Format("2006-01-02 15:04:05"))
+	current, err := planrun.ComputeFingerprints(cfg)
+	if err != nil {
+		return fmt.Errorf("计算当前元数据指纹失败: %w", err)
+	}
+	if diffs := planrun.DiffFingerprints(doc.Fingerprints, current); len(diffs) > 0 {
+		for _, d := range diffs {
+			logger.Error("指纹不一致: %s", d)
+		}
+		return fmt.Errorf("%w: %s，请重新生成并审核计划", ErrPlanStale, strings.Join(diffs, "; "))
+	}
+	logger.Info("计划指纹校验通过")
+
+	switch doc.Kind {
+	case planrun.KindInit:
+		return applyInit(cfg, doc.Init)
+	case planrun.KindRollback:
+		return rollbackrun.ApplyPlans(cfg, doc.Rollback)
+	default:
+		return fmt.Errorf("计划文件类型未知: %q", doc.Kind)
+	}
+}
+
+// applyInit 逐库对按计划执行初始化
+func applyInit(cfg *mcfg.Config, plans []initrun.PairInitPlan) error {
+	for _, pp := range plans {
+		idx := pairIndex(cfg, pp.Pair)
+		if idx < 0 {
+			return fmt.Errorf("计划中的数据库对 %s 不在当前配置中", pp.Pair)
+		}
+		logger.Info("开始按计划处理数据库对 %s，表数量: %d", pp.Pair, len(pp.Tables))
+		if err := initrun.NewInitManager(cfg, idx).ApplyPlan(pp); err != nil {
+			return fmt.Errorf("处理数据库对 %s 失败: %w", pp.Pair, err)
+		}
+	}
+	logger.Info("所有数据库对按计划处理完成 (init)")
+	return nil
+}
+
+func pairIndex(cfg *mcfg.Config, name string) int {
+	for i, p := range cfg.DatabasePairs {
+		if p.Name == name {
+			return i
+		}
+	}
+	return -1
+}
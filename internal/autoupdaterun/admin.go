@@ -0,0 +1,341 @@
+package autoupdaterun
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"cksr/builder"
+	"cksr/internal/common"
+	"cksr/internal/settings"
+	"cksr/lock"
+	"cksr/logger"
+
+	"example.com/migrationLib/retry"
+)
+
+// ViewResult 视图最近一次更新结果
+type ViewResult struct {
+	Outcome string     `json:"outcome,omitempty"` // updated、unchanged、kept、regression_blocked，失败时为空
+	Step    UpdateStep `json:"step,omitempty"`    // 失败的步骤
+	Error   string     `json:"error,omitempty"`
+	At      time.Time  `json:"at"`
+}
+
+// ViewInfo 管理接口中的视图信息
+type ViewInfo struct {
+	Pair     string      `json:"pair"`
+	View     string      `json:"view"`
+	Boundary string      `json:"boundary,omitempty"` // 线上视图当前边界，无法解析时为空
+	Schedule string      `json:"schedule"`           // global 或独立调度的 cron 表达式
+	Excluded string      `json:"excluded,omitempty"` // 不参与更新的原因
+	Last     *ViewResult `json:"last,omitempty"`     // 本进程内最近一次更新结果
+}
+
+// ScheduleEntry 调度项及其下一次触发时间
+type ScheduleEntry struct {
+	Job  string    `json:"job"`
+	Spec string    `json:"spec"`
+	Next time.Time `json:"next"`
+	Prev time.Time `json:"prev"`
+}
+
+// ScheduleInfo 调度器状态
+type ScheduleInfo struct {
+	Paused     bool               `json:"paused"`
+	Scheduling bool               `json:"scheduling"` // 选主模式下的备实例为 false
+	Leader     *lock.LeaderStatus `json:"leader,omitempty"`
+	Entries    []ScheduleEntry    `json:"entries"`
+}
+
+// recordResult 记录视图最近一次更新结果
+func (vu *ViewUpdater) recordResult(pair, view, outcome string, step UpdateStep, err error) {
+	r := ViewResult{Outcome: outcome, Step: step, At: time.Now()}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	vu.resultMu.Lock()
+	vu.results[pair+"/"+view] = r
+	vu.resultMu.Unlock()
+	vu.telemetry.observeView(pair, view, outcome, step, err)
+}
+
+// startAdmin 按 view_updater.admin 启动 HTTP 管理接口；监听失败时返回错误
+func (vu *ViewUpdater) startAdmin() error {
+	admin := settings.Of(vu.config).ViewUpdater.Admin
+	if !admin.Enabled {
+		return nil
+	}
+	addr := admin.Address()
+	token, err := admin.Token()
+	if err != nil {
+		return err
+	}
+	if token == "" && !admin.IsLoopback() {
+		return fmt.Errorf("管理接口监听 %s 不限于本机，需配置 view_updater.admin.token_file", addr)
+	}
+	logger.RegisterSecret(token)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("管理接口监听 %s 失败: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /views", vu.handleViews)
+	mux.HandleFunc("GET /schedule", vu.handleSchedule)
+	mux.HandleFunc("POST /update", requireToken(token, vu.handleUpdate))
+	mux.HandleFunc("POST /pause", requireToken(token, vu.handlePause))
+	mux.HandleFunc("POST /resume", requireToken(token, vu.handleResume))
+	mux.HandleFunc("POST /views/{view}/freeze", requireToken(token, vu.handleFreeze))
+	mux.HandleFunc("POST /views/{view}/unfreeze", requireToken(token, vu.handleUnfreeze))
+	vu.admin = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := vu.admin.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("管理接口异常退出: %v", err)
+		}
+	}()
+	logger.Info("管理接口已启动: http://%s", ln.Addr())
+	return nil
+}
+
+// requireToken 配置了令牌时校验 Authorization: Bearer <令牌>，不符时返回 401；令牌为空时不校验（仅本机监听）
+func requireToken(token string, next http.HandlerFunc) http.HandlerFunc {
+	if token == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cksr"`)
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("缺少或错误的访问令牌"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// stopAdmin 关闭管理接口，等待进行中的请求结束（最多 5 秒）
+func (vu *ViewUpdater) stopAdmin() {
+	if vu.admin == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := vu.admin.Shutdown(ctx); err != nil {
+		logger.Warn("关闭管理接口失败: %v", err)
+	}
+	vu.admin = nil
+}
+
+// handleViews GET /views[?pair=]：列出由 cksr 管理的视图、当前边界与最近一次更新结果（只读，不获取锁）
+func (vu *ViewUpdater) handleViews(w http.ResponseWriter, r *http.Request) {
+	pairName := r.URL.Query().Get("pair")
+	if err := vu.checkPair(pairName); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	views, err := vu.listViews(pairName)
+	if errors.Is(err, errNoTickYet) {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, views)
+}
+
+// handleSchedule GET /schedule：调度项与下一次触发时间
+func (vu *ViewUpdater) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	info := ScheduleInfo{Paused: vu.paused.Load(), Entries: []ScheduleEntry{}}
+	if status, ok := vu.LeaderStatus(); ok {
+		info.Leader = &status
+	}
+	vu.schedMu.Lock()
+	if vu.cron != nil {
+		info.Scheduling = true
+		for _, reg := range vu.entries {
+			e := vu.cron.Entry(reg.id)
+			info.Entries = append(info.Entries, ScheduleEntry{Job: jobName(reg.only), Spec: reg.spec, Next: e.Next, Prev: e.Prev})
+		}
+	}
+	vu.schedMu.Unlock()
+	writeJSON(w, http.StatusOK, info)
+}
+
+// handleUpdate POST /update[?pair=&view=]：立即在后台执行一轮更新。
+// 与定时触发共用 tickMu 和共享锁；已有一轮在执行或本实例不是 leader 时返回 409
+func (vu *ViewUpdater) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	sel := selection{manual: true, pair: r.URL.Query().Get("pair"), view: r.URL.Query().Get("view")}
+	if err := vu.checkPair(sel.pair); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if status, ok := vu.LeaderStatus(); ok && !status.IsLeader {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "本实例不是 leader", "leader": status.Leader})
+		return
+	}
+	if err := vu.blackout.Check(); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	if !vu.tickMu.TryLock() {
+		writeError(w, http.StatusConflict, fmt.Errorf("已有一轮更新在执行，请稍后重试"))
+		return
+	}
+	go func() {
+		defer vu.tickMu.Unlock()
+		if err := vu.updateViewsLocked(sel); err != nil {
+			logger.Error("%s更新视图失败: %v", sel.name(), err)
+		}
+	}()
+	logger.Info("管理接口: 已触发%s", sel.name())
+	writeJSON(w, http.StatusAccepted, map[string]string{"accepted": sel.name()})
+}
+
+// handlePause POST /pause：暂停定时触发，进行中的一轮会继续完成
+func (vu *ViewUpdater) handlePause(w http.ResponseWriter, r *http.Request) {
+	if !vu.paused.Swap(true) {
+		logger.Warn("管理接口: 调度已暂停")
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"paused": true})
+}
+
+// handleResume POST /resume：恢复定时触发
+func (vu *ViewUpdater) handleResume(w http.ResponseWriter, r *http.Request) {
+	if vu.paused.Swap(false) {
+		logger.Info("管理接口: 调度已恢复")
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"paused": false})
+}
+
+// handleFreeze POST /views/{view}/freeze[?until=]：冻结视图当前边界，until 格式同 frozen_until，省略时冻结到手动解冻为止。
+// 对各数据库对中的同名视图生效，下一轮起生效，进行中的一轮不受影响
+func (vu *ViewUpdater) handleFreeze(w http.ResponseWriter, r *http.Request) {
+	view := r.PathValue("view")
+	o := freezeOverride{frozen: true}
+	if s := r.URL.Query().Get("until"); s != "" {
+		until, err := settings.ParseFrozenUntil(s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if !until.After(time.Now()) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("冻结截止时间 %s 已过", s))
+			return
+		}
+		o.until = until
+	}
+	vu.setOverride(view, o)
+	resp := map[string]interface{}{"view": view, "frozen": true}
+	if o.until.IsZero() {
+		logger.Warn("管理接口: 视图 %s 已冻结，需手动解冻", view)
+	} else {
+		logger.Warn("管理接口: 视图 %s 已冻结至 %s", view, o.until.Format("2006-01-02 15:04:05"))
+		resp["until"] = o.until
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleUnfreeze POST /views/{view}/unfreeze：解除视图冻结，同时忽略配置中的 frozen_until，直至重启；不影响 enabled=false
+func (vu *ViewUpdater) handleUnfreeze(w http.ResponseWriter, r *http.Request) {
+	view := r.PathValue("view")
+	vu.setOverride(view, freezeOverride{})
+	logger.Info("管理接口: 视图 %s 已解冻", view)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"view": view, "frozen": false})
+}
+
+// checkPair 校验请求中的数据库对存在且在命令行范围内，空串表示全部
+func (vu *ViewUpdater) checkPair(name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, pair := range vu.config.DatabasePairs {
+		if pair.Name == name {
+			if !vu.scope.MatchPair(name) {
+				return fmt.Errorf("数据库对 %s 不在 --pair 范围内", name)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("未找到数据库对: %s", name)
+}
+
+// errNoTickYet 数据库对尚未完成过一轮视图列举，管理接口暂无视图列表
+var errNoTickYet = errors.New("尚未执行过一轮更新，视图列表暂不可用")
+
+// listViews 返回范围内各数据库对由 cksr 管理的视图及其线上边界。
+// 视图列表取自最近一轮更新，不导出 CK 表结构；边界每次请求从 SR 视图定义读取
+func (vu *ViewUpdater) listViews(pairName string) ([]ViewInfo, error) {
+	retryConfig := retry.Config{
+		MaxRetries: vu.config.Retry.MaxRetries,
+		Delay:      time.Duration(vu.config.Retry.DelayMs) * time.Millisecond,
+	}
+	now := time.Now()
+	views := []ViewInfo{}
+	for i, pair := range vu.config.DatabasePairs {
+		if !vu.scope.MatchPair(pair.Name) || pairName != "" && pair.Name != pairName {
+			continue
+		}
+		vu.managedMu.Lock()
+		names, ok := vu.managed[pair.Name]
+		vu.managedMu.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("数据库对 %s: %w", pair.Name, errNoTickYet)
+		}
+		conn, err := vu.connFor(i)
+		if err != nil {
+			return nil, fmt.Errorf("数据库对 %s: %w", pair.Name, err)
+		}
+		srDB, err := conn.dbManager.GetStarRocksConnection()
+		if err != nil {
+			return nil, fmt.Errorf("数据库对 %s: 获取StarRocks连接失败: %w", pair.Name, err)
+		}
+		defs, err := common.ViewDefinitions(srDB, retryConfig, pair.StarRocks.Database)
+		if err != nil {
+			return nil, fmt.Errorf("数据库对 %s: %w", pair.Name, err)
+		}
+		for _, name := range names {
+			info := ViewInfo{Pair: pair.Name, View: name, Schedule: vu.scheduleLabel(name), Excluded: vu.exclusion(pair.Name, name, now)}
+			if b, ok := builder.ParseViewBoundary(defs[name]); ok {
+				info.Boundary = b.Value
+			}
+			vu.resultMu.Lock()
+			if last, ok := vu.results[pair.Name+"/"+name]; ok {
+				info.Last = &last
+			}
+			vu.resultMu.Unlock()
+			views = append(views, info)
+		}
+	}
+	sort.SliceStable(views, func(a, b int) bool {
+		if views[a].Pair != views[b].Pair {
+			return views[a].Pair < views[b].Pair
+		}
+		return views[a].View < views[b].View
+	})
+	return views, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		logger.Warn("写入管理接口响应失败: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
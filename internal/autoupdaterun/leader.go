@@ -0,0 +1,76 @@
+package autoupdaterun
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"cksr/internal/common"
+	"cksr/internal/settings"
+	"cksr/lock"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+)
+
+// newElection 按 view_updater.leader_election 创建选主器；未启用或调试模式（无 K8s）时返回 nil，按单实例运行
+func newElection(cfg *mcfg.Config) (*lock.LeaderElection, error) {
+	vs := settings.Of(cfg).ViewUpdater
+	if !vs.LeaderElection {
+		return nil, nil
+	}
+	if cfg.Lock.DebugMode {
+		logger.Warn("调试模式下不支持选主，按单实例运行")
+		return nil, nil
+	}
+	election, err := lock.NewInClusterLeaderElection(
+		cfg.Lock.K8sNamespace,
+		vs.LeaderLease(cfg.Lock.LeaseName),
+		electionIdentity(cfg.Lock.Identity),
+		time.Duration(cfg.Lock.LockDurationSeconds)*time.Second,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("创建选主器失败: %w", err)
+	}
+	return election, nil
+}
+
+// electionIdentity 选主身份需在副本间唯一：在锁身份后追加主机名（K8s 中即 Pod 名）
+func electionIdentity(base string) string {
+	identity := common.BuildIdentity(base, common.RoleUpdater)
+	if host, err := os.Hostname(); err == nil && host != "" {
+		identity += "-" + host
+	}
+	return identity
+}
+
+// startElection 在后台持续参与选主：成为 leader 时开始调度，失去 leader 身份时停止调度
+func (vu *ViewUpdater) startElection() {
+	vu.electionDone = make(chan struct{})
+	go func() {
+		defer close(vu.electionDone)
+		err := vu.election.Run(vu.ctx, lock.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				if err := vu.startScheduling(); err != nil {
+					logger.Error("成为 leader 后启动调度失败: %v", err)
+				}
+			},
+			OnStoppedLeading: vu.stopScheduling,
+			OnNewLeader: func(string) {
+				vu.telemetry.leaderChanges.Inc()
+			},
+		})
+		if err != nil {
+			logger.Error("选主失败: %v", err)
+		}
+	}()
+}
+
+// LeaderStatus 返回选主状态；未启用选主时 ok 为 false
+func (vu *ViewUpdater) LeaderStatus() (lock.LeaderStatus, bool) {
+	if vu.election == nil {
+		return lock.LeaderStatus{}, false
+	}
+	return vu.election.Status(), true
+}
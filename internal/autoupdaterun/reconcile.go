@@ -0,0 +1,187 @@
+package autoupdaterun
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cksr/builder"
+	"cksr/internal/common"
+	"cksr/internal/initrun"
+	"cksr/internal/settings"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+	mdb "example.com/migrationLib/database"
+	"example.com/migrationLib/retry"
+)
+
+// 自愈动作（view_updater.reconcile）
+const (
+	ActionCreateCatalog = "create_catalog" // Catalog 缺失时重新创建
+	ActionOnboard       = "onboard"        // 新的共同表：CK 增列、SR 重命名为后缀表并创建视图
+	ActionRecreateView  = "recreate_view"  // 后缀表存在但基础名视图缺失时重新创建视图
+	ActionQuarantine    = "quarantine"     // 依赖缺失且无法重建的视图，不再参与更新
+)
+
+// ReasonQuarantined 视图因依赖缺失被自愈阶段隔离
+const ReasonQuarantined = "依赖缺失已隔离"
+
+// reconcilePair 在更新视图前对单个数据库对执行自愈；各步骤失败只记录日志，不影响本轮视图更新
+func (vu *ViewUpdater) reconcilePair(pairIndex int, pair mcfg.DatabasePair, conn *pairConn, srDB *sql.DB) {
+	dryRun := settings.Of(vu.config).ViewUpdater.Reconcile.IsDryRun()
+	retryConfig := retry.Config{
+		MaxRetries: vu.config.Retry.MaxRetries,
+		Delay:      time.Duration(vu.config.Retry.DelayMs) * time.Millisecond,
+	}
+
+	// 1) Catalog 被删除时所有视图的 CK 分支都会失败，先重建
+	exists, err := common.CatalogExists(srDB, retryConfig, pair.CatalogName)
+	if err != nil {
+		logger.Error("自愈: 检查数据库对 %s 的 Catalog 失败: %v", pair.Name, err)
+		return
+	}
+	if !exists {
+		ok := vu.reconcileAction(pair.Name, ActionCreateCatalog, pair.CatalogName, "Catalog 不存在", dryRun, func() error {
+			return common.CreateCatalog(vu.config, pairIndex, conn.dbManager, pair.CatalogName)
+		})
+		if !ok {
+			return
+		}
+	}
+
+	// 2) 复用 init 的计划：新的共同表纳管，已重命名但视图缺失的表重建视图
+	im := initrun.NewInitManagerWithConn(vu.config, pairIndex, conn.dbManager, vu.scope)
+	tables, err := im.PlanTables()
+	if err != nil {
+		logger.Error("自愈: 生成数据库对 %s 的初始化计划失败: %v", pair.Name, err)
+	} else if len(tables) > 0 {
+		srTypes, err := conn.meta.GetStarRocksTablesTypes()
+		if err != nil {
+			logger.Error("自愈: 获取数据库对 %s 的StarRocks表类型失败: %v", pair.Name, err)
+			return
+		}
+		now := time.Now()
+		changed := false
+		for _, t := range tables {
+			if reason := vu.holdReason(t.BaseTable, now); reason != "" {
+				logger.Debug("自愈: 跳过表 %s（%s）", t.BaseTable, reason)
+				continue
+			}
+			action, reason := ActionRecreateView, t.ViewReason
+			if t.NeedRename {
+				if _, conflict := srTypes[t.SuffixedTable]; conflict {
+					logger.Error("自愈: 数据库对 %s 中基础名 %s 与后缀表 %s 同时存在，无法自动处理，需人工确认后保留其一", pair.Name, t.BaseTable, t.SuffixedTable)
+					continue
+				}
+				action, reason = ActionOnboard, t.RenameReason
+			}
+			if vu.reconcileAction(pair.Name, action, t.BaseTable, reason, dryRun, func() error { return im.ApplyTable(t) }) && !dryRun {
+				changed = true
+			}
+		}
+		if changed {
+			// 表与视图已变化，本轮后续步骤重新读取元数据
+			conn.meta.Invalidate()
+		}
+	}
+
+	// 3) 依赖缺失且无法重建的视图：隔离，不再每轮失败
+	if err := vu.detectQuarantine(pair, conn, srDB, retryConfig, dryRun); err != nil {
+		logger.Error("自愈: 检查数据库对 %s 的视图依赖失败: %v", pair.Name, err)
+	}
+}
+
+// reconcileAction 记录并在非 dry-run 时执行一个自愈动作，返回是否成功（dry-run 视为成功）
+func (vu *ViewUpdater) reconcileAction(pair, action, target, reason string, dryRun bool, apply func() error) bool {
+	if dryRun {
+		logger.Info("自愈[dry-run]: 数据库对 %s 将执行 %s: %s（%s）", pair, action, target, reason)
+		vu.telemetry.reconcileActions.Inc(pair, action, "planned")
+		return true
+	}
+	logger.Warn("自愈: 数据库对 %s 执行 %s: %s（%s）", pair, action, target, reason)
+	if err := apply(); err != nil {
+		logger.Error("自愈: 数据库对 %s 执行 %s 失败: %s: %v", pair, action, target, err)
+		vu.telemetry.reconcileActions.Inc(pair, action, "failed")
+		return false
+	}
+	logger.Info("自愈: 数据库对 %s 已完成 %s: %s", pair, action, target)
+	vu.telemetry.reconcileActions.Inc(pair, action, "applied")
+	return true
+}
+
+// detectQuarantine 检查带归属注释的视图的依赖（CK 表、SR 后缀表），缺失时隔离；依赖恢复后自动解除
+func (vu *ViewUpdater) detectQuarantine(pair mcfg.DatabasePair, conn *pairConn, srDB *sql.DB, retryConfig retry.Config, dryRun bool) error {
+	comments, err := common.ViewComments(srDB, retryConfig, pair.StarRocks.Database)
+	if err != nil {
+		return err
+	}
+	srTypes, err := conn.meta.GetStarRocksTablesTypes()
+	if err != nil {
+		return fmt.Errorf("获取StarRocks表类型失败: %w", err)
+	}
+	ckTables, err := conn.meta.ExportClickHouseTablesAsParserTables()
+	if err != nil {
+		return fmt.Errorf("导出ClickHouse表结构失败: %w", err)
+	}
+
+	broken := map[string]string{}
+	for name, comment := range comments {
+		if comment != builder.ViewOwnerComment {
+			continue
+		}
+		if ok, _ := vu.scope.MatchTable(name); !ok {
+			continue
+		}
+		var missing []string
+		if _, ok := ckTables[name]; !ok {
+			missing = append(missing, fmt.Sprintf("ClickHouse 表 %s.%s", pair.ClickHouse.Database, name))
+		}
+		suffixed := vu.getStarRocksTableNameFromView(name, pair)
+		if strings.ToUpper(srTypes[suffixed]) != mdb.StarRocksTableTypeBaseTable {
+			missing = append(missing, fmt.Sprintf("StarRocks 后缀表 %s.%s", pair.StarRocks.Database, suffixed))
+		}
+		if len(missing) > 0 {
+			broken[name] = strings.Join(missing, "、") + " 不存在"
+		}
+	}
+
+	views := make([]string, 0, len(broken))
+	for name := range broken {
+		views = append(views, name)
+	}
+	sort.Strings(views)
+	if dryRun {
+		for _, name := range views {
+			vu.reconcileAction(pair.Name, ActionQuarantine, name, broken[name], true, nil)
+		}
+		return nil
+	}
+
+	vu.quarantineMu.Lock()
+	previous := vu.quarantine[pair.Name]
+	vu.quarantine[pair.Name] = broken
+	vu.quarantineMu.Unlock()
+	for _, name := range views {
+		if _, ok := previous[name]; ok {
+			continue
+		}
+		logger.Error("自愈: 隔离视图 %s.%s: %s；该视图不再参与更新，需人工恢复依赖或删除视图（依赖恢复后自动解除）", pair.StarRocks.Database, name, broken[name])
+		vu.telemetry.reconcileActions.Inc(pair.Name, ActionQuarantine, "applied")
+	}
+	for name := range previous {
+		if _, ok := broken[name]; !ok {
+			logger.Info("自愈: 视图 %s.%s 的依赖已恢复，解除隔离", pair.StarRocks.Database, name)
+		}
+	}
+	return nil
+}
+
+// quarantined 返回视图被隔离的原因，未隔离时返回空串
+func (vu *ViewUpdater) quarantined(pair, view string) string {
+	vu.quarantineMu.Lock()
+	defer vu.quarantineMu.Unlock()
+	return vu.quarantine[pair][view]
+}
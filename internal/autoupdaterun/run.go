@@ -11,15 +11,24 @@ package autoupdaterun
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"cksr/builder"
+	"cksr/internal/blackout"
+	"cksr/internal/boundary"
 	"cksr/internal/common"
+	"cksr/internal/metacache"
+	"cksr/internal/settings"
 	"cksr/internal/updaterun"
 	"cksr/lock"
 	"cksr/logger"
@@ -42,15 +51,61 @@ type ViewUpdaterConfig struct {
 type ViewUpdater struct {
 	config      *mcfg.Config
 	lockManager lock.LockManager
-	cron        *cron.Cron
 	ctx         context.Context
 	cancel      context.CancelFunc
 	running     bool
 	mu          sync.RWMutex
+	scope       common.Scope // 命令行指定的处理范围，零值表示全部
+	// lastFiltered 各调度项上一轮过滤结果的摘要，过滤结果变化时才输出明细，避免每轮刷屏
+	lastFiltered map[string]string
+	// tickMu 串行化全局与表级调度项，保证同一时刻只有一轮更新持有共享锁
+	tickMu sync.Mutex
+	// state 各调度项最近一次成功执行的时间，用于停机后补跑
+	state *runState
+	// conns 按数据库对下标缓存的连接与元数据缓存，在进程生命周期内复用
+	connMu sync.Mutex
+	conns  map[int]*pairConn
+	// cron 当前的调度器，未在调度（如选主模式下的备实例）时为 nil
+	schedMu     sync.Mutex
+	cron        *cron.Cron
+	schedCancel context.CancelFunc
+	entries     []registered
+	// paused 通过管理接口暂停调度：定时触发直接跳过，手动触发不受影响
+	paused atomic.Bool
+	// results 各视图最近一次更新结果，键为 <数据库对>/<视图名>
+	resultMu sync.Mutex
+	results  map[string]ViewResult
+	// quarantine 自愈阶段隔离的视图：数据库对 -> 视图名 -> 原因
+	quarantineMu sync.Mutex
+	quarantine   map[string]map[string]string
+	// managed 各数据库对最近一轮列出的由 cksr 管理的视图，管理接口 GET /views 直接使用，不再导出 CK 表结构
+	managedMu sync.Mutex
+	managed   map[string][]string
+	// overrides 通过管理接口设置的视图冻结/解冻，优先于配置中的 frozen_until；不持久化，重启后失效
+	overrideMu sync.Mutex
+	overrides  map[string]freezeOverride
+	// admin HTTP 管理接口，未启用时为 nil
+	admin *http.Server
+	// telemetry 指标与就绪状态；metricsServer 为指标接口，未启用时为 nil
+	telemetry     *telemetry
+	metricsServer *http.Server
+	// probing 后台正在重试初始化连接池，/readyz 不重复触发
+	probing atomic.Bool
+	// blackout 维护窗口，生效期间跳过定时触发
+	blackout *blackout.Schedule
+	// election 选主模式下的选主器，未启用时为 nil
+	election     *lock.LeaderElection
+	electionDone chan struct{}
+}
+
+// pairConn 单个数据库对的常驻连接管理器及其元数据缓存
+type pairConn struct {
+	dbManager *mdb.DatabasePairManager
+	meta      *metacache.Cache
 }
 
 // NewViewUpdater 创建视图更新器
-func NewViewUpdater(cfg *mcfg.Config) (*ViewUpdater, error) {
+func NewViewUpdater(cfg *mcfg.Config, scope common.Scope) (*ViewUpdater, error) {
 
 	// 创建锁管理器
 	lockManager, err := lock.CreateLockManager(
@@ -64,15 +119,38 @@ func NewViewUpdater(cfg *mcfg.Config) (*ViewUpdater, error) {
 		return nil, fmt.Errorf("创建锁管理器失败: %w", err)
 	}
 
+	election, err := newElection(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	windows, err := blackout.New(settings.Of(cfg).Blackout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &ViewUpdater{
-		config:      cfg,
-		lockManager: lockManager,
-		cron:        cron.New(cron.WithSeconds()),
-		ctx:         ctx,
-		cancel:      cancel,
-	}, nil
+	vu := &ViewUpdater{
+		config:       cfg,
+		lockManager:  lockManager,
+		election:     election,
+		blackout:     windows,
+		ctx:          ctx,
+		cancel:       cancel,
+		scope:        scope,
+		lastFiltered: make(map[string]string),
+		results:      make(map[string]ViewResult),
+		quarantine:   make(map[string]map[string]string),
+		overrides:    make(map[string]freezeOverride),
+		managed:      make(map[string][]string),
+		state:        loadRunState(settings.Of(cfg).ViewUpdater.StatePath(cfg.TempDir)),
+		conns:        make(map[int]*pairConn),
+		telemetry:    newTelemetry(),
+	}
+	vu.registerStateGauges()
+	common.SetRetryObserver(vu.telemetry.observeRetry)
+	return vu, nil
 }
 
 // Start 启动视图更新器
@@ -84,25 +162,74 @@ func (vu *ViewUpdater) Start() error {
 		return fmt.Errorf("视图更新器已在运行")
 	}
 
-	logger.Info("启动视图更新器，Cron表达式: %s", vu.config.ViewUpdater.CronExpression)
+	// 先校验调度配置，选主模式下也能在启动时发现错误
+	if _, err := vu.jobs(); err != nil {
+		return err
+	}
+	if err := vu.startMetrics(); err != nil {
+		return err
+	}
+	if err := vu.startAdmin(); err != nil {
+		vu.stopMetrics()
+		return err
+	}
 
-	// 添加定时任务，cron框架会自动在协程中执行
-	_, err := vu.cron.AddFunc(vu.config.ViewUpdater.CronExpression, func() {
-		if err := vu.updateAllViews(); err != nil {
-			logger.Error("更新视图失败: %v", err)
-		}
-	})
-	if err != nil {
-		return fmt.Errorf("添加定时任务失败: %w", err)
+	if vu.election != nil {
+		vu.startElection()
+		vu.running = true
+		logger.Info("视图更新器启动成功，成为 leader 后开始调度")
+		return nil
 	}
 
-	vu.cron.Start()
+	if err := vu.startScheduling(); err != nil {
+		vu.stopAdmin()
+		vu.stopMetrics()
+		return err
+	}
 	vu.running = true
 
 	logger.Info("视图更新器启动成功")
 	return nil
 }
 
+// startScheduling 创建调度器并注册全局与表级定时任务，cron框架会自动在协程中执行；
+// 选主模式下每次成为 leader 时重新创建
+func (vu *ViewUpdater) startScheduling() error {
+	vu.schedMu.Lock()
+	defer vu.schedMu.Unlock()
+	if vu.cron != nil {
+		return nil
+	}
+	c := cron.New(cron.WithSeconds())
+	ctx, cancel := context.WithCancel(vu.ctx)
+	regs, err := vu.registerJobs(ctx, c)
+	if err != nil {
+		cancel()
+		return err
+	}
+	c.Start()
+	vu.cron = c
+	vu.schedCancel = cancel
+	vu.entries = regs
+	vu.catchUp(regs)
+	return nil
+}
+
+// stopScheduling 停止调度器，不再触发新的一轮；已在执行的一轮会继续完成（仍持有共享锁）
+func (vu *ViewUpdater) stopScheduling() {
+	vu.schedMu.Lock()
+	defer vu.schedMu.Unlock()
+	if vu.cron == nil {
+		return
+	}
+	vu.cron.Stop()
+	vu.schedCancel()
+	vu.cron = nil
+	vu.schedCancel = nil
+	vu.entries = nil
+	logger.Info("已停止调度")
+}
+
 // Stop 停止视图更新器
 func (vu *ViewUpdater) Stop() {
 	vu.mu.Lock()
@@ -113,13 +240,18 @@ func (vu *ViewUpdater) Stop() {
 	}
 
 	logger.Info("正在停止视图更新器...")
+	vu.stopAdmin()
 
 	// 停止cron调度器
-	vu.cron.Stop()
+	vu.stopScheduling()
 
-	// 取消上下文
+	// 取消上下文；选主模式下等待释放 Lease，便于备实例立即接管
 	vu.cancel()
+	if vu.electionDone != nil {
+		<-vu.electionDone
+	}
 
+	vu.stopMetrics()
 	vu.running = false
 	logger.Info("视图更新器已停止")
 }
@@ -131,106 +263,297 @@ func (vu *ViewUpdater) IsRunning() bool {
 	return vu.running
 }
 
-// updateAllViews 更新所有视图的时间边界
-func (vu *ViewUpdater) updateAllViews() error {
-	logger.Info("开始更新所有视图的时间边界")
+// updateViews 执行一轮更新：全局调度更新未配置独立调度的全部视图，独立调度只更新各数据库对中的该视图，
+// 手动触发按指定的数据库对与视图更新
+func (vu *ViewUpdater) updateViews(sel selection) error {
+	vu.tickMu.Lock()
+	defer vu.tickMu.Unlock()
+	return vu.updateViewsLocked(sel)
+}
+
+// updateViewsLocked 在已持有 tickMu 时执行一轮更新，并获取与一次性命令共用的锁
+func (vu *ViewUpdater) updateViewsLocked(sel selection) (err error) {
+	start := time.Now()
+	defer func() { vu.telemetry.observeTick(sel, time.Since(start), err) }()
+
+	switch {
+	case sel.manual:
+		logger.Info("开始更新视图的时间边界（%s）", sel.name())
+	case sel.job == globalJob:
+		logger.Info("开始更新所有视图的时间边界")
+	default:
+		logger.Info("开始更新视图 %s 的时间边界（独立调度）", sel.job)
+	}
 
 	// 获取锁
 	releaseLock, err := vu.lockManager.AcquireLock(vu.ctx)
 	if err != nil {
+		vu.telemetry.lockFailures.Inc()
 		return fmt.Errorf("获取锁失败: %w", err)
 	}
 	defer releaseLock()
 
 	logger.Info("成功获取锁，开始更新视图")
 
-	// 遍历所有数据库对
+	// 遍历范围内的数据库对；continue_on_error 时单个视图或库对失败不影响其余部分
+	continueOnError := settings.Of(vu.config).ViewUpdater.Strategy == StrategyContinueOnError
+	stats := &TickStats{}
+	var filtered []common.Filtered
 	for i, pair := range vu.config.DatabasePairs {
+		if !vu.scope.MatchPair(pair.Name) {
+			logger.Debug("跳过数据库对 %s (未匹配 --pair)", pair.Name)
+			continue
+		}
+		if sel.pair != "" && pair.Name != sel.pair {
+			continue
+		}
 		logger.Info("开始更新数据库对 %s 的视图", pair.Name)
 
-		dbManager := mdb.NewDatabasePairManager(vu.config, i)
-		if err := vu.updateViewsForPair(dbManager, pair); err != nil {
+		pairFiltered, err := vu.updateViewsForPair(i, pair, sel, stats, continueOnError)
+		filtered = append(filtered, pairFiltered...)
+		if err != nil {
 			logger.Error("更新数据库对 %s 的视图失败: %v", pair.Name, err)
-			return err
+			if !continueOnError {
+				break
+			}
+			continue
 		}
 
 		logger.Info("数据库对 %s 的视图更新完成", pair.Name)
 	}
+	vu.reportFiltered(sel.name(), filtered)
+	stats.print()
+	vu.telemetry.observeFailures(stats.Failed)
 
-	logger.Info("所有视图时间边界更新完成")
+	// 任一失败即视为本轮失败
+	if len(stats.Failed) > 0 {
+		return fmt.Errorf("本轮有 %d 项更新失败", len(stats.Failed))
+	}
+	logger.Info("%s的视图时间边界更新完成", sel.name())
 	return nil
 }
 
-// updateViewsForPair 更新单个数据库对的视图
-func (vu *ViewUpdater) updateViewsForPair(dbManager *mdb.DatabasePairManager, pair mcfg.DatabasePair) error {
-	// 主动初始化连接池，未初始化不允许继续
-	if err := dbManager.Init(); err != nil {
-		return fmt.Errorf("初始化数据库连接失败: %w", err)
+// updateViewsForPair 更新单个数据库对范围内的视图并记录统计，返回被过滤的视图；
+// 库对级失败总是返回错误，视图级失败仅在 fail_fast 时返回
+func (vu *ViewUpdater) updateViewsForPair(pairIndex int, pair mcfg.DatabasePair, sel selection, stats *TickStats, continueOnError bool) ([]common.Filtered, error) {
+	conn, err := vu.connFor(pairIndex)
+	if err != nil {
+		return nil, stats.fail(pair.Name, "", StepConnect, err)
 	}
+	// 元数据只在本轮内有效，结束时显式失效，下一轮重新读取
+	defer conn.meta.Invalidate()
+
 	// 获取StarRocks连接
-	srDB, err := dbManager.GetStarRocksConnection()
+	srDB, err := conn.dbManager.GetStarRocksConnection()
 	if err != nil {
-		return fmt.Errorf("获取StarRocks连接失败: %w", err)
+		vu.dropConn(pairIndex)
+		return nil, stats.fail(pair.Name, "", StepConnect, fmt.Errorf("获取StarRocks连接失败: %w", err))
 	}
 
 	// 获取ClickHouse连接
-	chDB, err := dbManager.GetClickHouseConnection()
+	chDB, err := conn.dbManager.GetClickHouseConnection()
 	if err != nil {
-		return fmt.Errorf("获取ClickHouse连接失败: %w", err)
+		vu.dropConn(pairIndex)
+		return nil, stats.fail(pair.Name, "", StepConnect, fmt.Errorf("获取ClickHouse连接失败: %w", err))
+	}
+
+	vs := settings.Of(vu.config).ViewUpdater
+	if vs.Reconcile.Enabled && sel.reconcile() {
+		vu.reconcilePair(pairIndex, pair, conn, srDB)
 	}
 
-	// 获取所有视图
-	views, err := vu.getAllViews(srDB, pair.StarRocks.Database)
+	// 获取由 cksr 管理的视图，并按 ignore_tables 与命令行范围过滤
+	views, filtered, err := vu.managedViews(srDB, conn.meta, pair, sel)
 	if err != nil {
-		return fmt.Errorf("获取视图列表失败: %w", err)
+		return nil, stats.fail(pair.Name, "", StepConnect, err)
+	}
+
+	logger.Info("找到 %d 个视图需要更新（过滤 %d 个），并发数: %d", len(views), len(filtered), vs.Workers())
+
+	// 一次性预取本轮所有 SR 后缀表的 DDL
+	srTables := make([]string, len(views))
+	for i, viewName := range views {
+		srTables[i] = vu.getStarRocksTableNameFromView(viewName, pair)
 	}
+	conn.meta.PrefetchSRDDL(srTables, vs.Workers())
 
-	logger.Info("找到 %d 个视图需要更新", len(views))
+	// 并行更新视图，共用连接池；fail_fast 时首个失败后不再派发新视图
+	type viewResult struct {
+		outcome string
+		step    UpdateStep
+	}
+	results := make([]viewResult, len(views))
+	errs := common.RunBounded(len(views), vs.Workers(), vs.ViewTimeout(), !continueOnError, func(ctx context.Context, i int) error {
+		outcome, step, err := vu.UpdateSingleView(ctx, srDB, chDB, conn.meta, pair, views[i])
+		results[i] = viewResult{outcome: outcome, step: step}
+		return err
+	})
 
-	// 更新每个视图
-	for _, viewName := range views {
-		if err := vu.UpdateSingleView(srDB, chDB, dbManager, pair, viewName); err != nil {
+	// 按视图名顺序汇总结果，保证日志顺序稳定
+	var firstErr error
+	for i, viewName := range views {
+		err := errs[i]
+		if errors.Is(err, common.ErrNotRun) {
+			logger.Debug("视图 %s 未执行（前序视图失败）", viewName)
+			continue
+		}
+		stats.Total++
+		if err != nil {
+			step := results[i].step
+			if errors.Is(err, common.ErrTimeout) {
+				step = StepTimeout
+			}
 			logger.Error("更新视图 %s 失败: %v", viewName, err)
-			return err
+			vu.recordResult(pair.Name, viewName, "", step, err)
+			ferr := stats.fail(pair.Name, viewName, step, err)
+			if firstErr == nil {
+				firstErr = ferr
+			}
+			continue
 		}
-		logger.Debug("视图 %s 更新成功", viewName)
+		stats.record(results[i].outcome)
+		vu.recordResult(pair.Name, viewName, results[i].outcome, "", nil)
+		logger.Debug("视图 %s 处理完成: %s", viewName, results[i].outcome)
 	}
 
-	return nil
+	if !continueOnError && firstErr != nil {
+		return filtered, firstErr
+	}
+	return filtered, nil
 }
 
-// getAllViews 获取所有视图名称
-func (vu *ViewUpdater) getAllViews(srDB *sql.DB, database string) ([]string, error) {
-	query := fmt.Sprintf("SELECT TABLE_NAME FROM information_schema.VIEWS WHERE TABLE_SCHEMA = '%s'", database)
-
-	retryConfig := retry.Config{
-		MaxRetries: vu.config.Retry.MaxRetries,
-		Delay:      time.Duration(vu.config.Retry.DelayMs) * time.Millisecond,
+// connFor 返回数据库对的常驻连接，首次使用时创建并初始化连接池；初始化失败不缓存，下一轮重试
+func (vu *ViewUpdater) connFor(pairIndex int) (*pairConn, error) {
+	vu.connMu.Lock()
+	defer vu.connMu.Unlock()
+	if conn, ok := vu.conns[pairIndex]; ok {
+		return conn, nil
 	}
-	rows, err := retry.QueryWithRetry(srDB, retryConfig, query)
+	dbManager := mdb.NewDatabasePairManager(vu.config, pairIndex)
+	// 主动初始化连接池，未初始化不允许继续
+	err := dbManager.Init()
+	vu.telemetry.observePool(vu.config.DatabasePairs[pairIndex].Name, err)
 	if err != nil {
-		return nil, fmt.Errorf("查询视图失败: %w", err)
+		return nil, fmt.Errorf("初始化数据库连接失败: %w", err)
+	}
+	conn := &pairConn{
+		dbManager: dbManager,
+		meta:      metacache.New(vu.config.DatabasePairs[pairIndex].Name, dbManager),
+	}
+	vu.conns[pairIndex] = conn
+	return conn, nil
+}
+
+// dropConn 丢弃数据库对的常驻连接，下一轮重新创建
+func (vu *ViewUpdater) dropConn(pairIndex int) {
+	vu.connMu.Lock()
+	defer vu.connMu.Unlock()
+	delete(vu.conns, pairIndex)
+}
+
+// reportFiltered 输出本轮被过滤的视图；与同名一轮（调度项或手动触发）上次相同时仅在 DEBUG 级别输出数量
+func (vu *ViewUpdater) reportFiltered(key string, filtered []common.Filtered) {
+	var sb strings.Builder
+	for _, f := range filtered {
+		fmt.Fprintf(&sb, "%s/%s:%s;", f.Pair, f.Table, f.Reason)
+	}
+	vu.mu.Lock()
+	changed := sb.String() != vu.lastFiltered[key]
+	vu.lastFiltered[key] = sb.String()
+	vu.mu.Unlock()
+	if !changed {
+		logger.Debug("本轮过滤 %d 个视图（与上一轮相同）", len(filtered))
+		return
 	}
-	defer rows.Close()
+	common.LogFiltered(filtered)
+}
 
+// managedViews 返回本轮需要更新的视图（按名称排序）及被过滤的视图
+func (vu *ViewUpdater) managedViews(srDB *sql.DB, meta *metacache.Cache, pair mcfg.DatabasePair, sel selection) ([]string, []common.Filtered, error) {
+	names, err := vu.listManaged(srDB, meta, pair)
+	if err != nil {
+		return nil, nil, err
+	}
+	vu.managedMu.Lock()
+	vu.managed[pair.Name] = names
+	vu.managedMu.Unlock()
+	now := time.Now()
 	var views []string
-	for rows.Next() {
-		var viewName string
-		if err := rows.Scan(&viewName); err != nil {
-			return nil, fmt.Errorf("扫描视图名称失败: %w", err)
+	var filtered []common.Filtered
+	for _, name := range names {
+		if !vu.selected(name, sel) {
+			continue
+		}
+		if reason := vu.exclusion(pair.Name, name, now); reason != "" {
+			filtered = append(filtered, common.Filtered{Pair: pair.Name, Table: name, Reason: reason})
+			continue
+		}
+		views = append(views, name)
+	}
+	return views, filtered, nil
+}
+
+// exclusion 返回视图不参与更新的原因（ignore_tables、命令行范围、表级 enabled/frozen_until、自愈隔离），参与时返回空串
+func (vu *ViewUpdater) exclusion(pair, view string, now time.Time) string {
+	for _, t := range vu.config.IgnoreTables {
+		if t == view {
+			return common.ReasonIgnored
 		}
-		views = append(views, viewName)
 	}
+	if ok, reason := vu.scope.MatchTable(view); !ok {
+		return reason
+	}
+	if reason := vu.holdReason(view, now); reason != "" {
+		return reason
+	}
+	if reason := vu.quarantined(pair, view); reason != "" {
+		return ReasonQuarantined + "（" + reason + "）"
+	}
+	return ""
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("遍历视图列表失败: %w", err)
+// listManaged 返回由 cksr 管理的视图（按名称排序）。
+// 视图满足以下任一条件即视为由 cksr 管理：SR 中存在同名加后缀的原生表且 CK 中存在同名表，或带有 init 写入的归属注释；
+// 其余视图（如同库中手写的视图）仅在 DEBUG 级别记录后跳过
+func (vu *ViewUpdater) listManaged(srDB *sql.DB, meta *metacache.Cache, pair mcfg.DatabasePair) ([]string, error) {
+	retryConfig := retry.Config{
+		MaxRetries: vu.config.Retry.MaxRetries,
+		Delay:      time.Duration(vu.config.Retry.DelayMs) * time.Millisecond,
+	}
+	comments, err := common.ViewComments(srDB, retryConfig, pair.StarRocks.Database)
+	if err != nil {
+		return nil, fmt.Errorf("获取视图列表失败: %w", err)
+	}
+	srTypes, err := meta.GetStarRocksTablesTypes()
+	if err != nil {
+		return nil, fmt.Errorf("获取StarRocks表类型失败: %w", err)
+	}
+	ckTables, err := meta.ExportClickHouseTablesAsParserTables()
+	if err != nil {
+		return nil, fmt.Errorf("导出ClickHouse表结构失败: %w", err)
 	}
 
-	return views, nil
+	names := make([]string, 0, len(comments))
+	for name := range comments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var managed []string
+	for _, name := range names {
+		_, hasCK := ckTables[name]
+		hasSuffixed := strings.ToUpper(srTypes[vu.getStarRocksTableNameFromView(name, pair)]) == mdb.StarRocksTableTypeBaseTable
+		if !(hasCK && hasSuffixed) && comments[name] != builder.ViewOwnerComment {
+			logger.Debug("跳过非 cksr 管理的视图: %s (CK表存在=%t, SR后缀表存在=%t, 无归属注释)", name, hasCK, hasSuffixed)
+			continue
+		}
+		managed = append(managed, name)
+	}
+	return managed, nil
 }
 
-// UpdateSingleView 更新单个视图的时间边界
-func (vu *ViewUpdater) UpdateSingleView(srDB, chDB *sql.DB, dbManager *mdb.DatabasePairManager, pair mcfg.DatabasePair, viewName string) error {
+// UpdateSingleView 更新单个视图的时间边界，返回结果；失败时同时返回失败的步骤。ctx 超时后不再开始新的查询
+func (vu *ViewUpdater) UpdateSingleView(ctx context.Context, srDB, chDB *sql.DB, meta updaterun.Metadata, pair mcfg.DatabasePair, viewName string) (string, UpdateStep, error) {
 	// 自动更新：自行计算时间边界，然后委托一次性更新库执行
 	srTableName := vu.getStarRocksTableNameFromView(viewName, pair)
 
@@ -255,52 +578,97 @@ func (vu *ViewUpdater) UpdateSingleView(srDB, chDB *sql.DB, dbManager *mdb.Datab
 		}
 	}
 
-	// 查询最小时间戳
-	minQuery := fmt.Sprintf("select min(%s) from %s.%s", tsCol, pair.StarRocks.Database, srTableName)
-	var partStr string
-
+	// 按配置的边界策略计算新边界（默认 sr_min，与原行为一致）
 	retryConfig := retry.Config{
 		MaxRetries: vu.config.Retry.MaxRetries,
 		Delay:      time.Duration(vu.config.Retry.DelayMs) * time.Millisecond,
 	}
-
-	switch strings.ToLower(tsType) {
-	case "datetime", "date":
-		var nullable *string
-		if err := retry.QueryRowAndScanWithRetry(srDB, retryConfig, minQuery, []interface{}{&nullable}); err != nil {
-			return fmt.Errorf("查询最小时间戳失败: %w", err)
+	decision, err := boundary.Compute(settings.Of(vu.config).BoundaryFor(viewName), boundary.Source{
+		SRDB:       srDB,
+		CKDB:       chDB,
+		Retry:      retryConfig,
+		SRDatabase: pair.StarRocks.Database,
+		SRTable:    srTableName,
+		SRView:     viewName,
+		CKDatabase: pair.ClickHouse.Database,
+		CKTable:    viewName,
+		Column:     tsCol,
+		Type:       tsType,
+		Ctx:        ctx,
+	})
+	if err != nil {
+		return "", StepBoundary, fmt.Errorf("计算视图 %s 的时间边界失败: %w", viewName, err)
+	}
+	if decision.Keep {
+		logger.Info("视图 %s 保持当前边界（%s）", viewName, decision.Reason)
+		return OutcomeKept, "", nil
+	}
+	logger.Debug("视图 %s 新边界: %s（%s）", viewName, decision.Value, decision.Reason)
+
+	// 多层视图：各数据层之间的边界按各自的策略计算，最后一个为 CK 与 SR 之间的边界
+	boundaries := []string{decision.Value}
+	if tiers := common.ViewTiers(vu.config, viewName); len(tiers) > 0 {
+		tierValues, err := vu.tierBoundaries(ctx, srDB, pair, viewName, tiers, tsCol, tsType, decision.Value, retryConfig)
+		if err != nil {
+			return "", StepBoundary, err
 		}
-		if nullable == nil || *nullable == "" {
-			// 默认最大值
-			if strings.ToLower(tsType) == "date" {
-				partStr = "'9999-12-31'"
-			} else {
-				partStr = "'9999-12-31 23:59:59'"
-			}
-		} else {
-			// 加引号
-			if strings.HasPrefix(*nullable, "'") {
-				partStr = *nullable
-			} else {
-				partStr = "'" + *nullable + "'"
-			}
+		boundaries = append(tierValues, decision.Value)
+	}
+
+	// 委托一次性更新库执行（显式传分区值）；无变化时跳过，边界回退时不执行
+	outcome, err := updaterun.UpdateSingleView(ctx, vu.config, srDB, chDB, meta, pair, viewName, boundaries, false)
+	if errors.Is(err, updaterun.ErrBoundaryRegression) {
+		// 已记录为异常，本轮保持当前边界，不影响其他视图
+		return OutcomeRegression, "", nil
+	}
+	if err != nil {
+		return "", StepAlter, err
+	}
+	vu.telemetry.setBoundary(pair.Name, viewName, decision.Value, tsType, settings.Of(vu.config).BoundaryFor(viewName))
+	return outcome, "", nil
+}
+
+// tierBoundaries 从晚到早计算各数据层与其后一层之间的边界（返回值从早到晚）：较晚一侧为下一数据层或 CK Catalog 中的 CK 表，
+// 较早一侧为该数据层；晚于其后边界时取其后边界，保证各分支区间首尾相接。empty_sr=keep 时同样取其后边界，即较晚一侧（为空）不分配数据
+func (vu *ViewUpdater) tierBoundaries(ctx context.Context, srDB *sql.DB, pair mcfg.DatabasePair, viewName string, tiers []builder.Tier, tsCol, tsType, last string, retryConfig retry.Config) ([]string, error) {
+	values := make([]string, len(tiers))
+	next := last
+	for i := len(tiers) - 1; i >= 0; i-- {
+		newerCatalog, newerDB, newerTable := pair.CatalogName, pair.ClickHouse.Database, viewName
+		if i+1 < len(tiers) {
+			newerCatalog, newerDB, newerTable = tiers[i+1].Catalog, tiers[i+1].DBName, tiers[i+1].Name
 		}
-	case "bigint":
-		var nullable *int64
-		if err := retry.QueryRowAndScanWithRetry(srDB, retryConfig, minQuery, []interface{}{&nullable}); err != nil {
-			return fmt.Errorf("查询最小时间戳失败: %w", err)
+		decision, err := boundary.Compute(settings.Of(vu.config).TierBoundaryFor(viewName, i), boundary.Source{
+			SRDB:       srDB,
+			Retry:      retryConfig,
+			SRCatalog:  newerCatalog,
+			SRDatabase: newerDB,
+			SRTable:    newerTable,
+			SRView:     viewName,
+			CKCatalog:  tiers[i].Catalog,
+			CKDatabase: tiers[i].DBName,
+			CKTable:    tiers[i].Name,
+			Column:     tsCol,
+			Type:       tsType,
+			FromEnd:    len(tiers) - i,
+			Ctx:        ctx,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("计算视图 %s 数据层 %s 的边界失败: %w", viewName, tiers[i].Ref(), err)
 		}
-		if nullable == nil {
-			partStr = "9999999999999"
-		} else {
-			partStr = fmt.Sprintf("%d", *nullable)
+		value := decision.Value
+		if decision.Keep {
+			value = next
+		} else if cmp, err := boundary.Compare(value, next); err != nil {
+			return nil, fmt.Errorf("比较视图 %s 数据层 %s 的边界失败: %w", viewName, tiers[i].Ref(), err)
+		} else if cmp > 0 {
+			value = next
 		}
-	default:
-		return fmt.Errorf("不支持的时间戳类型: %s", tsType)
+		logger.Debug("视图 %s 数据层 %s 新边界: %s（%s）", viewName, tiers[i].Ref(), value, decision.Reason)
+		values[i] = value
+		next = value
 	}
-
-	// 委托一次性更新库执行（显式传分区值）
-	return updaterun.UpdateSingleView(vu.config, srDB, chDB, dbManager, pair, viewName, partStr, true)
+	return values, nil
 }
 
 // getStarRocksTableNameFromView 根据视图名和配置后缀生成StarRocks表名
@@ -308,9 +676,39 @@ func (vu *ViewUpdater) getStarRocksTableNameFromView(viewName string, pair mcfg.
 	return viewName + pair.SRTableSuffix
 }
 
+// RunOnce 执行一个完整周期后返回（auto-update --once）：依次执行全局调度与各表级独立调度，
+// 不做随机延迟与补跑判断；任一调度项失败时返回错误。维护窗口由调用方检查（与 init 等命令一致，可 --ignore-blackout）
+func RunOnce(cfg *mcfg.Config, scope common.Scope) error {
+	vu, err := NewViewUpdater(cfg, scope)
+	if err != nil {
+		return err
+	}
+	defer vu.cancel()
+	jobs, err := vu.jobs()
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, j := range jobs {
+		start := time.Now()
+		if err := vu.updateViews(selection{job: j.only}); err != nil {
+			logger.Error("%s更新视图失败: %v", jobName(j.only), err)
+			failed = append(failed, jobName(j.only))
+			continue
+		}
+		vu.state.record(j.only, start)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个调度项执行失败: %s", len(failed), strings.Join(failed, "、"))
+	}
+	logger.Info("单次运行完成，共 %d 个调度项", len(jobs))
+	return nil
+}
+
 // Run 统一入口：启动视图更新器并阻塞等待退出信号
-func Run(cfg *mcfg.Config) error {
-	viewUpdater, err := NewViewUpdater(cfg)
+func Run(cfg *mcfg.Config, scope common.Scope) error {
+	viewUpdater, err := NewViewUpdater(cfg, scope)
 	if err != nil {
 		logger.Error("创建视图更新器失败: %v", err)
 		return err
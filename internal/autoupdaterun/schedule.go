@@ -0,0 +1,296 @@
+package autoupdaterun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"cksr/internal/settings"
+	"cksr/logger"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ReasonDisabled 视图在 view_updater.tables 中被禁用
+const ReasonDisabled = "view_updater.tables 中 enabled=false"
+
+// globalJob 全局调度项的名称（updateViews 的 only 参数为空）
+const globalJob = ""
+
+// 停机期间错过调度时的处理（view_updater.catch_up）
+const (
+	CatchUpSkip    = "skip"     // 等待下次触发（默认）
+	CatchUpRunOnce = "run_once" // 启动后立即补跑一次，错过多次也只补一次
+)
+
+// ErrInvalidSchedule 调度配置（cron 表达式、frozen_until、blackout）非法
+var ErrInvalidSchedule = errors.New("调度配置非法")
+
+// cronParser 与 cron.New(cron.WithSeconds()) 使用的解析规则一致
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// job 一个调度项：全局调度或某个视图的独立调度
+type job struct {
+	only     string
+	spec     string
+	schedule cron.Schedule
+}
+
+// registered 已注册到调度器的调度项
+type registered struct {
+	job
+	id     cron.EntryID
+	runner cron.Job // 已包装跳过重叠与随机延迟
+}
+
+// selection 一轮更新的范围
+type selection struct {
+	job    string // 调度项：globalJob 或配置了独立调度的视图名
+	manual bool   // 手动触发：不按调度项划分，仍遵守 enabled、frozen_until、ignore_tables 与命令行范围
+	pair   string // 非空时只处理该数据库对
+	view   string // 非空时只处理该视图
+}
+
+// name 返回本轮在日志与过滤报告中的名称
+func (s selection) name() string {
+	if !s.manual {
+		return jobName(s.job)
+	}
+	switch {
+	case s.pair != "" && s.view != "":
+		return "手动触发（数据库对 " + s.pair + "，视图 " + s.view + "）"
+	case s.pair != "":
+		return "手动触发（数据库对 " + s.pair + "）"
+	case s.view != "":
+		return "手动触发（视图 " + s.view + "）"
+	}
+	return "手动触发"
+}
+
+// reconcile 本轮是否执行自愈：全局调度与未指定视图的手动触发执行，表级独立调度与单视图触发不执行
+func (s selection) reconcile() bool {
+	return s.view == "" && (s.manual || s.job == globalJob)
+}
+
+// jobs 返回全部调度项：全局调度在前，其后为启用的表级独立调度（按视图名排序）
+func (vu *ViewUpdater) jobs() ([]job, error) {
+	st := settings.Of(vu.config)
+	for _, name := range sortedTables(st) {
+		if _, _, err := st.Table(name).FrozenAt(time.Now()); err != nil {
+			return nil, fmt.Errorf("%w: view_updater.tables.%s.frozen_until: %v", ErrInvalidSchedule, name, err)
+		}
+	}
+
+	specs := []job{{only: globalJob, spec: vu.config.ViewUpdater.CronExpression}}
+	for _, name := range st.ScheduledTables() {
+		t := st.Table(name)
+		if !t.IsEnabled() {
+			continue
+		}
+		specs = append(specs, job{only: name, spec: strings.TrimSpace(t.CronExpression)})
+	}
+	for i := range specs {
+		schedule, err := cronParser.Parse(specs[i].spec)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s的 Cron 表达式 %q: %v", ErrInvalidSchedule, jobName(specs[i].only), specs[i].spec, err)
+		}
+		specs[i].schedule = schedule
+	}
+	return specs, nil
+}
+
+// registerJobs 注册全局调度项与表级独立调度项。
+// 同一调度项上一轮未结束时跳过本次触发；不同调度项共用同一把锁，由 tickMu 串行执行
+func (vu *ViewUpdater) registerJobs(ctx context.Context, c *cron.Cron) ([]registered, error) {
+	jobs, err := vu.jobs()
+	if err != nil {
+		return nil, err
+	}
+	st := settings.Of(vu.config)
+	for _, name := range st.ScheduledTables() {
+		if !st.Table(name).IsEnabled() {
+			logger.Info("视图 %s 已禁用（enabled=false），不注册独立调度", name)
+		}
+	}
+	regs := make([]registered, 0, len(jobs))
+	for _, j := range jobs {
+		runner := cron.NewChain(vu.skipIfStillRunning(j.only), vu.withJitter(ctx, j.only)).Then(vu.tickJob(j.only))
+		regs = append(regs, registered{job: j, id: c.Schedule(j.schedule, runner), runner: runner})
+		logger.Info("注册%s，Cron表达式: %s", jobName(j.only), j.spec)
+	}
+	return regs, nil
+}
+
+// tickJob 执行一轮更新，成功后记录执行时间，供重启后判断是否错过调度；调度暂停时跳过。
+// 失败的一轮不记录，停机后按错过调度处理
+func (vu *ViewUpdater) tickJob(only string) cron.Job {
+	return cron.FuncJob(func() {
+		if vu.paused.Load() {
+			logger.Info("调度已暂停，跳过%s的本次触发", jobName(only))
+			return
+		}
+		if a, ok := vu.blackout.Active(); ok {
+			logger.Warn("处于维护窗口 %s（至 %s），跳过%s的本次触发", a.Name, a.Until.Format("2006-01-02 15:04:05"), jobName(only))
+			return
+		}
+		start := time.Now()
+		if err := vu.updateViews(selection{job: only}); err != nil {
+			logger.Error("%s更新视图失败: %v", jobName(only), err)
+			return
+		}
+		vu.state.record(only, start)
+	})
+}
+
+// skipIfStillRunning 同一调度项上一轮仍在执行（含抖动等待）时跳过本次触发，语义同 cron.SkipIfStillRunning
+func (vu *ViewUpdater) skipIfStillRunning(only string) cron.JobWrapper {
+	return func(j cron.Job) cron.Job {
+		idle := make(chan struct{}, 1)
+		idle <- struct{}{}
+		return cron.FuncJob(func() {
+			select {
+			case v := <-idle:
+				defer func() { idle <- v }()
+				j.Run()
+			default:
+				logger.Warn("%s上一轮仍在执行，跳过本次触发", jobName(only))
+			}
+		})
+	}
+}
+
+// withJitter 触发后随机等待 [0, jitter_seconds) 秒再执行；等待期间停止调度（停止更新器或失去 leader 身份）则放弃本轮
+func (vu *ViewUpdater) withJitter(ctx context.Context, only string) cron.JobWrapper {
+	return func(j cron.Job) cron.Job {
+		limit := settings.Of(vu.config).ViewUpdater.Jitter()
+		if limit <= 0 {
+			return j
+		}
+		return cron.FuncJob(func() {
+			d := time.Duration(rand.Int63n(int64(limit)))
+			logger.Debug("%s随机延迟 %s 后开始", jobName(only), d.Round(time.Millisecond))
+			select {
+			case <-time.After(d):
+				j.Run()
+			case <-ctx.Done():
+				logger.Info("调度已停止，放弃%s的本轮执行", jobName(only))
+			}
+		})
+	}
+}
+
+// catchUp 按 catch_up 策略处理停机期间错过的调度：上次执行后的下一次触发时间早于当前时间即视为错过
+func (vu *ViewUpdater) catchUp(jobs []registered) {
+	policy := strings.ToLower(strings.TrimSpace(settings.Of(vu.config).ViewUpdater.CatchUp))
+	now := time.Now()
+	for _, j := range jobs {
+		last, ok := vu.state.lastRun(j.only)
+		if !ok {
+			logger.Debug("%s没有执行记录，不检查错过的调度", jobName(j.only))
+			continue
+		}
+		missed := j.schedule.Next(last)
+		if !missed.Before(now) {
+			continue
+		}
+		if policy != CatchUpRunOnce {
+			logger.Info("%s在停机期间错过调度（上次成功执行 %s，错过 %s），按 catch_up=skip 等待下次触发",
+				jobName(j.only), last.Format("2006-01-02 15:04:05"), missed.Format("2006-01-02 15:04:05"))
+			continue
+		}
+		logger.Info("%s在停机期间错过调度（上次成功执行 %s，错过 %s），立即补跑一次",
+			jobName(j.only), last.Format("2006-01-02 15:04:05"), missed.Format("2006-01-02 15:04:05"))
+		go j.runner.Run()
+	}
+}
+
+// selected 判断视图是否属于本轮范围：配置了独立调度的视图只由其自身的调度项更新，手动触发不按调度项划分；
+// 不属于本轮的视图不计入过滤结果
+func (vu *ViewUpdater) selected(view string, sel selection) bool {
+	if sel.view != "" && view != sel.view {
+		return false
+	}
+	if sel.manual {
+		return true
+	}
+	hasOwnJob := strings.TrimSpace(settings.Of(vu.config).Table(view).CronExpression) != ""
+	if sel.job == globalJob {
+		return !hasOwnJob
+	}
+	return view == sel.job
+}
+
+// freezeOverride 管理接口对单个视图的冻结设置：frozen 为 false 表示解冻（忽略配置中的 frozen_until），
+// until 为零值表示冻结到手动解冻为止
+type freezeOverride struct {
+	frozen bool
+	until  time.Time
+}
+
+// setOverride 记录管理接口对视图的冻结/解冻设置，覆盖之前的设置
+func (vu *ViewUpdater) setOverride(view string, o freezeOverride) {
+	vu.overrideMu.Lock()
+	vu.overrides[view] = o
+	vu.overrideMu.Unlock()
+}
+
+// holdReason 按表级 enabled、管理接口的冻结/解冻设置与 frozen_until 判断视图是否暂不更新，返回原因；可以更新时返回空串。
+// 每轮调用时判断，管理接口的设置在下一轮即生效
+func (vu *ViewUpdater) holdReason(view string, now time.Time) string {
+	t := settings.Of(vu.config).Table(view)
+	if !t.IsEnabled() {
+		return ReasonDisabled
+	}
+	vu.overrideMu.Lock()
+	o, ok := vu.overrides[view]
+	vu.overrideMu.Unlock()
+	if ok {
+		switch {
+		case !o.frozen:
+			return ""
+		case o.until.IsZero():
+			return "已通过管理接口冻结，需手动解冻"
+		case now.Before(o.until):
+			return fmt.Sprintf("已通过管理接口冻结至 %s", o.until.Format("2006-01-02 15:04:05"))
+		}
+		// 管理接口设置的冻结已到期，按配置判断
+	}
+	frozen, until, err := t.FrozenAt(now)
+	if err != nil {
+		// 启动时已校验，这里仅防御
+		logger.Warn("视图 %s 的冻结时间非法，按未冻结处理: %v", view, err)
+	}
+	if frozen {
+		return fmt.Sprintf("边界冻结至 %s", until.Format("2006-01-02 15:04:05"))
+	}
+	return ""
+}
+
+// scheduleLabel 返回视图所属的调度：独立调度的 cron 表达式，或 global
+func (vu *ViewUpdater) scheduleLabel(view string) string {
+	if expr := strings.TrimSpace(settings.Of(vu.config).Table(view).CronExpression); expr != "" {
+		return expr
+	}
+	return "global"
+}
+
+// jobName 返回调度项在日志中的名称
+func jobName(only string) string {
+	if only == globalJob {
+		return "全局调度"
+	}
+	return "视图 " + only + " 的独立调度"
+}
+
+func sortedTables(st *settings.Settings) []string {
+	names := make([]string, 0, len(st.ViewUpdater.Tables))
+	for name := range st.ViewUpdater.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,90 @@
+package autoupdaterun
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"cksr/logger"
+)
+
+// runState 各调度项最近一次成功执行的触发时间，持久化到状态文件，供重启后判断是否错过调度
+type runState struct {
+	path string
+	mu   sync.Mutex
+	// LastRun 键为 global 或 table:<视图名>
+	LastRun map[string]time.Time `json:"last_run"`
+}
+
+// loadRunState 读取状态文件；路径为空时只在内存中记录，文件不存在或损坏时视为没有执行记录
+func loadRunState(path string) *runState {
+	st := &runState{path: path, LastRun: make(map[string]time.Time)}
+	if path == "" {
+		logger.Debug("未配置调度状态文件（view_updater.state_file 与 temp_dir 均为空），不记录执行时间")
+		return st
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Warn("读取调度状态文件 %s 失败，视为没有执行记录: %v", path, err)
+		}
+		return st
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		logger.Warn("解析调度状态文件 %s 失败，视为没有执行记录: %v", path, err)
+		return st
+	}
+	if st.LastRun == nil {
+		st.LastRun = make(map[string]time.Time)
+	}
+	return st
+}
+
+// stateKey 返回调度项在状态文件中的键
+func stateKey(only string) string {
+	if only == globalJob {
+		return "global"
+	}
+	return "table:" + only
+}
+
+// lastRun 返回调度项最近一次成功执行的触发时间
+func (s *runState) lastRun(only string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.LastRun[stateKey(only)]
+	return t, ok
+}
+
+// record 记录调度项的执行时间并写回状态文件；写入失败仅告警
+func (s *runState) record(only string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.LastRun[stateKey(only)] = at
+	if s.path == "" {
+		return
+	}
+	if err := s.save(); err != nil {
+		logger.Warn("写入调度状态文件 %s 失败: %v", s.path, err)
+	}
+}
+
+// save 先写临时文件再重命名，避免进程中途退出留下半个文件
+func (s *runState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化调度状态失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,90 @@
+package autoupdaterun
+
+import (
+	"fmt"
+
+	"cksr/internal/updaterun"
+	"cksr/logger"
+)
+
+// 失败处理策略（view_updater.strategy），取值与 rollback.strategy 一致，为空时等同 fail_fast
+const (
+	StrategyFailFast        = "fail_fast"         // 任一视图失败即结束本轮
+	StrategyContinueOnError = "continue_on_error" // 记录失败并继续处理其余视图与数据库对
+)
+
+// UpdateStep 视图更新步骤
+type UpdateStep string
+
+const (
+	StepConnect  UpdateStep = "connect"    // 初始化连接并列出视图（库对级）
+	StepBoundary UpdateStep = "boundary"   // 按策略计算新边界
+	StepAlter    UpdateStep = "alter_view" // 生成并执行 ALTER VIEW
+	StepTimeout  UpdateStep = "timeout"    // 超过 view_updater.view_timeout_seconds
+)
+
+// 单个视图在本轮的结果，补充 updaterun 中的 updated/unchanged
+const (
+	OutcomeKept       = "kept"               // 按 empty_sr=keep 保持当前边界
+	OutcomeRegression = "regression_blocked" // 新边界早于当前边界，被单调性保护拦截
+)
+
+// FailureRecord 单个失败记录；库对级失败的 View 为空
+type FailureRecord struct {
+	Pair string
+	View string
+	Step UpdateStep
+	Err  error
+}
+
+// FailureRecord 实现 error 接口，既可用于统计也可用于上抛
+func (e *FailureRecord) Error() string {
+	if e.View == "" {
+		return fmt.Sprintf("数据库对 %s 步骤 %s 失败: %v", e.Pair, e.Step, e.Err)
+	}
+	return fmt.Sprintf("视图 %s.%s 步骤 %s 失败: %v", e.Pair, e.View, e.Step, e.Err)
+}
+
+// TickStats 单轮更新的统计信息
+type TickStats struct {
+	Total       int
+	Updated     int
+	Unchanged   int
+	Kept        int
+	Regressions int
+	Failed      []FailureRecord
+}
+
+// record 记录单个视图成功结束时的结果
+func (s *TickStats) record(outcome string) {
+	switch outcome {
+	case updaterun.OutcomeUpdated:
+		s.Updated++
+	case updaterun.OutcomeUnchanged:
+		s.Unchanged++
+	case OutcomeKept:
+		s.Kept++
+	case OutcomeRegression:
+		s.Regressions++
+	}
+}
+
+// fail 记录失败并返回可上抛的错误
+func (s *TickStats) fail(pair, view string, step UpdateStep, err error) error {
+	f := FailureRecord{Pair: pair, View: view, Step: step, Err: err}
+	s.Failed = append(s.Failed, f)
+	return &f
+}
+
+// print 打印本轮统计（每轮结束时调用一次）
+func (s *TickStats) print() {
+	logger.Info("视图更新统计 - 本轮")
+	logger.Info("总视图: %d, 已更新: %d, 无变化: %d, 保持边界: %d, 回退拦截: %d, 失败: %d",
+		s.Total, s.Updated, s.Unchanged, s.Kept, s.Regressions, len(s.Failed))
+	if len(s.Failed) > 0 {
+		logger.Info("失败详情：")
+		for i, f := range s.Failed {
+			logger.Error("[%d] 库对: %s, 视图: %s, 步骤: %s, 错误: %v", i+1, f.Pair, f.View, string(f.Step), f.Err)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package autoupdaterun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"cksr/internal/boundary"
+	"cksr/internal/metrics"
+	"cksr/internal/settings"
+	"cksr/logger"
+)
+
+// tickBuckets 单轮耗时直方图的上界（秒）
+var tickBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+// telemetry auto-update 的指标与就绪状态
+type telemetry struct {
+	registry         *metrics.Registry
+	ticks            *metrics.CounterVec   // job, result
+	tickDuration     *metrics.HistogramVec // job
+	viewUpdates      *metrics.CounterVec   // pair, view, result
+	viewBoundary     *metrics.GaugeVec     // pair, view
+	lockFailures     *metrics.CounterVec
+	leaderChanges    *metrics.CounterVec
+	stepFailures     *metrics.CounterVec // step
+	sqlRetries       *metrics.CounterVec
+	poolFailures     *metrics.CounterVec // pair
+	reconcileActions *metrics.CounterVec // pair, action, result
+
+	mu          sync.Mutex
+	lastSuccess map[[2]string]time.Time // [数据库对, 视图] -> 最近一次成功时间
+	failedTicks int                     // 连续失败的轮数
+	poolErrs    map[string]string       // 连接池初始化失败的数据库对 -> 错误
+}
+
+func newTelemetry() *telemetry {
+	r := metrics.NewRegistry()
+	return &telemetry{
+		registry:         r,
+		ticks:            r.NewCounterVec("cksr_ticks_total", "auto-update 执行的轮数，result 为 success 或 failure", "job", "result"),
+		tickDuration:     r.NewHistogramVec("cksr_tick_duration_seconds", "auto-update 单轮耗时（含等待共享锁）", tickBuckets, "job"),
+		viewUpdates:      r.NewCounterVec("cksr_view_updates_total", "视图更新次数，result 为 updated、unchanged、kept、regression_blocked 或 failure", "pair", "view", "result"),
+		viewBoundary:     r.NewGaugeVec("cksr_view_boundary_seconds", "视图最近一次写入的时间边界（Unix 秒），哨兵值不记录", "pair", "view"),
+		lockFailures:     r.NewCounterVec("cksr_lock_acquire_failures_total", "获取共享锁失败的次数"),
+		leaderChanges:    r.NewCounterVec("cksr_leader_changes_total", "选主模式下观察到的 leader 变化次数"),
+		stepFailures:     r.NewCounterVec("cksr_step_failures_total", "失败的更新步骤次数（SQL 已按 retry 配置重试），step 为 connect、boundary、alter_view 或 timeout", "step"),
+		sqlRetries:       r.NewCounterVec("cksr_sql_retries_total", "更新视图时 SQL 失败后的重试次数（每次重新执行计一次）"),
+		poolFailures:     r.NewCounterVec("cksr_pool_init_failures_total", "数据库连接池初始化失败的次数", "pair"),
+		reconcileActions: r.NewCounterVec("cksr_reconcile_actions_total", "自愈动作次数，result 为 planned（dry-run）、applied 或 failed", "pair", "action", "result"),
+		lastSuccess:      make(map[[2]string]time.Time),
+		poolErrs:         make(map[string]string),
+	}
+}
+
+// registerStateGauges 注册在抓取时计算的仪表盘
+func (vu *ViewUpdater) registerStateGauges() {
+	t := vu.telemetry
+	t.registry.NewGaugeFunc("cksr_view_seconds_since_last_success", "距视图最近一次成功更新的秒数（本进程内）", func(emit func(float64, ...string)) {
+		now := time.Now()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		for k, at := range t.lastSuccess {
+			emit(now.Sub(at).Seconds(), k[0], k[1])
+		}
+	}, "pair", "view")
+	t.registry.NewGaugeFunc("cksr_consecutive_failed_ticks", "连续失败的轮数", func(emit func(float64, ...string)) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		emit(float64(t.failedTicks))
+	})
+	t.registry.NewGaugeFunc("cksr_is_leader", "本实例是否为 leader；未启用选主时恒为 1", func(emit func(float64, ...string)) {
+		status, ok := vu.LeaderStatus()
+		emit(boolValue(!ok || status.IsLeader))
+	})
+	t.registry.NewGaugeFunc("cksr_scheduler_paused", "调度是否通过管理接口暂停", func(emit func(float64, ...string)) {
+		emit(boolValue(vu.paused.Load()))
+	})
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// jobLabel 指标中的调度项标签：global、table:<视图名> 或 manual
+func jobLabel(sel selection) string {
+	if sel.manual {
+		return "manual"
+	}
+	return stateKey(sel.job)
+}
+
+// observeTick 记录一轮的结果与耗时，并维护连续失败轮数
+func (t *telemetry) observeTick(sel selection, elapsed time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	t.ticks.Inc(jobLabel(sel), result)
+	t.tickDuration.Observe(elapsed.Seconds(), jobLabel(sel))
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.failedTicks++
+	} else {
+		t.failedTicks = 0
+	}
+}
+
+// observeView 记录单个视图的结果；updated、unchanged、kept 视为成功，regression_blocked 不刷新最近成功时间
+func (t *telemetry) observeView(pair, view, outcome string, step UpdateStep, err error) {
+	if err != nil {
+		t.viewUpdates.Inc(pair, view, "failure")
+		return
+	}
+	t.viewUpdates.Inc(pair, view, outcome)
+	if outcome == OutcomeRegression {
+		return
+	}
+	t.mu.Lock()
+	t.lastSuccess[[2]string{pair, view}] = time.Now()
+	t.mu.Unlock()
+}
+
+// observeFailures 按步骤记录本轮的失败（库对级与视图级）
+func (t *telemetry) observeFailures(failed []FailureRecord) {
+	for _, f := range failed {
+		t.stepFailures.Inc(string(f.Step))
+	}
+}
+
+// observeRetry 记录一次 SQL 重试
+func (t *telemetry) observeRetry() {
+	t.sqlRetries.Inc()
+}
+
+// setBoundary 记录视图写入的边界；无法换算为时间（如哨兵值）时删除该序列
+func (t *telemetry) setBoundary(pair, view, value, typ string, b settings.Boundary) {
+	if epoch, ok := boundary.EpochSeconds(value, typ, boundary.Normalize(b).BigintUnit); ok {
+		t.viewBoundary.Set(epoch, pair, view)
+		return
+	}
+	t.viewBoundary.Delete(pair, view)
+}
+
+// observePool 记录数据库对连接池的初始化结果
+func (t *telemetry) observePool(pair string, err error) {
+	if err != nil {
+		t.poolFailures.Inc(pair)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.poolErrs[pair] = err.Error()
+	} else {
+		delete(t.poolErrs, pair)
+	}
+}
+
+// notReady 返回未就绪的原因，就绪时返回空
+func (t *telemetry) notReady(failedTicks int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var reasons []string
+	if t.failedTicks >= failedTicks {
+		reasons = append(reasons, fmt.Sprintf("连续 %d 轮更新失败", t.failedTicks))
+	}
+	pairs := make([]string, 0, len(t.poolErrs))
+	for pair := range t.poolErrs {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	for _, pair := range pairs {
+		reasons = append(reasons, fmt.Sprintf("数据库对 %s 连接池初始化失败: %s", pair, t.poolErrs[pair]))
+	}
+	return reasons
+}
+
+// failedPools 返回连接池初始化失败的数据库对
+func (t *telemetry) failedPools() map[string]bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	failed := make(map[string]bool, len(t.poolErrs))
+	for pair := range t.poolErrs {
+		failed[pair] = true
+	}
+	return failed
+}
+
+// probePools 初始化范围内各数据库对的连接池；only 非空时只初始化其中的数据库对
+func (vu *ViewUpdater) probePools(only map[string]bool) {
+	for i, pair := range vu.config.DatabasePairs {
+		if !vu.scope.MatchPair(pair.Name) || only != nil && !only[pair.Name] {
+			continue
+		}
+		if _, err := vu.connFor(i); err != nil {
+			logger.Warn("数据库对 %s 连接池初始化失败: %v", pair.Name, err)
+		}
+	}
+}
+
+// reprobePools 在后台重试初始化 only 中的连接池；已有重试在进行时直接返回，不阻塞调用方
+func (vu *ViewUpdater) reprobePools(only map[string]bool) {
+	if !vu.probing.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer vu.probing.Store(false)
+		vu.probePools(only)
+	}()
+}
+
+// startMetrics 按 view_updater.metrics 启动指标与健康检查接口；监听失败时返回错误
+func (vu *ViewUpdater) startMetrics() error {
+	m := settings.Of(vu.config).ViewUpdater.Metrics
+	if !m.Enabled {
+		return nil
+	}
+	addr := m.Address()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("指标接口监听 %s 失败: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", vu.handleMetrics)
+	mux.HandleFunc("GET /healthz", vu.handleHealthz)
+	mux.HandleFunc("GET /readyz", vu.handleReadyz)
+	vu.metricsServer = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := vu.metricsServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("指标接口异常退出: %v", err)
+		}
+	}()
+	logger.Info("指标接口已启动: http://%s/metrics", ln.Addr())
+
+	// 启动时即初始化连接池，使 /readyz 反映数据库是否可用（备实例也会检查）
+	vu.reprobePools(nil)
+	return nil
+}
+
+// stopMetrics 关闭指标接口
+func (vu *ViewUpdater) stopMetrics() {
+	if vu.metricsServer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := vu.metricsServer.Shutdown(ctx); err != nil {
+		logger.Warn("关闭指标接口失败: %v", err)
+	}
+	vu.metricsServer = nil
+}
+
+// handleMetrics GET /metrics：Prometheus 文本格式
+func (vu *ViewUpdater) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	vu.telemetry.registry.WriteText(w)
+}
+
+// handleHealthz GET /healthz：存活检查，进程能响应即返回 200
+func (vu *ViewUpdater) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadyz GET /readyz：连续失败轮数达到 view_updater.metrics.failed_ticks 或有数据库对连接池初始化失败时返回 503；
+// 只读取已记录的状态，连接池失败的数据库对在后台重试初始化，恢复后的下一次检查即就绪
+func (vu *ViewUpdater) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if failed := vu.telemetry.failedPools(); len(failed) > 0 {
+		vu.reprobePools(failed)
+	}
+	reasons := vu.telemetry.notReady(settings.Of(vu.config).ViewUpdater.Metrics.ReadinessFailedTicks())
+	if len(reasons) > 0 {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "not_ready", "reasons": reasons})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
@@ -0,0 +1,213 @@
+// Package blackout 判断当前是否处于禁止执行 DDL 的维护窗口（顶层 blackout 配置）。
+// 窗口有两种写法：cron + duration（每次 cron 触发后持续 duration），或 weekdays + start/end（每天的时间段）
+package blackout
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cksr/internal/interval"
+	"cksr/internal/settings"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ErrActive 当前处于维护窗口
+var ErrActive = errors.New("处于维护窗口")
+
+// cronParser 与 view_updater.cron_expression 的解析规则一致（带秒字段）
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// weekdayNames weekdays 支持的取值
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Active 生效中的维护窗口
+type Active struct {
+	Name  string
+	Until time.Time // 窗口结束时间（按配置时区）
+}
+
+// Schedule 已解析的维护窗口
+type Schedule struct {
+	loc     *time.Location
+	windows []window
+	now     func() time.Time
+}
+
+type window struct {
+	name string
+	// cron + duration
+	schedule cron.Schedule
+	duration time.Duration
+	// weekdays + start/end；days 为空表示每天
+	days       map[time.Weekday]bool
+	start, end time.Duration // 距当天零点的时长
+}
+
+// New 解析维护窗口配置；未配置窗口时返回的 Schedule 永不生效
+func New(b settings.Blackout) (*Schedule, error) {
+	s := &Schedule{loc: time.Local, now: time.Now}
+	if tz := strings.TrimSpace(b.Timezone); tz != "" {
+		loc, err := time.LoadLocation(tz)
+		if err != nil {
+			return nil, fmt.Errorf("blackout.timezone: 未知时区 %q: %w", tz, err)
+		}
+		s.loc = loc
+	}
+	for i, wc := range b.Windows {
+		w, err := parseWindow(wc)
+		if err != nil {
+			return nil, fmt.Errorf("blackout.windows.%d: %w", i, err)
+		}
+		if w.name == "" {
+			w.name = fmt.Sprintf("#%d", i)
+		}
+		s.windows = append(s.windows, w)
+	}
+	return s, nil
+}
+
+// WithClock 使用指定的时钟判断窗口，便于测试
+func (s *Schedule) WithClock(now func() time.Time) *Schedule {
+	c := *s
+	c.now = now
+	return &c
+}
+
+// Active 返回当前生效的维护窗口
+func (s *Schedule) Active() (Active, bool) {
+	return s.ActiveAt(s.now())
+}
+
+// ActiveAt 返回 t 时刻生效的维护窗口；多个窗口同时生效时返回结束最晚的一个
+func (s *Schedule) ActiveAt(t time.Time) (Active, bool) {
+	t = t.In(s.loc)
+	var found Active
+	ok := false
+	for _, w := range s.windows {
+		until, active := w.activeAt(t)
+		if active && (!ok || until.After(found.Until)) {
+			found = Active{Name: w.name, Until: until}
+			ok = true
+		}
+	}
+	return found, ok
+}
+
+// Check 处于维护窗口时返回包装了 ErrActive 的错误
+func (s *Schedule) Check() error {
+	if a, ok := s.Active(); ok {
+		return fmt.Errorf("%w %s（至 %s）", ErrActive, a.Name, a.Until.Format("2006-01-02 15:04:05 MST"))
+	}
+	return nil
+}
+
+func (w window) activeAt(t time.Time) (time.Time, bool) {
+	if w.schedule != nil {
+		// 找到 (t-duration, t] 内最后一次触发
+		var last time.Time
+		for next := w.schedule.Next(t.Add(-w.duration)); !next.After(t); next = w.schedule.Next(next) {
+			last = next
+		}
+		if last.IsZero() {
+			return time.Time{}, false
+		}
+		return last.Add(w.duration), true
+	}
+	// 跨午夜的窗口属于开始那一天，因此同时检查前一天
+	y, m, d := t.Date()
+	for _, offset := range []int{0, -1} {
+		day := time.Date(y, m, d+offset, 0, 0, 0, 0, t.Location())
+		if len(w.days) > 0 && !w.days[day.Weekday()] {
+			continue
+		}
+		start, end := clockOn(day, w.start), clockOn(day, w.end)
+		if w.end <= w.start {
+			end = clockOn(day.AddDate(0, 0, 1), w.end)
+		}
+		if !t.Before(start) && t.Before(end) {
+			return end, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// clockOn 返回 day 当天的某个时刻；按日期与时分构造，夏令时切换日也得到当地的墙上时间
+func clockOn(day time.Time, offset time.Duration) time.Time {
+	y, m, d := day.Date()
+	return time.Date(y, m, d, int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// ValidateWindow 校验单个窗口配置
+func ValidateWindow(wc settings.BlackoutWindow) error {
+	_, err := parseWindow(wc)
+	return err
+}
+
+func parseWindow(wc settings.BlackoutWindow) (window, error) {
+	w := window{name: strings.TrimSpace(wc.Name)}
+	hasCron := strings.TrimSpace(wc.Cron) != ""
+	hasRange := strings.TrimSpace(wc.Start) != "" || strings.TrimSpace(wc.End) != "" || len(wc.Weekdays) > 0
+	switch {
+	case hasCron && hasRange:
+		return w, fmt.Errorf("cron/duration 与 weekdays/start/end 只能二选一")
+	case hasCron:
+		sched, err := cronParser.Parse(strings.TrimSpace(wc.Cron))
+		if err != nil {
+			return w, fmt.Errorf("cron %q 非法: %w", wc.Cron, err)
+		}
+		d, err := interval.Parse(wc.Duration)
+		if err != nil {
+			return w, fmt.Errorf("duration: %w", err)
+		}
+		w.schedule, w.duration = sched, d
+		return w, nil
+	case hasRange:
+		var err error
+		if w.start, err = parseClock(wc.Start); err != nil {
+			return w, fmt.Errorf("start: %w", err)
+		}
+		if w.end, err = parseClock(wc.End); err != nil {
+			return w, fmt.Errorf("end: %w", err)
+		}
+		if len(wc.Weekdays) > 0 {
+			w.days = make(map[time.Weekday]bool, len(wc.Weekdays))
+			for _, name := range wc.Weekdays {
+				day, ok := parseWeekday(name)
+				if !ok {
+					return w, fmt.Errorf("weekdays: 未知的星期 %q（mon、tue、wed、thu、fri、sat、sun）", name)
+				}
+				w.days[day] = true
+			}
+		}
+		return w, nil
+	}
+	return w, fmt.Errorf("需配置 cron + duration 或 start + end")
+}
+
+// parseWeekday 解析星期，支持缩写（mon）与全称（monday），不区分大小写
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if len(s) < 3 {
+		return 0, false
+	}
+	day, ok := weekdayNames[s[:3]]
+	if !ok || len(s) > 3 && s != strings.ToLower(day.String()) {
+		return 0, false
+	}
+	return day, true
+}
+
+// parseClock 解析 HH:MM
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("%q 不是合法的时间（HH:MM）", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,208 @@
+package blackout
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata" // 测试依赖 IANA 时区，不依赖运行环境的 zoneinfo
+
+	"cksr/internal/settings"
+)
+
+func mustSchedule(t *testing.T, b settings.Blackout) *Schedule {
+	t.Helper()
+	s, err := New(b)
+	if err != nil {
+		t.Fatalf("解析维护窗口失败: %v", err)
+	}
+	return s
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+// probe 单个时刻的期望：active 为 false 时不检查 name 与 until
+type probe struct {
+	at     time.Time
+	active bool
+	name   string
+	until  time.Time
+}
+
+func checkProbes(t *testing.T, s *Schedule, probes []probe) {
+	t.Helper()
+	for _, p := range probes {
+		got, ok := s.ActiveAt(p.at)
+		if ok != p.active {
+			t.Errorf("%s: 是否生效 = %v，期望 %v（%+v）", p.at, ok, p.active, got)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if got.Name != p.name || !got.Until.Equal(p.until) {
+			t.Errorf("%s: 生效窗口 = %s 至 %s，期望 %s 至 %s", p.at, got.Name, got.Until, p.name, p.until)
+		}
+	}
+}
+
+func TestCronDurationWindow(t *testing.T) {
+	loc := mustLoad(t, "Asia/Shanghai")
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2025, month, day, hour, min, 0, 0, loc)
+	}
+	s := mustSchedule(t, settings.Blackout{
+		Timezone: "Asia/Shanghai",
+		Windows: []settings.BlackoutWindow{
+			{Name: "month-start", Cron: "0 0 0 1 * *", Duration: "2d"},
+			{Name: "nightly", Cron: "0 0 22 * * *", Duration: "4h"},
+		},
+	})
+	checkProbes(t, s, []probe{
+		{at: at(3, 1, 0, 0), active: true, name: "month-start", until: at(3, 3, 0, 0)},
+		{at: at(3, 2, 12, 0), active: true, name: "month-start", until: at(3, 3, 0, 0)},
+		// 3 月 3 日 0 点至 2 点仍在前一天 22 点开始的 nightly 窗口内
+		{at: at(3, 3, 0, 0), active: true, name: "nightly", until: at(3, 3, 2, 0)},
+		{at: at(3, 3, 2, 0), active: false},
+		{at: at(2, 28, 21, 59), active: false},
+		// 跨午夜：前一天 22 点触发，持续到次日 2 点
+		{at: at(3, 5, 23, 30), active: true, name: "nightly", until: at(3, 6, 2, 0)},
+		{at: at(3, 6, 1, 59), active: true, name: "nightly", until: at(3, 6, 2, 0)},
+		{at: at(3, 6, 2, 0), active: false},
+	})
+}
+
+func TestWeekdayRangeCrossingMidnight(t *testing.T) {
+	loc := mustLoad(t, "Asia/Shanghai")
+	// 2025-03-07 为周五
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2025, time.March, day, hour, min, 0, 0, loc)
+	}
+	s := mustSchedule(t, settings.Blackout{
+		Timezone: "Asia/Shanghai",
+		Windows: []settings.BlackoutWindow{
+			{Name: "fri-night", Weekdays: []string{"Friday"}, Start: "22:00", End: "02:00"},
+		},
+	})
+	checkProbes(t, s, []probe{
+		{at: at(7, 21, 59), active: false},
+		{at: at(7, 22, 0), active: true, name: "fri-night", until: at(8, 2, 0)},
+		// 周六凌晨属于周五开始的窗口
+		{at: at(8, 1, 59), active: true, name: "fri-night", until: at(8, 2, 0)},
+		{at: at(8, 2, 0), active: false},
+		// 周六晚上与周四晚上不在窗口内
+		{at: at(8, 23, 0), active: false},
+		{at: at(6, 23, 0), active: false},
+		{at: at(7, 1, 0), active: false},
+	})
+}
+
+func TestTimezoneConversion(t *testing.T) {
+	shanghai := mustLoad(t, "Asia/Shanghai")
+	s := mustSchedule(t, settings.Blackout{
+		Timezone: "Asia/Shanghai",
+		Windows:  []settings.BlackoutWindow{{Name: "morning", Start: "09:00", End: "11:00"}},
+	})
+	utc := func(day, hour, min int) time.Time {
+		return time.Date(2025, time.March, day, hour, min, 0, 0, time.UTC)
+	}
+	checkProbes(t, s, []probe{
+		// UTC 01:30 即上海 09:30
+		{at: utc(3, 1, 30), active: true, name: "morning", until: time.Date(2025, time.March, 3, 11, 0, 0, 0, shanghai)},
+		{at: utc(3, 9, 30), active: false},
+		// UTC 前一天 23:30 即上海当天 07:30
+		{at: utc(2, 23, 30), active: false},
+	})
+	if a, _ := s.ActiveAt(utc(3, 1, 30)); a.Until.Location().String() != shanghai.String() {
+		t.Fatalf("窗口结束时间应按配置时区表示，实际时区 %s", a.Until.Location())
+	}
+
+	// 夏令时切换前后窗口都按当地墙上时间生效
+	ny := mustLoad(t, "America/New_York")
+	s = mustSchedule(t, settings.Blackout{
+		Timezone: "America/New_York",
+		Windows:  []settings.BlackoutWindow{{Name: "standup", Weekdays: []string{"mon"}, Start: "09:00", End: "10:00"}},
+	})
+	checkProbes(t, s, []probe{
+		{at: utc(3, 14, 30), active: true, name: "standup", until: time.Date(2025, time.March, 3, 10, 0, 0, 0, ny)},
+		{at: utc(10, 13, 30), active: true, name: "standup", until: time.Date(2025, time.March, 10, 10, 0, 0, 0, ny)},
+		{at: utc(10, 14, 30), active: false},
+	})
+}
+
+func TestOverlappingWindows(t *testing.T) {
+	loc := mustLoad(t, "Asia/Shanghai")
+	at := func(hour, min int) time.Time {
+		return time.Date(2025, time.March, 5, hour, min, 0, 0, loc)
+	}
+	s := mustSchedule(t, settings.Blackout{
+		Timezone: "Asia/Shanghai",
+		Windows: []settings.BlackoutWindow{
+			{Name: "morning", Start: "09:00", End: "11:00"},
+			{Name: "report", Cron: "0 0 10 * * *", Duration: "3h"},
+			{Start: "10:15", End: "10:45"},
+		},
+	})
+	checkProbes(t, s, []probe{
+		{at: at(9, 30), active: true, name: "morning", until: at(11, 0)},
+		// 多个窗口同时生效时取结束最晚的一个
+		{at: at(10, 30), active: true, name: "report", until: at(13, 0)},
+		{at: at(12, 0), active: true, name: "report", until: at(13, 0)},
+		{at: at(13, 0), active: false},
+	})
+
+	// 未命名窗口以序号命名
+	only := mustSchedule(t, settings.Blackout{
+		Timezone: "Asia/Shanghai",
+		Windows:  []settings.BlackoutWindow{{Start: "10:15", End: "10:45"}},
+	})
+	checkProbes(t, only, []probe{{at: at(10, 30), active: true, name: "#0", until: at(10, 45)}})
+}
+
+func TestCheckWithClock(t *testing.T) {
+	loc := mustLoad(t, "Asia/Shanghai")
+	s := mustSchedule(t, settings.Blackout{
+		Timezone: "Asia/Shanghai",
+		Windows:  []settings.BlackoutWindow{{Name: "morning", Start: "09:00", End: "11:00"}},
+	})
+	inside := s.WithClock(func() time.Time { return time.Date(2025, time.March, 5, 10, 0, 0, 0, loc) })
+	if err := inside.Check(); !errors.Is(err, ErrActive) {
+		t.Fatalf("窗口内 Check 应返回 ErrActive，实际: %v", err)
+	}
+	outside := s.WithClock(func() time.Time { return time.Date(2025, time.March, 5, 12, 0, 0, 0, loc) })
+	if err := outside.Check(); err != nil {
+		t.Fatalf("窗口外 Check 不应出错: %v", err)
+	}
+	if err := mustSchedule(t, settings.Blackout{}).Check(); err != nil {
+		t.Fatalf("未配置窗口时 Check 不应出错: %v", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		b    settings.Blackout
+	}{
+		{"未知时区", settings.Blackout{Timezone: "Mars/Base"}},
+		{"cron 与时段同时配置", settings.Blackout{Windows: []settings.BlackoutWindow{{Cron: "0 0 0 * * *", Duration: "1h", Start: "09:00", End: "10:00"}}}},
+		{"cron 非法", settings.Blackout{Windows: []settings.BlackoutWindow{{Cron: "every day", Duration: "1h"}}}},
+		{"缺少 duration", settings.Blackout{Windows: []settings.BlackoutWindow{{Cron: "0 0 0 * * *"}}}},
+		{"时间格式非法", settings.Blackout{Windows: []settings.BlackoutWindow{{Start: "9点", End: "10:00"}}}},
+		{"未知星期", settings.Blackout{Windows: []settings.BlackoutWindow{{Weekdays: []string{"fry"}, Start: "09:00", End: "10:00"}}}},
+		{"星期全称拼写错误", settings.Blackout{Windows: []settings.BlackoutWindow{{Weekdays: []string{"mondays"}, Start: "09:00", End: "10:00"}}}},
+		{"空窗口", settings.Blackout{Windows: []settings.BlackoutWindow{{Name: "empty"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.b); err == nil {
+				t.Fatal("期望解析失败")
+			}
+		})
+	}
+}
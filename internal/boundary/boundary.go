@@ -0,0 +1,337 @@
+// Package boundary 计算 auto-update 写入视图的时间边界：CK 分支取 ts < 边界，SR 分支取 ts >= 边界。
+// 多层视图中数据层与下一层之间的边界按同样的方式计算：较早的一侧对应 CK，较晚的一侧对应 SR
+package boundary
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"cksr/builder"
+	"cksr/internal/common"
+	"cksr/internal/interval"
+	"cksr/internal/settings"
+	"cksr/logger"
+
+	"example.com/migrationLib/retry"
+)
+
+// 边界策略（view_updater.boundary.strategy）
+const (
+	StrategySRMin            = "sr_min"            // SR 后缀表的最小时间戳（默认）
+	StrategyRetention        = "retention"         // 当前时间减去保留时长
+	StrategyPartitionAligned = "partition_aligned" // SR 最小时间戳按分区粒度向下取整
+	StrategyCKMax            = "ck_max"            // CK 表最大时间戳的下一个单位
+	StrategyFixedLag         = "fixed_lag"         // 视图当前边界加上固定间隔，不超过 SR 最小时间戳与当前时间
+)
+
+// SR 后缀表为空时的处理（view_updater.boundary.empty_sr）
+const (
+	EmptyRouteToCK = "route_to_ck" // 边界置为哨兵最大值，全部数据走 CK（默认）
+	EmptyKeep      = "keep"        // 保持视图当前边界不变
+	EmptyFail      = "fail"        // 报错，本视图本轮不更新
+)
+
+// 分区粒度（view_updater.boundary.granularity）
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityMonth = "month"
+)
+
+// bigint 时间戳单位（view_updater.boundary.bigint_unit）
+const (
+	UnitSecond      = "s"
+	UnitMillisecond = "ms"
+)
+
+// Source 计算单个视图边界所需的上下文
+type Source struct {
+	SRDB       *sql.DB
+	CKDB       *sql.DB
+	Retry      retry.Config
+	SRCatalog  string // 非空时较晚一侧为 SR 中该 Catalog 下的表（数据层边界），为空时为 SR 内表
+	SRDatabase string
+	SRTable    string // SR 后缀表
+	SRView     string // SR 基础名视图，fixed_lag 读取其当前边界
+	CKCatalog  string // 非空时较早一侧经 SR 中该 Catalog 查询（数据层边界），为空时直接查询 CK
+	CKDatabase string
+	CKTable    string
+	Column     string // 时间戳列
+	Type       string // date、datetime、bigint
+	// FromEnd 该边界在视图中从后往前的序号：0 为 CK 与 SR 之间的边界，1 为其前一个，依此类推；fixed_lag 据此读取当前边界
+	FromEnd int
+	Now     time.Time
+	// Ctx 查询所用的 ctx（如单视图超时），为空时不限时
+	Ctx context.Context
+}
+
+// ctx 返回查询所用的 ctx
+func (src Source) ctx() context.Context {
+	if src.Ctx == nil {
+		return context.Background()
+	}
+	return src.Ctx
+}
+
+// Decision 边界计算结果
+type Decision struct {
+	Value  string // 新边界，已按列类型格式化（日期时间带单引号）；Keep 为 true 时为空
+	Keep   bool   // 保持视图当前边界，不执行 ALTER VIEW
+	Reason string // 便于日志审计的决策说明
+}
+
+// Normalize 填充默认值并统一大小写
+func Normalize(b settings.Boundary) settings.Boundary {
+	b.Strategy = strings.ToLower(strings.TrimSpace(b.Strategy))
+	if b.Strategy == "" {
+		b.Strategy = StrategySRMin
+	}
+	b.EmptySR = strings.ToLower(strings.TrimSpace(b.EmptySR))
+	if b.EmptySR == "" {
+		b.EmptySR = EmptyRouteToCK
+	}
+	b.BigintUnit = strings.ToLower(strings.TrimSpace(b.BigintUnit))
+	if b.BigintUnit == "" {
+		b.BigintUnit = UnitSecond
+	}
+	b.Granularity = strings.ToLower(strings.TrimSpace(b.Granularity))
+	return b
+}
+
+// Validate 校验策略及其参数是否完整合法
+func Validate(b settings.Boundary) error {
+	b = Normalize(b)
+	switch b.Strategy {
+	case StrategySRMin, StrategyCKMax:
+	case StrategyRetention:
+		if _, err := interval.Parse(b.Retention); err != nil {
+			return fmt.Errorf("retention 策略的 retention 非法: %w", err)
+		}
+	case StrategyPartitionAligned:
+		switch b.Granularity {
+		case GranularityHour, GranularityDay, GranularityMonth:
+		default:
+			return fmt.Errorf("partition_aligned 策略的 granularity %q 非法，仅支持 %s、%s、%s",
+				b.Granularity, GranularityHour, GranularityDay, GranularityMonth)
+		}
+	case StrategyFixedLag:
+		if _, err := interval.Parse(b.Interval); err != nil {
+			return fmt.Errorf("fixed_lag 策略的 interval 非法: %w", err)
+		}
+	default:
+		return fmt.Errorf("未知的边界策略 %q，仅支持 %s、%s、%s、%s、%s", b.Strategy,
+			StrategySRMin, StrategyRetention, StrategyPartitionAligned, StrategyCKMax, StrategyFixedLag)
+	}
+	switch b.EmptySR {
+	case EmptyRouteToCK, EmptyKeep, EmptyFail:
+	default:
+		return fmt.Errorf("未知的 empty_sr %q，仅支持 %s、%s、%s", b.EmptySR, EmptyRouteToCK, EmptyKeep, EmptyFail)
+	}
+	switch b.BigintUnit {
+	case UnitSecond, UnitMillisecond:
+	default:
+		return fmt.Errorf("未知的 bigint_unit %q，仅支持 %s、%s", b.BigintUnit, UnitSecond, UnitMillisecond)
+	}
+	return nil
+}
+
+// Compute 按策略计算视图的新边界；SR 后缀表为空时先按 empty_sr 处理
+func Compute(b settings.Boundary, src Source) (Decision, error) {
+	b = Normalize(b)
+	if err := Validate(b); err != nil {
+		return Decision{}, err
+	}
+	if src.Now.IsZero() {
+		src.Now = time.Now()
+	}
+	typ := strings.ToLower(src.Type)
+
+	srMin, ok, err := queryMin(src)
+	if err != nil {
+		return Decision{}, err
+	}
+	if !ok {
+		return emptyDecision(b, typ)
+	}
+
+	switch b.Strategy {
+	case StrategySRMin:
+		return Decision{Value: srMin, Reason: "SR 最小时间戳"}, nil
+
+	case StrategyRetention:
+		d, _ := interval.Parse(b.Retention)
+		v, err := formatTime(src.Now.Add(-d), typ, b.BigintUnit)
+		if err != nil {
+			return Decision{}, err
+		}
+		return Decision{Value: v, Reason: fmt.Sprintf("当前时间减去保留时长 %s", b.Retention)}, nil
+
+	case StrategyPartitionAligned:
+		t, err := parseTime(srMin, typ, b.BigintUnit)
+		if err != nil {
+			return Decision{}, err
+		}
+		v, err := formatTime(truncate(t, b.Granularity), typ, b.BigintUnit)
+		if err != nil {
+			return Decision{}, err
+		}
+		return Decision{Value: v, Reason: fmt.Sprintf("SR 最小时间戳 %s 按 %s 向下取整", srMin, b.Granularity)}, nil
+
+	case StrategyCKMax:
+		v, ok, err := queryCKMax(src)
+		if err != nil {
+			return Decision{}, err
+		}
+		if !ok {
+			// CK 无数据时全部走 SR 也不会丢数据，但无法确定边界，回退到 SR 最小值
+			logger.Warn("CK 表 %s.%s 无数据，ck_max 策略回退为 SR 最小时间戳", src.CKDatabase, src.CKTable)
+			return Decision{Value: srMin, Reason: "CK 无数据，回退为 SR 最小时间戳"}, nil
+		}
+		// CK 分支取 ts < 边界，边界取 CK 最大值的下一个单位，保证 CK 中等于最大值的行仍走 CK；
+		// SR 中不晚于 CK 最大值的行视为已在 CK 中，不再出现在视图里
+		next, err := successor(v, typ, b.BigintUnit)
+		if err != nil {
+			return Decision{}, fmt.Errorf("计算 CK 最大时间戳 %s 的下一个单位失败: %w", v, err)
+		}
+		return Decision{Value: next, Reason: fmt.Sprintf("CK 最大时间戳 %s 的下一个单位", v)}, nil
+
+	case StrategyFixedLag:
+		prev, ok, err := previousBoundary(src)
+		if err != nil {
+			return Decision{}, err
+		}
+		if !ok {
+			logger.Warn("视图 %s 没有可推进的当前边界，fixed_lag 策略回退为 SR 最小时间戳", src.SRView)
+			return Decision{Value: srMin, Reason: "无当前边界，回退为 SR 最小时间戳"}, nil
+		}
+		t, err := parseTime(prev, typ, b.BigintUnit)
+		if err != nil {
+			return Decision{}, fmt.Errorf("解析视图 %s 当前边界失败: %w", src.SRView, err)
+		}
+		d, _ := interval.Parse(b.Interval)
+		next := t.Add(d)
+		// 不超过 SR 最小时间戳与当前时间：写入停滞时边界越过 SR 最小值，SR 独有的行会从视图中消失
+		limit, err := parseTime(srMin, typ, b.BigintUnit)
+		if err != nil {
+			return Decision{}, fmt.Errorf("解析 SR 最小时间戳失败: %w", err)
+		}
+		if src.Now.Before(limit) {
+			limit = src.Now
+		}
+		if !next.After(limit) {
+			v, err := formatTime(next, typ, b.BigintUnit)
+			if err != nil {
+				return Decision{}, err
+			}
+			return Decision{Value: v, Reason: fmt.Sprintf("当前边界 %s 加上间隔 %s", prev, b.Interval)}, nil
+		}
+		if !limit.After(t) {
+			logger.Warn("视图 %s 当前边界 %s 已不早于 SR 最小时间戳 %s 或当前时间，fixed_lag 策略不再推进", src.SRView, prev, srMin)
+			return Decision{Keep: true, Reason: "已到达 SR 最小时间戳或当前时间，保持当前边界"}, nil
+		}
+		v, err := formatTime(limit, typ, b.BigintUnit)
+		if err != nil {
+			return Decision{}, err
+		}
+		logger.Warn("视图 %s 的 fixed_lag 边界 %s 加上间隔 %s 将越过 SR 最小时间戳 %s 或当前时间，截断为 %s", src.SRView, prev, b.Interval, srMin, v)
+		return Decision{Value: v, Reason: fmt.Sprintf("当前边界 %s 加上间隔 %s，截断到 SR 最小时间戳与当前时间中较早者", prev, b.Interval)}, nil
+	}
+	return Decision{}, fmt.Errorf("未知的边界策略: %s", b.Strategy)
+}
+
+// emptyDecision 按 empty_sr 处理 SR 后缀表为空的情况
+func emptyDecision(b settings.Boundary, typ string) (Decision, error) {
+	switch b.EmptySR {
+	case EmptyKeep:
+		return Decision{Keep: true, Reason: "SR 表为空，按 empty_sr=keep 保持当前边界"}, nil
+	case EmptyFail:
+		return Decision{}, fmt.Errorf("SR 表为空（empty_sr=fail）")
+	default:
+		v, err := builder.MaxTimestampValue(typ)
+		if err != nil {
+			return Decision{}, err
+		}
+		return Decision{Value: v, Reason: "SR 表为空，全部数据走 CK"}, nil
+	}
+}
+
+// queryMin 查询 SR 后缀表（数据层边界为较晚一侧的表）的最小时间戳，表为空时 ok 为 false
+func queryMin(src Source) (string, bool, error) {
+	ref := fmt.Sprintf("`%s`.`%s`", src.SRDatabase, src.SRTable)
+	if src.SRCatalog != "" {
+		ref = fmt.Sprintf("`%s`.%s", src.SRCatalog, ref)
+	}
+	q := fmt.Sprintf("select min(`%s`) from %s", src.Column, ref)
+	return queryValue(src.ctx(), src.SRDB, src.Retry, q, src.Type)
+}
+
+// queryCKMax 直接查询 CK 表的最大时间戳；maxOrNull 保证空表返回 NULL 而不是类型默认值。
+// 配置了 CKCatalog 时经 SR 查询该 Catalog 下的表
+func queryCKMax(src Source) (string, bool, error) {
+	if src.CKCatalog != "" {
+		q := fmt.Sprintf("select max(`%s`) from `%s`.`%s`.`%s`", src.Column, src.CKCatalog, src.CKDatabase, src.CKTable)
+		return queryValue(src.ctx(), src.SRDB, src.Retry, q, src.Type)
+	}
+	expr := fmt.Sprintf("toString(maxOrNull(`%s`))", src.Column)
+	if strings.ToLower(src.Type) == "bigint" {
+		expr = fmt.Sprintf("toInt64(maxOrNull(`%s`))", src.Column)
+	}
+	q := fmt.Sprintf("SELECT %s FROM `%s`.`%s`", expr, src.CKDatabase, src.CKTable)
+	return queryValue(src.ctx(), src.CKDB, src.Retry, q, src.Type)
+}
+
+// queryValue 在 ctx 下执行单值查询并按列类型格式化；结果为 NULL 或空串时 ok 为 false
+func queryValue(ctx context.Context, db *sql.DB, retryConfig retry.Config, q, typ string) (string, bool, error) {
+	switch strings.ToLower(typ) {
+	case "datetime", "date":
+		var nullable *string
+		if err := common.QueryRowContextWithRetry(ctx, db, retryConfig, q, []interface{}{&nullable}); err != nil {
+			if err == sql.ErrNoRows {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("查询时间戳失败: %w", err)
+		}
+		if nullable == nil || strings.TrimSpace(*nullable) == "" {
+			return "", false, nil
+		}
+		if strings.HasPrefix(*nullable, "'") {
+			return *nullable, true, nil
+		}
+		return "'" + *nullable + "'", true, nil
+	case "bigint":
+		var nullable *int64
+		if err := common.QueryRowContextWithRetry(ctx, db, retryConfig, q, []interface{}{&nullable}); err != nil {
+			if err == sql.ErrNoRows {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("查询时间戳失败: %w", err)
+		}
+		if nullable == nil {
+			return "", false, nil
+		}
+		return fmt.Sprintf("%d", *nullable), true, nil
+	default:
+		return "", false, fmt.Errorf("不支持的时间戳类型: %s", typ)
+	}
+}
+
+// previousBoundary 读取视图中 FromEnd 对应的当前边界；视图不存在、无法解析或为哨兵最大值时 ok 为false
+func previousBoundary(src Source) (string, bool, error) {
+	def, ok, err := common.ViewDefinition(src.ctx(), src.SRDB, src.Retry, src.SRDatabase, src.SRView)
+	if err != nil || !ok {
+		return "", false, err
+	}
+	bounds := builder.ParseViewBoundaries(def)
+	idx := len(bounds) - 1 - src.FromEnd
+	if idx < 0 {
+		return "", false, nil
+	}
+	b := bounds[idx]
+	if sentinel, err := builder.MaxTimestampValue(src.Type); err == nil && b.Value == sentinel {
+		return "", false, nil
+	}
+	return b.Value, true, nil
+}
@@ -0,0 +1,329 @@
+package boundary
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cksr/internal/settings"
+)
+
+// fakeConnector 按查询中包含的关键字返回单值结果；未命中时返回空结果集
+type fakeConnector map[string]driver.Value
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn(c), nil }
+func (c fakeConnector) Driver() driver.Driver                        { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, errors.New("not supported") }
+
+type fakeConn map[string]driver.Value
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("not supported") }
+
+func (c fakeConn) QueryContext(_ context.Context, q string, _ []driver.NamedValue) (driver.Rows, error) {
+	for key, v := range c {
+		if strings.Contains(q, key) {
+			return &fakeRows{values: []driver.Value{v}}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct{ values []driver.Value }
+
+func (r *fakeRows) Columns() []string { return []string{"v"} }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+func fakeDB(t *testing.T, responses map[string]driver.Value) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(fakeConnector(responses))
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func mustLocal(t *testing.T, s string) time.Time {
+	t.Helper()
+	v, err := time.ParseInLocation("2006-01-02 15:04:05", s, time.Local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+func TestCompute(t *testing.T) {
+	now := mustLocal(t, "2025-06-10 12:00:00")
+	view := func(b string) string {
+		return "select a from `c`.`db`.`t` where `ts` < " + b + " union all select a from `db`.`t_local` where `ts` >= " + b
+	}
+	tests := []struct {
+		name     string
+		boundary settings.Boundary
+		typ      string
+		sr       map[string]driver.Value
+		ck       map[string]driver.Value
+		want     string
+		keep     bool
+	}{
+		{
+			name: "sr_min 默认策略",
+			typ:  "datetime",
+			sr:   map[string]driver.Value{"min(": "2025-06-01 08:30:00"},
+			want: "'2025-06-01 08:30:00'",
+		},
+		{
+			name:     "SR 为空时 route_to_ck 取哨兵值",
+			typ:      "datetime",
+			want:     "'9999-12-31 23:59:59'",
+			boundary: settings.Boundary{EmptySR: EmptyRouteToCK},
+		},
+		{
+			name:     "SR 为空时 keep 保持边界",
+			typ:      "bigint",
+			boundary: settings.Boundary{EmptySR: EmptyKeep},
+			keep:     true,
+		},
+		{
+			name:     "retention 为当前时间减去保留时长",
+			typ:      "datetime",
+			boundary: settings.Boundary{Strategy: StrategyRetention, Retention: "1d12h"},
+			sr:       map[string]driver.Value{"min(": "2025-06-01 00:00:00"},
+			want:     "'2025-06-09 00:00:00'",
+		},
+		{
+			name:     "partition_aligned 按天向下取整",
+			typ:      "datetime",
+			boundary: settings.Boundary{Strategy: StrategyPartitionAligned, Granularity: GranularityDay},
+			sr:       map[string]driver.Value{"min(": "2025-06-01 08:30:00"},
+			want:     "'2025-06-01 00:00:00'",
+		},
+		{
+			name:     "partition_aligned 按月处理 bigint 毫秒",
+			typ:      "bigint",
+			boundary: settings.Boundary{Strategy: StrategyPartitionAligned, Granularity: GranularityMonth, BigintUnit: UnitMillisecond},
+			sr:       map[string]driver.Value{"min(": mustLocal(t, "2025-06-15 10:00:00").UnixMilli()},
+			want:     itoa(mustLocal(t, "2025-06-01 00:00:00").UnixMilli()),
+		},
+		{
+			name:     "ck_max 取 CK 最大值的下一秒",
+			typ:      "datetime",
+			boundary: settings.Boundary{Strategy: StrategyCKMax},
+			sr:       map[string]driver.Value{"min(": "2025-06-01 00:00:00"},
+			ck:       map[string]driver.Value{"maxOrNull": "2025-06-01 23:59:59"},
+			want:     "'2025-06-02 00:00:00'",
+		},
+		{
+			name:     "ck_max 处理 bigint",
+			typ:      "bigint",
+			boundary: settings.Boundary{Strategy: StrategyCKMax},
+			sr:       map[string]driver.Value{"min(": int64(100)},
+			ck:       map[string]driver.Value{"maxOrNull": int64(1717200000)},
+			want:     "1717200001",
+		},
+		{
+			name:     "ck_max 的 date 类型加一天",
+			typ:      "date",
+			boundary: settings.Boundary{Strategy: StrategyCKMax},
+			sr:       map[string]driver.Value{"min(": "2025-06-01"},
+			ck:       map[string]driver.Value{"maxOrNull": "2025-06-03"},
+			want:     "'2025-06-04'",
+		},
+		{
+			name:     "ck_max 在 CK 为空时回退为 sr_min",
+			typ:      "datetime",
+			boundary: settings.Boundary{Strategy: StrategyCKMax},
+			sr:       map[string]driver.Value{"min(": "2025-06-01 00:00:00"},
+			want:     "'2025-06-01 00:00:00'",
+		},
+		{
+			name:     "fixed_lag 正常推进",
+			typ:      "datetime",
+			boundary: settings.Boundary{Strategy: StrategyFixedLag, Interval: "1d"},
+			sr: map[string]driver.Value{
+				"min(":            "2025-06-05 00:00:00",
+				"VIEW_DEFINITION": view("'2025-06-01 00:00:00'"),
+			},
+			want: "'2025-06-02 00:00:00'",
+		},
+		{
+			name:     "fixed_lag 截断到 SR 最小时间戳",
+			typ:      "datetime",
+			boundary: settings.Boundary{Strategy: StrategyFixedLag, Interval: "3d"},
+			sr: map[string]driver.Value{
+				"min(":            "2025-06-02 06:00:00",
+				"VIEW_DEFINITION": view("'2025-06-01 00:00:00'"),
+			},
+			want: "'2025-06-02 06:00:00'",
+		},
+		{
+			name:     "fixed_lag 截断到当前时间",
+			typ:      "datetime",
+			boundary: settings.Boundary{Strategy: StrategyFixedLag, Interval: "7d"},
+			sr: map[string]driver.Value{
+				"min(":            "2025-07-01 00:00:00",
+				"VIEW_DEFINITION": view("'2025-06-08 00:00:00'"),
+			},
+			want: "'2025-06-10 12:00:00'",
+		},
+		{
+			name:     "fixed_lag 已到达上限时保持边界",
+			typ:      "datetime",
+			boundary: settings.Boundary{Strategy: StrategyFixedLag, Interval: "1d"},
+			sr: map[string]driver.Value{
+				"min(":            "2025-06-01 00:00:00",
+				"VIEW_DEFINITION": view("'2025-06-03 00:00:00'"),
+			},
+			keep: true,
+		},
+		{
+			name:     "fixed_lag 无当前边界时回退为 sr_min",
+			typ:      "datetime",
+			boundary: settings.Boundary{Strategy: StrategyFixedLag, Interval: "1d"},
+			sr:       map[string]driver.Value{"min(": "2025-06-05 00:00:00"},
+			want:     "'2025-06-05 00:00:00'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compute(tt.boundary, Source{
+				SRDB:       fakeDB(t, tt.sr),
+				CKDB:       fakeDB(t, tt.ck),
+				SRDatabase: "db",
+				SRTable:    "t_local",
+				SRView:     "t",
+				CKDatabase: "db",
+				CKTable:    "t",
+				Column:     "ts",
+				Type:       tt.typ,
+				Now:        now,
+			})
+			if err != nil {
+				t.Fatalf("Compute 返回错误: %v", err)
+			}
+			if got.Keep != tt.keep {
+				t.Fatalf("Keep = %v，期望 %v（%s）", got.Keep, tt.keep, got.Reason)
+			}
+			if got.Value != tt.want {
+				t.Fatalf("边界 = %q，期望 %q（%s）", got.Value, tt.want, got.Reason)
+			}
+		})
+	}
+}
+
+func TestComputeEmptyFail(t *testing.T) {
+	_, err := Compute(settings.Boundary{EmptySR: EmptyFail}, Source{SRDB: fakeDB(t, nil), Type: "bigint"})
+	if err == nil {
+		t.Fatal("empty_sr=fail 时应返回错误")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		b       settings.Boundary
+		wantErr bool
+	}{
+		{"空配置使用默认值", settings.Boundary{}, false},
+		{"大小写与空白", settings.Boundary{Strategy: " SR_MIN ", EmptySR: "Keep"}, false},
+		{"retention 缺少时长", settings.Boundary{Strategy: StrategyRetention}, true},
+		{"retention 合法", settings.Boundary{Strategy: StrategyRetention, Retention: "7d"}, false},
+		{"partition_aligned 缺少粒度", settings.Boundary{Strategy: StrategyPartitionAligned}, true},
+		{"partition_aligned 合法", settings.Boundary{Strategy: StrategyPartitionAligned, Granularity: "hour"}, false},
+		{"fixed_lag 非法间隔", settings.Boundary{Strategy: StrategyFixedLag, Interval: "abc"}, true},
+		{"未知策略", settings.Boundary{Strategy: "latest"}, true},
+		{"未知 empty_sr", settings.Boundary{EmptySR: "drop"}, true},
+		{"未知 bigint_unit", settings.Boundary{BigintUnit: "us"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.b); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate 错误 = %v，期望出错: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{"'2025-01-01 00:00:00'", "'2025-01-02 00:00:00'", -1, false},
+		{"'2025-01-02'", "'2025-01-01 23:59:59'", 1, false},
+		{"100", "100", 0, false},
+		{"101", "100", 1, false},
+		{"100", "'2025-01-01'", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := Compare(tt.a, tt.b)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("Compare(%s, %s) 错误 = %v，期望出错: %v", tt.a, tt.b, err, tt.wantErr)
+		}
+		if !tt.wantErr && sign(got) != tt.want {
+			t.Fatalf("Compare(%s, %s) = %d，期望 %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSuccessor(t *testing.T) {
+	tests := []struct {
+		value, typ, unit, want string
+	}{
+		{"'2025-06-01 23:59:59'", "datetime", UnitSecond, "'2025-06-02 00:00:00'"},
+		{"'2025-06-01 10:00:00.500'", "datetime", UnitSecond, "'2025-06-01 10:00:01'"},
+		{"'2025-12-31'", "date", UnitSecond, "'2026-01-01'"},
+		{"1717200000", "bigint", UnitSecond, "1717200001"},
+		{"1717200000000", "bigint", UnitMillisecond, "1717200000001"},
+	}
+	for _, tt := range tests {
+		got, err := successor(tt.value, tt.typ, tt.unit)
+		if err != nil {
+			t.Fatalf("successor(%s) 返回错误: %v", tt.value, err)
+		}
+		if got != tt.want {
+			t.Fatalf("successor(%s) = %s，期望 %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIsSentinel(t *testing.T) {
+	for _, v := range []string{"'9999-12-31'", "'9999-12-31 23:59:59'", "9999999999999"} {
+		if !IsSentinel(v) {
+			t.Fatalf("%s 应为哨兵值", v)
+		}
+	}
+	if IsSentinel("'2025-01-01'") {
+		t.Fatal("普通日期不应为哨兵值")
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
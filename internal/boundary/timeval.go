@@ -0,0 +1,136 @@
+package boundary
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// datetimeLayouts SR/CK 返回的日期时间可能带小数秒或 ISO 格式
+var datetimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999",
+	time.RFC3339,
+	"2006-01-02",
+}
+
+// parseTime 将边界值（日期时间带单引号，bigint 为数字）解析为本地时间
+func parseTime(value, typ, unit string) (time.Time, error) {
+	v := strings.Trim(strings.TrimSpace(value), "'")
+	switch typ {
+	case "bigint":
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bigint 时间戳 %q 非法: %w", value, err)
+		}
+		if unit == UnitMillisecond {
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(n, 0), nil
+	case "date", "datetime":
+		for _, layout := range datetimeLayouts {
+			if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("无法解析时间 %q", value)
+	default:
+		return time.Time{}, fmt.Errorf("不支持的时间戳类型: %s", typ)
+	}
+}
+
+// formatTime 按列类型格式化边界值；date 类型向下取整到天
+func formatTime(t time.Time, typ, unit string) (string, error) {
+	t = t.In(time.Local)
+	switch typ {
+	case "bigint":
+		if unit == UnitMillisecond {
+			return strconv.FormatInt(t.UnixMilli(), 10), nil
+		}
+		return strconv.FormatInt(t.Unix(), 10), nil
+	case "date":
+		return "'" + t.Format("2006-01-02") + "'", nil
+	case "datetime":
+		return "'" + t.Format("2006-01-02 15:04:05") + "'", nil
+	default:
+		return "", fmt.Errorf("不支持的时间戳类型: %s", typ)
+	}
+}
+
+// successor 返回比边界值晚一个最小单位的值：date 加一天，datetime 去掉小数部分后加一秒，bigint 按单位加一
+func successor(value, typ, unit string) (string, error) {
+	t, err := parseTime(value, typ, unit)
+	if err != nil {
+		return "", err
+	}
+	switch typ {
+	case "date":
+		t = t.AddDate(0, 0, 1)
+	case "datetime":
+		t = t.Truncate(time.Second).Add(time.Second)
+	case "bigint":
+		if unit == UnitMillisecond {
+			t = t.Add(time.Millisecond)
+		} else {
+			t = t.Add(time.Second)
+		}
+	}
+	return formatTime(t, typ, unit)
+}
+
+// truncate 按分区粒度向下取整（按本地时区的自然时/日/月）
+func truncate(t time.Time, granularity string) time.Time {
+	t = t.In(time.Local)
+	y, mo, d := t.Date()
+	switch granularity {
+	case GranularityHour:
+		return time.Date(y, mo, d, t.Hour(), 0, 0, 0, time.Local)
+	case GranularityDay:
+		return time.Date(y, mo, d, 0, 0, 0, 0, time.Local)
+	case GranularityMonth:
+		return time.Date(y, mo, 1, 0, 0, 0, 0, time.Local)
+	}
+	return t
+}
+
+// Compare 比较两个边界值的先后：日期时间（带单引号）按时间比较，bigint 按数值比较；
+// 两者格式不一致时返回错误
+func Compare(a, b string) (int, error) {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	aQuoted, bQuoted := strings.HasPrefix(a, "'"), strings.HasPrefix(b, "'")
+	if aQuoted != bQuoted {
+		return 0, fmt.Errorf("边界值 %s 与 %s 类型不一致", a, b)
+	}
+	typ := "bigint"
+	if aQuoted {
+		typ = "datetime"
+	}
+	ta, err := parseTime(a, typ, UnitSecond)
+	if err != nil {
+		return 0, err
+	}
+	tb, err := parseTime(b, typ, UnitSecond)
+	if err != nil {
+		return 0, err
+	}
+	return ta.Compare(tb), nil
+}
+
+// EpochSeconds 将边界值转换为 Unix 秒（bigint 按 unit 解释）；哨兵值或无法解析时 ok 为 false
+func EpochSeconds(value, typ, unit string) (float64, bool) {
+	if IsSentinel(value) {
+		return 0, false
+	}
+	t, err := parseTime(value, strings.ToLower(typ), unit)
+	if err != nil {
+		return 0, false
+	}
+	return float64(t.UnixMilli()) / 1000, true
+}
+
+// IsSentinel 判断边界值是否为哨兵最大值（SR 为空时全部走 CK）
+func IsSentinel(value string) bool {
+	v := strings.Trim(strings.TrimSpace(value), "'")
+	return strings.HasPrefix(v, "9999-12-31") || v == "9999999999999"
+}
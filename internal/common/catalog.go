@@ -0,0 +1,70 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"cksr/internal/settings"
+
+	mcfg "example.com/migrationLib/config"
+	mdb "example.com/migrationLib/database"
+	"example.com/migrationLib/retry"
+)
+
+// ckJDBCDriverClass ClickHouse JDBC 驱动类名（driver_url 指向的 clickhouse-jdbc 包）
+const ckJDBCDriverClass = "com.clickhouse.jdbc.ClickHouseDriver"
+
+// CreateCatalog 创建 StarRocks Catalog（已存在时不报错）；配置了 catalog_password_file 时以其内容作为 JDBC 密码，
+// 此时自行生成 CREATE EXTERNAL CATALOG IF NOT EXISTS 并在已有的 SR 连接上执行，不另建连接。
+// jdbc_uri 与 driver_url 取自与 migrationLib 相同的配置项（clickhouse.host/http_port 与 driver_url）
+func CreateCatalog(cfg *mcfg.Config, pairIndex int, dbManager *mdb.DatabasePairManager, catalogName string) error {
+	password := settings.Of(cfg).Pair(pairIndex).CatalogPassword
+	if password == "" {
+		return dbManager.CreateStarRocksCatalog(catalogName)
+	}
+	stmt, err := createCatalogSQL(cfg, pairIndex, catalogName, password)
+	if err != nil {
+		return err
+	}
+	srDB, err := dbManager.GetStarRocksConnection()
+	if err != nil {
+		return fmt.Errorf("获取StarRocks连接失败: %w", err)
+	}
+	retryConfig := retry.Config{MaxRetries: cfg.Retry.MaxRetries, Delay: time.Duration(cfg.Retry.DelayMs) * time.Millisecond}
+	if err := retry.ExecWithRetry(srDB, retryConfig, stmt); err != nil {
+		return fmt.Errorf("创建 Catalog %s 失败: %w", catalogName, err)
+	}
+	return nil
+}
+
+// createCatalogSQL 生成指向数据库对中 CK 的 JDBC Catalog 语句，password 为 Catalog 使用的 JDBC 密码；
+// 不为 http_port 与 driver_url 假设默认值，未配置时报错
+func createCatalogSQL(cfg *mcfg.Config, pairIndex int, catalogName, password string) (string, error) {
+	ck := cfg.DatabasePairs[pairIndex].ClickHouse
+	if ck.HTTPPort == 0 {
+		return "", fmt.Errorf("配置了 catalog_password_file 时需配置 clickhouse.http_port 作为 Catalog 的 JDBC 端口")
+	}
+	if cfg.DriverURL == "" {
+		return "", fmt.Errorf("配置了 catalog_password_file 时需配置 driver_url")
+	}
+	props := [][2]string{
+		{"type", "jdbc"},
+		{"user", ck.Username},
+		{"password", password},
+		{"jdbc_uri", fmt.Sprintf("jdbc:clickhouse://%s:%d", ck.Host, ck.HTTPPort)},
+		{"driver_url", cfg.DriverURL},
+		{"driver_class", ckJDBCDriverClass},
+	}
+	lines := make([]string, len(props))
+	for i, p := range props {
+		lines[i] = fmt.Sprintf("\t%s = %s", quoteProperty(p[0]), quoteProperty(p[1]))
+	}
+	return fmt.Sprintf("CREATE EXTERNAL CATALOG IF NOT EXISTS `%s`\nPROPERTIES (\n%s\n)", catalogName, strings.Join(lines, ",\n")), nil
+}
+
+// quoteProperty 以双引号包裹 PROPERTIES 中的键或值，转义反斜杠与双引号
+func quoteProperty(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
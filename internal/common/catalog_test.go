@@ -0,0 +1,51 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	mcfg "example.com/migrationLib/config"
+)
+
+func catalogTestConfig() *mcfg.Config {
+	return &mcfg.Config{
+		DriverURL: "file:///opt/clickhouse-jdbc.jar",
+		DatabasePairs: []mcfg.DatabasePair{{
+			ClickHouse: mcfg.ClickHouseConfig{Host: "ck-host", HTTPPort: 30076, Username: "reader", Password: "ck-pass"},
+		}},
+	}
+}
+
+func TestCreateCatalogSQL(t *testing.T) {
+	got, err := createCatalogSQL(catalogTestConfig(), 0, "ck_catalog", `p"a\ss`)
+	if err != nil {
+		t.Fatalf("生成 Catalog 语句失败: %v", err)
+	}
+	for _, want := range []string{
+		"CREATE EXTERNAL CATALOG IF NOT EXISTS `ck_catalog`",
+		`"user" = "reader"`,
+		`"password" = "p\"a\\ss"`,
+		`"jdbc_uri" = "jdbc:clickhouse://ck-host:30076"`,
+		`"driver_url" = "file:///opt/clickhouse-jdbc.jar"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("Catalog 语句缺少 %s:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "ck-pass") {
+		t.Fatalf("Catalog 语句不应使用 clickhouse.password:\n%s", got)
+	}
+}
+
+func TestCreateCatalogSQLRequiresSettings(t *testing.T) {
+	noPort := catalogTestConfig()
+	noPort.DatabasePairs[0].ClickHouse.HTTPPort = 0
+	if _, err := createCatalogSQL(noPort, 0, "ck_catalog", "x"); err == nil || !strings.Contains(err.Error(), "http_port") {
+		t.Fatalf("未配置 http_port 时应报错，实际: %v", err)
+	}
+	noDriver := catalogTestConfig()
+	noDriver.DriverURL = ""
+	if _, err := createCatalogSQL(noDriver, 0, "ck_catalog", "x"); err == nil || !strings.Contains(err.Error(), "driver_url") {
+		t.Fatalf("未配置 driver_url 时应报错，实际: %v", err)
+	}
+}
@@ -0,0 +1,60 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotRun 因前序任务失败（stopOnError）而未执行的任务
+var ErrNotRun = errors.New("因前序失败未执行")
+
+// ErrTimeout 任务超过单任务超时时间
+var ErrTimeout = errors.New("执行超时")
+
+// RunBounded 以最多 concurrency 个并发执行 n 个任务，返回按下标排列的错误（与执行完成顺序无关）。
+// timeout > 0 时每个任务的 ctx 带有该超时；fn 需在各步骤间检查 ctx 并以 ctx 执行SQL，返回超时错误时记为 ErrTimeout。
+// 并发名额在 fn 返回后才释放，因此同时执行的任务不超过 concurrency 个，返回时不会残留执行中的任务。
+// stopOnError 为 true 时任一任务失败后不再派发新任务，未派发的任务记为 ErrNotRun
+func RunBounded(n, concurrency int, timeout time.Duration, stopOnError bool, fn func(ctx context.Context, i int) error) []error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	results := make([]error, n)
+	sem := make(chan struct{}, concurrency)
+	var workers sync.WaitGroup
+	var stopped atomic.Bool
+
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		if stopped.Load() {
+			<-sem
+			results[i] = ErrNotRun
+			continue
+		}
+		workers.Add(1)
+		go func(i int) {
+			defer workers.Done()
+			defer func() { <-sem }()
+
+			ctx, cancel := context.Background(), context.CancelFunc(func() {})
+			if timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+			}
+			err := fn(ctx, i)
+			cancel()
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("%w（%s）: %v", ErrTimeout, timeout, err)
+			}
+			results[i] = err
+			if err != nil && stopOnError {
+				stopped.Store(true)
+			}
+		}(i)
+	}
+	workers.Wait()
+	return results
+}
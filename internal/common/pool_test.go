@@ -0,0 +1,89 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBoundedLimitsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	errs := RunBounded(8, 2, 0, false, func(_ context.Context, i int) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	})
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("任务 %d 返回错误: %v", i, err)
+		}
+	}
+	if p := peak.Load(); p > 2 {
+		t.Fatalf("并发峰值 %d 超过上限 2", p)
+	}
+}
+
+func TestRunBoundedTimeoutHoldsSlot(t *testing.T) {
+	var running, peak atomic.Int32
+	errs := RunBounded(3, 1, 20*time.Millisecond, false, func(ctx context.Context, i int) error {
+		n := running.Add(1)
+		if n > peak.Load() {
+			peak.Store(n)
+		}
+		defer running.Add(-1)
+		if i == 0 {
+			<-ctx.Done()
+			// 超时后仍在清理，名额不应被其他任务占用
+			time.Sleep(20 * time.Millisecond)
+			return ctx.Err()
+		}
+		return nil
+	})
+	if !errors.Is(errs[0], ErrTimeout) {
+		t.Fatalf("任务 0 应记为超时，实际: %v", errs[0])
+	}
+	if errs[1] != nil || errs[2] != nil {
+		t.Fatalf("其余任务不应失败: %v", errs)
+	}
+	if p := peak.Load(); p > 1 {
+		t.Fatalf("超时任务返回前名额被释放，并发峰值 %d", p)
+	}
+}
+
+func TestRunBoundedLateSuccessIsNotTimeout(t *testing.T) {
+	errs := RunBounded(1, 1, 10*time.Millisecond, false, func(ctx context.Context, _ int) error {
+		// 模拟已开始、不受超时影响的 ALTER VIEW
+		time.Sleep(30 * time.Millisecond)
+		return nil
+	})
+	if errs[0] != nil {
+		t.Fatalf("超时后完成的任务应按成功处理，实际: %v", errs[0])
+	}
+}
+
+func TestRunBoundedStopOnError(t *testing.T) {
+	boom := errors.New("boom")
+	errs := RunBounded(3, 1, 0, true, func(_ context.Context, i int) error {
+		if i == 0 {
+			return boom
+		}
+		return nil
+	})
+	if !errors.Is(errs[0], boom) {
+		t.Fatalf("任务 0 应返回原始错误，实际: %v", errs[0])
+	}
+	for _, err := range errs[1:] {
+		if !errors.Is(err, ErrNotRun) {
+			t.Fatalf("后续任务应记为 ErrNotRun，实际: %v", err)
+		}
+	}
+}
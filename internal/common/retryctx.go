@@ -0,0 +1,70 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"example.com/migrationLib/retry"
+)
+
+// ExecContextWithRetry 在 ctx 下执行 SQL，失败后按 retryConfig 重试；ctx 取消或超时后立即返回，不再重试
+func ExecContextWithRetry(ctx context.Context, db *sql.DB, retryConfig retry.Config, q string, args ...interface{}) error {
+	return withRetry(ctx, retryConfig, func() error {
+		_, err := db.ExecContext(ctx, q, args...)
+		return err
+	})
+}
+
+// QueryRowContextWithRetry 在 ctx 下执行单行查询并扫描到 dest，失败后按 retryConfig 重试；
+// sql.ErrNoRows 原样返回且不重试
+func QueryRowContextWithRetry(ctx context.Context, db *sql.DB, retryConfig retry.Config, q string, dest []interface{}, args ...interface{}) error {
+	return withRetry(ctx, retryConfig, func() error {
+		return db.QueryRowContext(ctx, q, args...).Scan(dest...)
+	})
+}
+
+// retryObserver 每次重试前调用，见 SetRetryObserver
+var retryObserver atomic.Pointer[func()]
+
+// SetRetryObserver 设置重试回调（如重试次数指标），ExecContextWithRetry 与 QueryRowContextWithRetry 每次重试前调用；
+// 传入 nil 时取消
+func SetRetryObserver(fn func()) {
+	if fn == nil {
+		retryObserver.Store(nil)
+		return
+	}
+	retryObserver.Store(&fn)
+}
+
+// withRetry 执行 fn，失败时间隔 retryConfig.Delay 最多重试 MaxRetries 次
+func withRetry(ctx context.Context, retryConfig retry.Config, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := fn()
+		if err == nil || errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("%w: %v", ctxErr, err)
+		}
+		if attempt >= retryConfig.MaxRetries {
+			return err
+		}
+		timer := time.NewTimer(retryConfig.Delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-timer.C:
+		}
+		if observe := retryObserver.Load(); observe != nil {
+			(*observe)()
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"example.com/migrationLib/retry"
+)
+
+func TestWithRetryObserver(t *testing.T) {
+	retries := 0
+	SetRetryObserver(func() { retries++ })
+	defer SetRetryObserver(nil)
+	cfg := retry.Config{MaxRetries: 3}
+	errFail := errors.New("连接被重置")
+
+	tests := []struct {
+		name        string
+		failures    int
+		failWith    error
+		wantErr     error
+		wantRetries int
+	}{
+		{"首次成功不重试", 0, errFail, nil, 0},
+		{"失败两次后成功", 2, errFail, nil, 2},
+		{"重试耗尽", 10, errFail, errFail, 3},
+		{"无结果不重试", 10, sql.ErrNoRows, sql.ErrNoRows, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retries = 0
+			calls := 0
+			err := withRetry(context.Background(), cfg, func() error {
+				calls++
+				if calls <= tt.failures {
+					return tt.failWith
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Fatalf("错误 = %v，期望 %v", err, tt.wantErr)
+			}
+			if retries != tt.wantRetries || calls != tt.wantRetries+1 {
+				t.Fatalf("重试回调 %d 次、执行 %d 次，期望重试 %d 次", retries, calls, tt.wantRetries)
+			}
+		})
+	}
+}
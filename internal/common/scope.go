@@ -0,0 +1,95 @@
+package common
+
+import (
+	"fmt"
+	"path"
+
+	"cksr/logger"
+)
+
+// Scope 命令行指定的处理范围，各项均支持 glob 通配符（*、?、[...]），为空表示不限制
+type Scope struct {
+	Pairs         []string // --pair：只处理匹配的数据库对
+	Tables        []string // --table：只处理匹配的表
+	ExcludeTables []string // --exclude-table：排除匹配的表，优先于 --table
+}
+
+// ReasonIgnored 因配置 ignore_tables 被过滤的原因
+const ReasonIgnored = "在配置的忽略列表中"
+
+// Filtered 被过滤掉的表及原因
+type Filtered struct {
+	Pair   string `json:"pair"`
+	Table  string `json:"table"`
+	Reason string `json:"reason"`
+}
+
+// Validate 校验所有 glob 模式是否合法
+func (s Scope) Validate() error {
+	for _, group := range []struct {
+		flag     string
+		patterns []string
+	}{
+		{"--pair", s.Pairs},
+		{"--table", s.Tables},
+		{"--exclude-table", s.ExcludeTables},
+	} {
+		for _, p := range group.patterns {
+			if p == "" {
+				return fmt.Errorf("%s 不能为空", group.flag)
+			}
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("%s 模式 %q 非法: %w", group.flag, p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// HasTableFilter 是否指定了表级过滤
+func (s Scope) HasTableFilter() bool {
+	return len(s.Tables) > 0 || len(s.ExcludeTables) > 0
+}
+
+// MatchPair 判断数据库对是否在处理范围内
+func (s Scope) MatchPair(name string) bool {
+	if len(s.Pairs) == 0 {
+		return true
+	}
+	_, ok := matchAny(s.Pairs, name)
+	return ok
+}
+
+// MatchTable 判断表是否在处理范围内，不在范围内时返回原因
+func (s Scope) MatchTable(name string) (bool, string) {
+	if p, ok := matchAny(s.ExcludeTables, name); ok {
+		return false, fmt.Sprintf("匹配 --exclude-table %s", p)
+	}
+	if len(s.Tables) > 0 {
+		if _, ok := matchAny(s.Tables, name); !ok {
+			return false, "未匹配任何 --table"
+		}
+	}
+	return true, ""
+}
+
+// matchAny 返回第一个匹配的模式；模式已在 Validate 中校验，此处忽略错误
+func matchAny(patterns []string, name string) (string, bool) {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// LogFiltered 输出被过滤的表及原因
+func LogFiltered(filtered []Filtered) {
+	if len(filtered) == 0 {
+		return
+	}
+	logger.Info("共过滤 %d 个表：", len(filtered))
+	for i, f := range filtered {
+		logger.Info("[%d] 库对: %s, 表: %s, 原因: %s", i+1, f.Pair, f.Table, f.Reason)
+	}
+}
@@ -0,0 +1,105 @@
+package common
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"example.com/migrationLib/retry"
+)
+
+// CatalogExists 通过 SHOW CATALOGS 判断 Catalog 是否存在
+func CatalogExists(srDB *sql.DB, retryConfig retry.Config, catalogName string) (bool, error) {
+	rows, err := retry.QueryWithRetry(srDB, retryConfig, "SHOW CATALOGS")
+	if err != nil {
+		return false, fmt.Errorf("查询Catalog列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return false, fmt.Errorf("读取Catalog列表列信息失败: %w", err)
+	}
+	exists := false
+	for rows.Next() {
+		// 不同版本返回列数不同，首列为 Catalog 名称
+		values := make([]sql.NullString, len(cols))
+		dest := make([]interface{}, len(cols))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return false, fmt.Errorf("扫描Catalog列表失败: %w", err)
+		}
+		if len(values) > 0 && values[0].String == catalogName {
+			exists = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, fmt.Errorf("遍历Catalog列表失败: %w", err)
+	}
+	return exists, nil
+}
+
+// ViewDefinitions 一次性获取库内所有视图定义（视图名 -> 定义）
+func ViewDefinitions(srDB *sql.DB, retryConfig retry.Config, database string) (map[string]string, error) {
+	q := "SELECT TABLE_NAME, VIEW_DEFINITION FROM information_schema.VIEWS WHERE TABLE_SCHEMA = ?"
+	rows, err := retry.QueryWithRetry(srDB, retryConfig, q, database)
+	if err != nil {
+		return nil, fmt.Errorf("查询视图定义失败: %w", err)
+	}
+	defer rows.Close()
+
+	defs := make(map[string]string)
+	for rows.Next() {
+		var name string
+		var def sql.NullString
+		if err := rows.Scan(&name, &def); err != nil {
+			return nil, fmt.Errorf("扫描视图定义失败: %w", err)
+		}
+		defs[name] = def.String
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历视图定义失败: %w", err)
+	}
+	return defs, nil
+}
+
+// ViewDefinition 在 ctx 下获取单个视图的定义，视图不存在时 ok 为 false
+func ViewDefinition(ctx context.Context, srDB *sql.DB, retryConfig retry.Config, database, view string) (string, bool, error) {
+	q := "SELECT VIEW_DEFINITION FROM information_schema.VIEWS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?"
+	var def sql.NullString
+	err := QueryRowContextWithRetry(ctx, srDB, retryConfig, q, []interface{}{&def}, database, view)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("查询视图 %s 定义失败: %w", view, err)
+	}
+	return def.String, true, nil
+}
+
+// ViewComments 一次性获取库内所有视图及其注释（视图名 -> 注释）
+func ViewComments(srDB *sql.DB, retryConfig retry.Config, database string) (map[string]string, error) {
+	q := "SELECT TABLE_NAME, TABLE_COMMENT FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'VIEW'"
+	rows, err := retry.QueryWithRetry(srDB, retryConfig, q, database)
+	if err != nil {
+		return nil, fmt.Errorf("查询视图列表失败: %w", err)
+	}
+	defer rows.Close()
+
+	comments := make(map[string]string)
+	for rows.Next() {
+		var name string
+		var comment sql.NullString
+		if err := rows.Scan(&name, &comment); err != nil {
+			return nil, fmt.Errorf("扫描视图列表失败: %w", err)
+		}
+		comments[name] = comment.String
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历视图列表失败: %w", err)
+	}
+	return comments, nil
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"strings"
+
+	"cksr/builder"
+	"cksr/internal/settings"
+
+	mcfg "example.com/migrationLib/config"
+)
+
+// ViewTiers 返回视图配置的更早数据层（view_updater.tables.<视图名>.tiers，从早到晚），未配置时为空
+// clickhouse 层沿用 CK 表的字段转换器（结构须与 CK 表一致），external 层的列由 builder 经该 Catalog 的 information_schema 读取
+func ViewTiers(cfg *mcfg.Config, view string) []builder.Tier {
+	var tiers []builder.Tier
+	for _, t := range settings.Of(cfg).TiersFor(view) {
+		kind := builder.TierClickHouse
+		if t.TierKind() == settings.TierExternal {
+			kind = builder.TierExternal
+		}
+		tiers = append(tiers, builder.Tier{
+			Kind:    kind,
+			Catalog: strings.TrimSpace(t.Catalog),
+			DBName:  strings.TrimSpace(t.Database),
+			Name:    t.TableName(view),
+		})
+	}
+	return tiers
+}
+
+// TierCatalogs 返回全部数据层引用的 Catalog
+func TierCatalogs(cfg *mcfg.Config) map[string]bool {
+	out := map[string]bool{}
+	for _, t := range settings.Of(cfg).ViewUpdater.Tables {
+		for _, tier := range t.Tiers {
+			out[strings.TrimSpace(tier.Catalog)] = true
+		}
+	}
+	return out
+}
@@ -0,0 +1,38 @@
+package configload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"cksr/internal/settings"
+)
+
+// 顶层的扩展字段（migrationLib 配置结构中不存在，解析前从配置文档中取出）
+type rootExt struct {
+	Blackout settings.Blackout `json:"blackout"`
+}
+
+func init() {
+	registerExtension(configType, reflect.TypeOf(rootExt{}))
+}
+
+// extractBlackout 将顶层 blackout 解析到扩展配置并从文档中移除；出现未知键时报错
+func extractBlackout(doc map[string]interface{}, st *settings.Settings) error {
+	v, ok := doc["blackout"]
+	if !ok {
+		return nil
+	}
+	delete(doc, "blackout")
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化 blackout 配置失败: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&st.Blackout); err != nil {
+		return fmt.Errorf("解析 blackout 配置失败: %w", err)
+	}
+	return nil
+}
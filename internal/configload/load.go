@@ -0,0 +1,363 @@
+package configload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cksr/internal/settings"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+	"sigs.k8s.io/yaml"
+)
+
+// 配置值来源，优先级：flag > env > file > default
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceInline  = "inline"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// EnvPrefix 环境变量覆盖前缀，层级之间以双下划线分隔，例如 CKSR_LOCK__DEBUG_MODE
+const EnvPrefix = "CKSR_"
+
+// EnvConfigPath 未通过 --config 指定时，用于指定配置文件路径的环境变量
+const EnvConfigPath = EnvPrefix + "CONFIG"
+
+// DefaultFileName 默认配置文件名（位于可执行文件同目录）
+const DefaultFileName = "config.json"
+
+// defaults 未在任何来源中出现时使用的默认值
+var defaults = map[string]interface{}{
+	"log": map[string]interface{}{
+		"log_level": "INFO",
+	},
+}
+
+// Override 单个配置项覆盖（来自命令行参数）
+type Override struct {
+	Path   string // 点分路径，例如 log.log_level
+	Value  string
+	Source string // 用于日志展示的来源，例如 flag:--log-level
+}
+
+// Options 配置加载选项
+type Options struct {
+	Path       string     // --config 指定的文件路径
+	InlineJSON string     // --config-json 指定的内联 JSON
+	Flags      []Override // 显式设置的命令行参数覆盖
+	Environ    []string   // 环境变量，为 nil 时使用 os.Environ()
+}
+
+// Result 配置加载结果
+type Result struct {
+	Config  *mcfg.Config
+	Raw     []byte            // 合并所有来源并消解密码引用后的 JSON 文档，含明文密码，不得输出
+	Origin  string            // 基础配置来源（文件路径或 inline）
+	Sources map[string]string // 叶子配置项路径 -> 来源
+	// Settings cksr 扩展配置，已与 Config 绑定
+	Settings *settings.Settings
+}
+
+// DefaultPath 返回可执行文件同目录下的 config.json
+func DefaultPath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("获取可执行文件路径失败: %w", err)
+	}
+	return filepath.Join(filepath.Dir(exe), DefaultFileName), nil
+}
+
+// Load 按 default < file < env < flag 的优先级合并配置并解析
+func Load(opts Options) (*Result, error) {
+	environ := opts.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	res := &Result{Sources: make(map[string]string)}
+
+	doc := deepCopy(defaults).(map[string]interface{})
+	markLeaves(doc, nil, SourceDefault, res.Sources)
+
+	base, origin, err := readBase(opts, environ)
+	if err != nil {
+		return nil, err
+	}
+	res.Origin = origin
+	source := SourceFile + ":" + origin
+	if origin == SourceInline {
+		source = SourceInline
+	}
+	mergeInto(doc, base, nil, source, res.Sources)
+
+	for _, ov := range envOverrides(environ) {
+		if err := apply(doc, ov, res.Sources); err != nil {
+			return nil, err
+		}
+	}
+	for _, ov := range opts.Flags {
+		if err := apply(doc, ov, res.Sources); err != nil {
+			return nil, err
+		}
+	}
+
+	// 密码引用需在解析与校验之前消解
+	st, err := resolveSecrets(doc, res.Sources, environ)
+	if err != nil {
+		return nil, err
+	}
+	if err := extractViewUpdater(doc, st); err != nil {
+		return nil, err
+	}
+	if err := extractBlackout(doc, st); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("序列化合并后的配置失败: %w", err)
+	}
+	cfg, err := mcfg.ParseConfigBytes(raw)
+	if err != nil {
+		return nil, err
+	}
+	settings.Attach(cfg, st)
+	res.Config = cfg
+	res.Raw = raw
+	res.Settings = st
+	return res, nil
+}
+
+// LogSources 在 DEBUG 级别输出每个配置项的生效来源（不输出值，避免泄露敏感信息）
+func (r *Result) LogSources() {
+	paths := make([]string, 0, len(r.Sources))
+	for p := range r.Sources {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	logger.Debug("配置来源: %s", r.Origin)
+	for _, p := range paths {
+		logger.Debug("配置项 %s 来源: %s", p, r.Sources[p])
+	}
+}
+
+// readBase 读取基础配置：--config-json、--config、CKSR_CONFIG、可执行文件同目录 config.json 依次择一
+func readBase(opts Options, environ []string) (map[string]interface{}, string, error) {
+	if opts.InlineJSON != "" && opts.Path != "" {
+		return nil, "", fmt.Errorf("--config 与 --config-json 不能同时使用")
+	}
+	if opts.InlineJSON != "" {
+		m, err := decodeObject([]byte(opts.InlineJSON))
+		if err != nil {
+			return nil, "", fmt.Errorf("解析 --config-json 失败: %w", err)
+		}
+		return m, SourceInline, nil
+	}
+
+	path := opts.Path
+	if path == "" {
+		path = lookupEnv(environ, EnvConfigPath)
+	}
+	if path == "" {
+		p, err := DefaultPath()
+		if err != nil {
+			return nil, "", err
+		}
+		path = p
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err = yaml.YAMLToJSON(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("解析 YAML 配置文件 %s 失败: %w", path, err)
+		}
+	}
+	m, err := decodeObject(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	return m, path, nil
+}
+
+func decodeObject(data []byte) (map[string]interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, fmt.Errorf("配置内容为空")
+	}
+	return m, nil
+}
+
+// envOverrides 收集 CKSR_ 前缀的环境变量，按变量名排序保证结果稳定
+func envOverrides(environ []string) []Override {
+	var out []Override
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, EnvPrefix) || name == EnvConfigPath {
+			continue
+		}
+		segs := strings.Split(strings.ToLower(strings.TrimPrefix(name, EnvPrefix)), "__")
+		out = append(out, Override{
+			Path:   strings.Join(segs, "."),
+			Value:  value,
+			Source: SourceEnv + ":" + name,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+	return out
+}
+
+func lookupEnv(environ []string, key string) string {
+	v, _ := lookupEnvOK(environ, key)
+	return v
+}
+
+func lookupEnvOK(environ []string, key string) (string, bool) {
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok && name == key {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// apply 将单个覆盖写入配置文档；环境变量中无法识别的路径仅告警，命令行参数则报错
+func apply(doc map[string]interface{}, ov Override, sources map[string]string) error {
+	path, value, err := resolve(doc, strings.Split(ov.Path, "."), ov.Value)
+	if err != nil {
+		if strings.HasPrefix(ov.Source, SourceEnv+":") {
+			logger.Warn("忽略无法识别的环境变量 %s: %v", strings.TrimPrefix(ov.Source, SourceEnv+":"), err)
+			return nil
+		}
+		return fmt.Errorf("%s 覆盖配置 %s 失败: %w", ov.Source, ov.Path, err)
+	}
+	setAt(doc, path, value)
+	clearSources(sources, path)
+	markLeaves(value, path, ov.Source, sources)
+	return nil
+}
+
+// mergeInto 深度合并：对象逐键合并，其余类型（含数组）整体替换
+func mergeInto(dst map[string]interface{}, src map[string]interface{}, prefix []string, source string, sources map[string]string) {
+	for k, v := range src {
+		path := append(append([]string{}, prefix...), k)
+		if sm, ok := v.(map[string]interface{}); ok {
+			if dm, ok := dst[k].(map[string]interface{}); ok {
+				mergeInto(dm, sm, path, source, sources)
+				continue
+			}
+		}
+		clearSources(sources, path)
+		dst[k] = v
+		markLeaves(v, path, source, sources)
+	}
+}
+
+// setAt 按已解析的路径写入值；数组下标已在 resolve 阶段确认存在，缺失的对象按需创建
+func setAt(doc map[string]interface{}, path []string, value interface{}) {
+	var node interface{} = doc
+	for i, seg := range path {
+		last := i == len(path)-1
+		switch n := node.(type) {
+		case map[string]interface{}:
+			if last {
+				n[seg] = value
+				return
+			}
+			next, ok := n[seg]
+			if !ok || next == nil {
+				next = map[string]interface{}{}
+				n[seg] = next
+			}
+			node = next
+		case []interface{}:
+			idx, _ := strconv.Atoi(seg)
+			if last {
+				n[idx] = value
+				return
+			}
+			if n[idx] == nil {
+				n[idx] = map[string]interface{}{}
+			}
+			node = n[idx]
+		}
+	}
+}
+
+// markLeaves 为值中的每个叶子记录来源
+func markLeaves(v interface{}, path []string, source string, sources map[string]string) {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		if len(n) == 0 {
+			sources[strings.Join(path, ".")] = source
+		}
+		for k, c := range n {
+			markLeaves(c, append(append([]string{}, path...), k), source, sources)
+		}
+	case []interface{}:
+		// 对象数组（如 database_pairs）逐元素记录，标量数组整体作为叶子
+		if !hasObject(n) {
+			sources[strings.Join(path, ".")] = source
+			return
+		}
+		for i, c := range n {
+			markLeaves(c, append(append([]string{}, path...), strconv.Itoa(i)), source, sources)
+		}
+	default:
+		sources[strings.Join(path, ".")] = source
+	}
+}
+
+func hasObject(s []interface{}) bool {
+	for _, v := range s {
+		if _, ok := v.(map[string]interface{}); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// clearSources 删除路径及其子路径上已记录的来源
+func clearSources(sources map[string]string, path []string) {
+	p := strings.Join(path, ".")
+	for k := range sources {
+		if k == p || strings.HasPrefix(k, p+".") {
+			delete(sources, k)
+		}
+	}
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch n := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(n))
+		for k, c := range n {
+			m[k] = deepCopy(c)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(n))
+		for i, c := range n {
+			s[i] = deepCopy(c)
+		}
+		return s
+	default:
+		return v
+	}
+}
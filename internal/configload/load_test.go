@@ -0,0 +1,258 @@
+package configload
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const baseJSON = `{
+	"log": {"log_level": "DEBUG", "log_file_path": "/var/log/cksr.log"},
+	"lock": {"debug_mode": false, "lease_name": "cksr"},
+	"database_pairs": [
+		{"name": "pair1", "clickhouse": {"host": "ck1", "port": 9000}, "starrocks": {"host": "sr1", "port": 9030}},
+		{"name": "pair2", "clickhouse": {"host": "ck2", "port": 9000}, "starrocks": {"host": "sr2", "port": 9030}}
+	]
+}`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.json", baseJSON)
+	tests := []struct {
+		name       string
+		opts       Options
+		wantLevel  string
+		wantSource string
+	}{
+		{
+			name:       "仅默认值",
+			opts:       Options{InlineJSON: `{}`, Environ: []string{}},
+			wantLevel:  "INFO",
+			wantSource: SourceDefault,
+		},
+		{
+			name:       "文件覆盖默认值",
+			opts:       Options{Path: file, Environ: []string{}},
+			wantLevel:  "DEBUG",
+			wantSource: SourceFile + ":" + file,
+		},
+		{
+			name:       "内联 JSON 覆盖默认值",
+			opts:       Options{InlineJSON: `{"log": {"log_level": "ERROR"}}`, Environ: []string{}},
+			wantLevel:  "ERROR",
+			wantSource: SourceInline,
+		},
+		{
+			name:       "环境变量覆盖文件",
+			opts:       Options{Path: file, Environ: []string{"CKSR_LOG__LOG_LEVEL=WARN"}},
+			wantLevel:  "WARN",
+			wantSource: "env:CKSR_LOG__LOG_LEVEL",
+		},
+		{
+			name: "命令行参数覆盖环境变量",
+			opts: Options{
+				Path:    file,
+				Environ: []string{"CKSR_LOG__LOG_LEVEL=WARN"},
+				Flags:   []Override{{Path: "log.log_level", Value: "ERROR", Source: "flag:--log-level"}},
+			},
+			wantLevel:  "ERROR",
+			wantSource: "flag:--log-level",
+		},
+		{
+			name:       "环境变量名不区分大小写",
+			opts:       Options{Path: file, Environ: []string{"CKSR_Log__Log_Level=WARN"}},
+			wantLevel:  "WARN",
+			wantSource: "env:CKSR_Log__Log_Level",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Load(tt.opts)
+			if err != nil {
+				t.Fatalf("加载配置失败: %v", err)
+			}
+			if got := res.Config.Log.LogLevel; got != tt.wantLevel {
+				t.Fatalf("log_level = %q，期望 %q", got, tt.wantLevel)
+			}
+			if got := res.Sources["log.log_level"]; got != tt.wantSource {
+				t.Fatalf("log.log_level 来源 = %q，期望 %q", got, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestLoadOverridesKeepSiblings(t *testing.T) {
+	file := writeFile(t, "config.json", baseJSON)
+	res, err := Load(Options{
+		Path: file,
+		Environ: []string{
+			"CKSR_DATABASE_PAIRS__PAIR2__CLICKHOUSE__PORT=9440",
+			"CKSR_LOCK__DEBUG_MODE=true",
+		},
+		Flags: []Override{{Path: "database_pairs.0.starrocks.host", Value: "sr-flag", Source: "flag:--set"}},
+	})
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	cfg := res.Config
+	if got := cfg.DatabasePairs[1].ClickHouse.Port; got != 9440 {
+		t.Fatalf("按 name 定位的数组元素未被覆盖，port = %d", got)
+	}
+	if cfg.DatabasePairs[1].ClickHouse.Host != "ck2" || cfg.DatabasePairs[0].ClickHouse.Port != 9000 {
+		t.Fatalf("覆盖单个字段不应影响同级字段: %+v", cfg.DatabasePairs)
+	}
+	if cfg.DatabasePairs[0].StarRocks.Host != "sr-flag" {
+		t.Fatalf("按下标定位的数组元素未被覆盖: %+v", cfg.DatabasePairs[0].StarRocks)
+	}
+	if !cfg.Lock.DebugMode || cfg.Lock.LeaseName != "cksr" {
+		t.Fatalf("lock 覆盖结果不正确: %+v", cfg.Lock)
+	}
+	if cfg.Log.LogFilePath != "/var/log/cksr.log" {
+		t.Fatalf("未覆盖的文件配置丢失: %+v", cfg.Log)
+	}
+	for path, want := range map[string]string{
+		"database_pairs.1.clickhouse.port": "env:CKSR_DATABASE_PAIRS__PAIR2__CLICKHOUSE__PORT",
+		"database_pairs.1.clickhouse.host": SourceFile + ":" + file,
+		"database_pairs.0.starrocks.host":  "flag:--set",
+		"lock.debug_mode":                  "env:CKSR_LOCK__DEBUG_MODE",
+	} {
+		if got := res.Sources[path]; got != want {
+			t.Errorf("%s 来源 = %q，期望 %q", path, got, want)
+		}
+	}
+}
+
+func TestLoadBaseSelection(t *testing.T) {
+	explicit := writeFile(t, "explicit.json", `{"log": {"log_level": "WARN"}}`)
+	fromEnv := writeFile(t, "env.yaml", "log:\n  log_level: ERROR\n")
+	tests := []struct {
+		name      string
+		opts      Options
+		wantLevel string
+		wantErr   string
+	}{
+		{
+			name:      "--config 优先于 CKSR_CONFIG",
+			opts:      Options{Path: explicit, Environ: []string{EnvConfigPath + "=" + fromEnv}},
+			wantLevel: "WARN",
+		},
+		{
+			name:      "未指定 --config 时使用 CKSR_CONFIG，支持 YAML",
+			opts:      Options{Environ: []string{EnvConfigPath + "=" + fromEnv}},
+			wantLevel: "ERROR",
+		},
+		{
+			name:    "--config 与 --config-json 不能同时使用",
+			opts:    Options{Path: explicit, InlineJSON: `{}`, Environ: []string{}},
+			wantErr: "不能同时使用",
+		},
+		{
+			name:    "配置文件不存在",
+			opts:    Options{Path: filepath.Join(t.TempDir(), "missing.json"), Environ: []string{}},
+			wantErr: "读取配置文件",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := Load(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("加载配置失败: %v", err)
+			}
+			if got := res.Config.Log.LogLevel; got != tt.wantLevel {
+				t.Fatalf("log_level = %q，期望 %q", got, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestLoadOverrideErrors(t *testing.T) {
+	file := writeFile(t, "config.json", baseJSON)
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{
+			name:    "命令行参数路径未知时报错",
+			opts:    Options{Path: file, Environ: []string{}, Flags: []Override{{Path: "log.no_such_key", Value: "x", Source: "flag:--set"}}},
+			wantErr: "未知配置项",
+		},
+		{
+			name:    "命令行参数类型不匹配时报错",
+			opts:    Options{Path: file, Environ: []string{}, Flags: []Override{{Path: "lock.debug_mode", Value: "maybe", Source: "flag:--set"}}},
+			wantErr: "期望布尔值",
+		},
+		{
+			name:    "数组元素不存在时报错",
+			opts:    Options{Path: file, Environ: []string{}, Flags: []Override{{Path: "database_pairs.pair9.clickhouse.port", Value: "1", Source: "flag:--set"}}},
+			wantErr: "pair9",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.opts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("错误 = %v，期望包含 %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// 环境变量路径无法识别时仅告警，不影响加载
+	res, err := Load(Options{Path: file, Environ: []string{"CKSR_NO_SUCH_SECTION__KEY=1"}})
+	if err != nil {
+		t.Fatalf("无法识别的环境变量不应导致加载失败: %v", err)
+	}
+	if res.Config.Log.LogLevel != "DEBUG" {
+		t.Fatalf("log_level = %q，期望 DEBUG", res.Config.Log.LogLevel)
+	}
+}
+
+func TestLoadPasswordReference(t *testing.T) {
+	secret := writeFile(t, "ck.pass", "s3cret\n")
+	doc := `{"database_pairs": [{"name": "pair1",
+		"clickhouse": {"host": "ck1", "password_file": "` + secret + `"},
+		"starrocks": {"host": "sr1", "password_env": "SR_PASS"}}]}`
+	res, err := Load(Options{InlineJSON: doc, Environ: []string{"SR_PASS=from-env"}})
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	p := res.Config.DatabasePairs[0]
+	if p.ClickHouse.Password != "s3cret" || p.StarRocks.Password != "from-env" {
+		t.Fatalf("密码引用未消解: ck=%q sr=%q", p.ClickHouse.Password, p.StarRocks.Password)
+	}
+	if strings.Contains(string(res.Raw), "password_file") || strings.Contains(string(res.Raw), "password_env") {
+		t.Fatalf("合并后的配置不应保留密码引用字段: %s", res.Raw)
+	}
+
+	// 配置文件中的明文密码与引用同时出现视为冲突，命令行覆盖的密码优先于引用
+	conflict := `{"database_pairs": [{"name": "pair1", "clickhouse": {"password": "plain", "password_env": "CK_PASS"}}]}`
+	if _, err := Load(Options{InlineJSON: conflict, Environ: []string{"CK_PASS=x"}}); err == nil {
+		t.Fatal("password 与 password_env 同时配置时应报错")
+	}
+	res, err = Load(Options{
+		InlineJSON: conflict,
+		Environ:    []string{"CK_PASS=x"},
+		Flags:      []Override{{Path: "database_pairs.pair1.clickhouse.password", Value: "from-flag", Source: "flag:--set"}},
+	})
+	if err != nil {
+		t.Fatalf("命令行覆盖密码后不应报错: %v", err)
+	}
+	if got := res.Config.DatabasePairs[0].ClickHouse.Password; got != "from-flag" {
+		t.Fatalf("password = %q，期望 from-flag", got)
+	}
+}
@@ -0,0 +1,175 @@
+package configload
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	mcfg "example.com/migrationLib/config"
+)
+
+// configType 配置结构体类型，用于按 json 标签解析覆盖路径并确定值类型
+var configType = reflect.TypeOf(mcfg.Config{})
+
+// resolve 根据配置结构将覆盖路径规范化（数组元素可用下标或 name 指定，map 键大小写不敏感），
+// 并按目标字段类型转换字符串值
+func resolve(doc map[string]interface{}, segs []string, raw string) ([]string, interface{}, error) {
+	t := configType
+	var node interface{} = doc
+	path := make([]string, 0, len(segs))
+	for _, seg := range segs {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		switch t.Kind() {
+		case reflect.Struct:
+			f, ok := fieldByJSONName(t, seg)
+			if !ok {
+				return nil, nil, fmt.Errorf("未知配置项 %q", strings.Join(append(path, seg), "."))
+			}
+			name := jsonName(f)
+			path = append(path, name)
+			node = child(node, name)
+			t = f.Type
+		case reflect.Map:
+			key := seg
+			if m, ok := node.(map[string]interface{}); ok {
+				for k := range m {
+					if strings.EqualFold(k, seg) {
+						key = k
+						break
+					}
+				}
+			}
+			path = append(path, key)
+			node = child(node, key)
+			t = t.Elem()
+		case reflect.Slice:
+			arr, _ := node.([]interface{})
+			idx, err := sliceIndex(arr, seg)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", strings.Join(path, "."), err)
+			}
+			path = append(path, strconv.Itoa(idx))
+			node = arr[idx]
+			t = t.Elem()
+		default:
+			return nil, nil, fmt.Errorf("配置项 %q 不是对象，无法继续定位 %q", strings.Join(path, "."), seg)
+		}
+	}
+	value, err := convert(t, raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("配置项 %s: %w", strings.Join(path, "."), err)
+	}
+	return path, value, nil
+}
+
+func child(node interface{}, key string) interface{} {
+	if m, ok := node.(map[string]interface{}); ok {
+		return m[key]
+	}
+	return nil
+}
+
+// sliceIndex 数组元素可通过下标或元素的 name 字段（大小写不敏感）定位，下标必须已存在
+func sliceIndex(arr []interface{}, seg string) (int, error) {
+	if idx, err := strconv.Atoi(seg); err == nil {
+		if idx < 0 || idx >= len(arr) {
+			return 0, fmt.Errorf("下标 %d 超出范围（共 %d 个元素）", idx, len(arr))
+		}
+		return idx, nil
+	}
+	for i, v := range arr {
+		if m, ok := v.(map[string]interface{}); ok {
+			if name, ok := m["name"].(string); ok && strings.EqualFold(name, seg) {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("未找到 name 为 %q 的元素", seg)
+}
+
+// extensions 为 migrationLib 配置结构补充 cksr 自有字段，使其同样可被环境变量覆盖
+var extensions = map[reflect.Type][]reflect.Type{}
+
+func registerExtension(parent, ext reflect.Type) {
+	extensions[parent] = append(extensions[parent], ext)
+}
+
+func fieldByJSONName(t reflect.Type, name string) (reflect.StructField, bool) {
+	for _, st := range append([]reflect.Type{t}, extensions[t]...) {
+		for i := 0; i < st.NumField(); i++ {
+			f := st.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if strings.EqualFold(jsonName(f), name) {
+				return f, true
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func jsonName(f reflect.StructField) string {
+	tag := f.Tag.Get("json")
+	if tag == "" || tag == "-" {
+		return f.Name
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// convert 按目标类型转换覆盖值：标量直接解析，字符串数组支持逗号分隔，其余类型要求 JSON
+func convert(t reflect.Type, raw string) (interface{}, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return raw, nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("期望布尔值，实际为 %q", raw)
+		}
+		return b, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if _, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64); err != nil {
+			return nil, fmt.Errorf("期望整数，实际为 %q", raw)
+		}
+		return json.Number(strings.TrimSpace(raw)), nil
+	case reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(raw), 64); err != nil {
+			return nil, fmt.Errorf("期望数值，实际为 %q", raw)
+		}
+		return json.Number(strings.TrimSpace(raw)), nil
+	case reflect.Slice:
+		trimmed := strings.TrimSpace(raw)
+		if t.Elem().Kind() == reflect.String && !strings.HasPrefix(trimmed, "[") {
+			var items []interface{}
+			for _, s := range strings.Split(trimmed, ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					items = append(items, s)
+				}
+			}
+			if items == nil {
+				items = []interface{}{}
+			}
+			return items, nil
+		}
+	}
+	var v interface{}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("期望 JSON 值: %w", err)
+	}
+	return v, nil
+}
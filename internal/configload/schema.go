@@ -0,0 +1,182 @@
+package configload
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaID 配置 JSON Schema 的标识
+const SchemaID = "https://cksr/config.schema.json"
+
+// schemaAnnotations 在反射生成的结构之上补充的约束，键为点分路径，数组元素与 map 值以 * 表示
+var schemaAnnotations = map[string]map[string]interface{}{
+	"database_pairs":                            {"minItems": 1},
+	"database_pairs.*.name":                     {"minLength": 1, "description": "数据库对名称，需唯一"},
+	"database_pairs.*.catalog_name":             {"minLength": 1, "description": "StarRocks Catalog 名称，需唯一"},
+	"database_pairs.*.sr_table_suffix":          {"minLength": 1, "description": "SR 表重命名后缀，不能为空"},
+	"database_pairs.*.catalog_password_file":    {"description": "创建 Catalog 时使用的 JDBC 密码文件"},
+	"database_pairs.*.clickhouse.password_file": {"description": "密码文件路径，与 password/password_env 互斥"},
+	"database_pairs.*.clickhouse.password_env":  {"description": "存放密码的环境变量名，与 password/password_file 互斥"},
+	"database_pairs.*.starrocks.password_file":  {"description": "密码文件路径，与 password/password_env 互斥"},
+	"database_pairs.*.starrocks.password_env":   {"description": "存放密码的环境变量名，与 password/password_file 互斥"},
+	"timestamp_columns.*.column":                {"minLength": 1},
+	"timestamp_columns.*.type":                  {"enum": []string{"date", "datetime", "bigint"}},
+	"log.log_level": {"enum": []string{
+		"SILENT", "ERROR", "WARN", "WARNING", "INFO", "DEBUG",
+		"silent", "error", "warn", "warning", "info", "debug",
+	}},
+	"view_updater.cron_expression":               {"description": "带秒字段的 cron 表达式，例如 */10 * * * * *"},
+	"rollback.strategy":                          {"enum": []string{"", "fail_fast", "continue_on_error"}},
+	"view_updater.strategy":                      {"enum": []string{"", "fail_fast", "continue_on_error"}},
+	"view_updater.concurrency":                   {"minimum": 1, "description": "单个数据库对内并行更新视图的协程数，默认 1（串行）"},
+	"view_updater.view_timeout_seconds":          {"minimum": 0, "description": "单个视图更新超时秒数，0 表示不限制"},
+	"view_updater.jitter_seconds":                {"minimum": 0, "description": "每次触发后随机延迟的最大秒数，0 表示不延迟"},
+	"view_updater.catch_up":                      {"enum": []string{"", "skip", "run_once"}, "description": "停机期间错过调度时的处理"},
+	"view_updater.state_file":                    {"description": "调度状态文件，默认 <temp_dir>/auto_update_state.json"},
+	"view_updater.leader_election":               {"description": "多副本常驻时在 K8s Lease 上持续选主，只有 leader 执行调度"},
+	"view_updater.leader_lease_name":             {"description": "选主使用的 Lease 名称，默认 <lock.lease_name>-leader"},
+	"view_updater.admin.enabled":                 {"description": "启动 auto-update 的 HTTP 管理接口"},
+	"view_updater.admin.listen":                  {"description": "管理接口监听地址，默认 127.0.0.1:9480（仅本机）"},
+	"view_updater.metrics.enabled":               {"description": "启动 auto-update 的指标与健康检查接口（/metrics、/healthz、/readyz）"},
+	"view_updater.metrics.listen":                {"description": "指标接口监听地址，默认 :9481"},
+	"view_updater.metrics.failed_ticks":          {"minimum": 0, "description": "连续失败多少轮后 /readyz 返回 503，默认 3"},
+	"blackout":                                   {"description": "禁止执行 DDL 的维护窗口：auto-update 跳过触发，init/update/rollback/apply 需 --ignore-blackout"},
+	"blackout.timezone":                          {"description": "窗口时间所在的 IANA 时区，例如 Asia/Shanghai，默认本地时区"},
+	"blackout.windows.*.cron":                    {"description": "窗口开始时间，带秒字段的 cron 表达式，与 duration 搭配"},
+	"blackout.windows.*.duration":                {"description": "每次 cron 触发后窗口持续的时长，例如 2h、3d"},
+	"blackout.windows.*.weekdays":                {"description": "与 start/end 搭配的星期（mon…sun），为空表示每天"},
+	"blackout.windows.*.start":                   {"description": "每天的开始时间 HH:MM"},
+	"blackout.windows.*.end":                     {"description": "每天的结束时间 HH:MM，不晚于 start 时跨越午夜"},
+	"view_updater.reconcile.enabled":             {"description": "每轮全局调度前执行自愈：纳管新的共同表、补建 Catalog 与缺失的视图、隔离依赖缺失的视图"},
+	"view_updater.reconcile.dry_run":             {"description": "自愈只记录将执行的动作，不做任何变更，默认 true"},
+	"view_updater.boundary":                      {"description": "auto-update 时间边界策略，view_updater.tables.<视图名>.boundary 可按表覆盖"},
+	"view_updater.boundary.strategy":             boundaryStrategy,
+	"view_updater.boundary.empty_sr":             boundaryEmptySR,
+	"view_updater.boundary.granularity":          boundaryGranularity,
+	"view_updater.boundary.bigint_unit":          boundaryBigintUnit,
+	"view_updater.tables.*.cron_expression":      {"description": "该视图的独立调度，带秒字段的 cron 表达式；配置后不再参与全局调度"},
+	"view_updater.tables.*.enabled":              {"description": "为 false 时 auto-update 不更新该视图，默认 true"},
+	"view_updater.tables.*.frozen_until":         {"description": "在该时间前保持视图当前边界，格式 2006-01-02 15:04:05（本地时区）或 RFC3339"},
+	"view_updater.tables.*.boundary.strategy":    boundaryStrategy,
+	"view_updater.tables.*.boundary.empty_sr":    boundaryEmptySR,
+	"view_updater.tables.*.boundary.granularity": boundaryGranularity,
+	"view_updater.tables.*.boundary.bigint_unit": boundaryBigintUnit,
+	"view_updater.tables.*.tiers":                {"description": "比 CK 更早的数据层（从早到晚），视图依次由各层、CK、SR 分支组成"},
+	"view_updater.tables.*.tiers.*.kind":         {"enum": []string{"", "clickhouse", "external"}},
+	"view_updater.tables.*.tiers.*.catalog":      {"description": "SR 中访问该层的 Catalog，需预先创建，回滚不会删除"},
+	"view_updater.tables.*.tiers.*.database":     {"description": "该层表所在的库"},
+	"view_updater.tables.*.tiers.*.table":        {"description": "该层的表名，默认与视图同名"},
+	"lock.lock_duration_seconds":                 {"exclusiveMinimum": 0},
+}
+
+// 边界策略字段的约束，全局与表级共用；取值与 internal/boundary 中的常量一致
+var (
+	boundaryStrategy    = map[string]interface{}{"enum": []string{"", "sr_min", "retention", "partition_aligned", "ck_max", "fixed_lag"}}
+	boundaryEmptySR     = map[string]interface{}{"enum": []string{"", "route_to_ck", "keep", "fail"}}
+	boundaryGranularity = map[string]interface{}{"enum": []string{"", "hour", "day", "month"}}
+	boundaryBigintUnit  = map[string]interface{}{"enum": []string{"", "s", "ms"}}
+)
+
+// Schema 由配置结构（含 cksr 扩展字段）生成 JSON Schema 文档
+func Schema() map[string]interface{} {
+	s := schemaFor(configType, nil)
+	s["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	s["$id"] = SchemaID
+	s["title"] = "cksr 配置"
+	return s
+}
+
+func schemaFor(t reflect.Type, path []string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var s map[string]interface{}
+	switch t.Kind() {
+	case reflect.Struct:
+		props := map[string]interface{}{}
+		for _, st := range append([]reflect.Type{t}, extensions[t]...) {
+			for i := 0; i < st.NumField(); i++ {
+				f := st.Field(i)
+				if !f.IsExported() || f.Tag.Get("json") == "-" {
+					continue
+				}
+				name := jsonName(f)
+				props[name] = schemaFor(f.Type, append(append([]string{}, path...), name))
+			}
+		}
+		s = map[string]interface{}{
+			"type":                 "object",
+			"properties":           props,
+			"additionalProperties": false,
+		}
+	case reflect.Map:
+		s = map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": schemaFor(t.Elem(), append(append([]string{}, path...), "*")),
+		}
+	case reflect.Slice, reflect.Array:
+		s = map[string]interface{}{
+			"type":  "array",
+			"items": schemaFor(t.Elem(), append(append([]string{}, path...), "*")),
+		}
+	case reflect.String:
+		s = map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		s = map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		s = map[string]interface{}{"type": "number"}
+	default:
+		s = map[string]interface{}{}
+	}
+	for k, v := range schemaAnnotations[strings.Join(path, ".")] {
+		s[k] = v
+	}
+	return s
+}
+
+// UnknownKeys 返回配置文档中不属于配置结构的键（通常是拼写错误），按路径排序
+func UnknownKeys(doc map[string]interface{}) []string {
+	var out []string
+	collectUnknown(doc, configType, nil, &out)
+	sort.Strings(out)
+	return out
+}
+
+func collectUnknown(node interface{}, t reflect.Type, path []string, out *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for k, v := range m {
+			p := append(append([]string{}, path...), k)
+			f, ok := fieldByJSONName(t, k)
+			if !ok || jsonName(f) != k {
+				*out = append(*out, strings.Join(p, "."))
+				continue
+			}
+			collectUnknown(v, f.Type, p, out)
+		}
+	case reflect.Map:
+		if m, ok := node.(map[string]interface{}); ok {
+			for k, v := range m {
+				collectUnknown(v, t.Elem(), append(append([]string{}, path...), k), out)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if arr, ok := node.([]interface{}); ok {
+			for i, v := range arr {
+				collectUnknown(v, t.Elem(), append(append([]string{}, path...), strconv.Itoa(i)), out)
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package configload
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"cksr/internal/settings"
+	"cksr/logger"
+)
+
+// 数据库连接中引用密码的扩展字段（migrationLib 配置结构中不存在，解析前在配置文档中消解）
+type secretRef struct {
+	PasswordFile string `json:"password_file"`
+	PasswordEnv  string `json:"password_env"`
+}
+
+// 数据库对中 Catalog JDBC 密码的扩展字段
+type pairSecretRef struct {
+	CatalogPasswordFile string `json:"catalog_password_file"`
+}
+
+// 密码来源标识
+const (
+	SourcePasswordFile = "password_file"
+	SourcePasswordEnv  = "password_env"
+)
+
+func init() {
+	pairType := configType
+	if f, ok := pairType.FieldByName("DatabasePairs"); ok {
+		pairType = f.Type.Elem()
+		registerExtension(pairType, reflect.TypeOf(pairSecretRef{}))
+		for _, side := range []string{"ClickHouse", "StarRocks"} {
+			if f, ok := pairType.FieldByName(side); ok {
+				registerExtension(f.Type, reflect.TypeOf(secretRef{}))
+			}
+		}
+	}
+}
+
+// resolveSecrets 将 password_file / password_env / catalog_password_file 解析为实际密码，
+// 从配置文档中移除引用字段，并登记到日志脱敏列表
+func resolveSecrets(doc map[string]interface{}, sources map[string]string, environ []string) (*settings.Settings, error) {
+	st := &settings.Settings{}
+	pairs, _ := doc["database_pairs"].([]interface{})
+	for i, v := range pairs {
+		pair, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		prefix := "database_pairs." + strconv.Itoa(i)
+		for _, side := range []string{"clickhouse", "starrocks"} {
+			conn, ok := pair[side].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := resolveConnPassword(conn, prefix+"."+side, sources, environ); err != nil {
+				return nil, err
+			}
+			if pw, ok := conn["password"].(string); ok {
+				logger.RegisterSecret(pw)
+			}
+		}
+
+		ps := settings.Pair{}
+		if path, ok := pair["catalog_password_file"].(string); ok && path != "" {
+			pw, err := readSecretFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s.catalog_password_file: %w", prefix, err)
+			}
+			ps.CatalogPassword = pw
+			logger.RegisterSecret(pw)
+		}
+		delete(pair, "catalog_password_file")
+		clearSources(sources, []string{prefix, "catalog_password_file"})
+		st.Pairs = append(st.Pairs, ps)
+	}
+	return st, nil
+}
+
+// resolveConnPassword 解析单个连接的密码引用
+// 规则：password_file 与 password_env 只能配置其一；与配置文件中的 password 同时出现视为冲突，
+// 但由环境变量或命令行覆盖的 password 优先于引用（符合 flag > env > file 的优先级）
+func resolveConnPassword(conn map[string]interface{}, prefix string, sources map[string]string, environ []string) error {
+	file, _ := conn["password_file"].(string)
+	envName, _ := conn["password_env"].(string)
+	delete(conn, "password_file")
+	delete(conn, "password_env")
+	clearSources(sources, []string{prefix, "password_file"})
+	clearSources(sources, []string{prefix, "password_env"})
+	if file == "" && envName == "" {
+		return nil
+	}
+	if file != "" && envName != "" {
+		return fmt.Errorf("%s: password_file 与 password_env 只能配置其一", prefix)
+	}
+
+	passwordPath := prefix + ".password"
+	if pw, _ := conn["password"].(string); pw != "" {
+		src := sources[passwordPath]
+		if strings.HasPrefix(src, SourceEnv+":") || strings.HasPrefix(src, SourceFlag+":") {
+			logger.Debug("配置项 %s 已由 %s 覆盖，忽略密码引用", passwordPath, src)
+			return nil
+		}
+		return fmt.Errorf("%s: password 与 password_file/password_env 不能同时配置", prefix)
+	}
+
+	if file != "" {
+		pw, err := readSecretFile(file)
+		if err != nil {
+			return fmt.Errorf("%s.password_file: %w", prefix, err)
+		}
+		conn["password"] = pw
+		sources[passwordPath] = SourcePasswordFile + ":" + file
+		return nil
+	}
+	pw, ok := lookupEnvOK(environ, envName)
+	if !ok {
+		return fmt.Errorf("%s.password_env: 环境变量 %s 未设置", prefix, envName)
+	}
+	conn["password"] = pw
+	sources[passwordPath] = SourcePasswordEnv + ":" + envName
+	return nil
+}
+
+// readSecretFile 读取密钥文件，去除末尾换行（Kubernetes Secret 挂载或 echo 生成的文件常带换行）
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("读取密钥文件 %s 失败: %w", path, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
@@ -0,0 +1,78 @@
+package configload
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"cksr/internal/settings"
+)
+
+// view_updater 下的扩展字段（migrationLib 配置结构中不存在，解析前从配置文档中取出）
+type viewUpdaterExt struct {
+	Strategy           string                    `json:"strategy"`
+	Concurrency        int                       `json:"concurrency"`
+	ViewTimeoutSeconds int                       `json:"view_timeout_seconds"`
+	JitterSeconds      int                       `json:"jitter_seconds"`
+	CatchUp            string                    `json:"catch_up"`
+	StateFile          string                    `json:"state_file"`
+	LeaderElection     bool                      `json:"leader_election"`
+	LeaderLeaseName    string                    `json:"leader_lease_name"`
+	Admin              settings.Admin            `json:"admin"`
+	Metrics            settings.Metrics          `json:"metrics"`
+	Reconcile          settings.Reconcile        `json:"reconcile"`
+	Boundary           settings.Boundary         `json:"boundary"`
+	Tables             map[string]settings.Table `json:"tables"`
+}
+
+func init() {
+	if f, ok := configType.FieldByName("ViewUpdater"); ok {
+		registerExtension(f.Type, reflect.TypeOf(viewUpdaterExt{}))
+	}
+}
+
+// extractViewUpdater 将 view_updater 下的扩展字段（strategy、concurrency、view_timeout_seconds、jitter_seconds、catch_up、state_file、leader_election、leader_lease_name、admin、metrics、reconcile、boundary、tables）解析到扩展配置并从文档中移除；
+// 扩展字段中出现未知键时报错，避免拼写错误导致静默回退到默认策略
+func extractViewUpdater(doc map[string]interface{}, st *settings.Settings) error {
+	vu, ok := doc["view_updater"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	ext := map[string]interface{}{}
+	for _, k := range []string{"strategy", "concurrency", "view_timeout_seconds", "jitter_seconds", "catch_up", "state_file", "leader_election", "leader_lease_name", "admin", "metrics", "reconcile", "boundary", "tables"} {
+		if v, ok := vu[k]; ok {
+			ext[k] = v
+			delete(vu, k)
+		}
+	}
+	if len(ext) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return fmt.Errorf("序列化 view_updater 扩展配置失败: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var e viewUpdaterExt
+	if err := dec.Decode(&e); err != nil {
+		return fmt.Errorf("解析 view_updater 扩展配置失败: %w", err)
+	}
+	st.ViewUpdater = settings.ViewUpdater{
+		Strategy:           e.Strategy,
+		Concurrency:        e.Concurrency,
+		ViewTimeoutSeconds: e.ViewTimeoutSeconds,
+		JitterSeconds:      e.JitterSeconds,
+		CatchUp:            e.CatchUp,
+		StateFile:          e.StateFile,
+		LeaderElection:     e.LeaderElection,
+		LeaderLeaseName:    e.LeaderLeaseName,
+		Admin:              e.Admin,
+		Metrics:            e.Metrics,
+		Reconcile:          e.Reconcile,
+		Boundary:           e.Boundary,
+		Tables:             e.Tables,
+	}
+	return nil
+}
@@ -0,0 +1,398 @@
+package configrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"cksr/internal/autoupdaterun"
+	"cksr/internal/blackout"
+	"cksr/internal/boundary"
+	"cksr/internal/configload"
+	"cksr/internal/rollbackrun"
+	"cksr/internal/settings"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+	mdb "example.com/migrationLib/database"
+	"github.com/robfig/cron/v3"
+)
+
+// 问题级别
+const (
+	LevelError = "error"
+	LevelWarn  = "warn"
+)
+
+// 输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// cronParser 与自动更新器 cron.New(cron.WithSeconds()) 使用的解析规则一致
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Issue 单条校验问题
+type Issue struct {
+	Level   string `json:"level"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// Report 校验结果
+type Report struct {
+	Source string  `json:"source"`
+	Valid  bool    `json:"valid"`
+	Issues []Issue `json:"issues"`
+}
+
+// Options 校验选项
+type Options struct {
+	// Offline 为 true 时跳过需要连接数据库的检查（ignore_tables 是否存在）
+	Offline bool
+}
+
+func (r *Report) add(level, path, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, Issue{Level: level, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Errors 返回错误级别问题数量
+func (r *Report) Errors() int {
+	n := 0
+	for _, i := range r.Issues {
+		if i.Level == LevelError {
+			n++
+		}
+	}
+	return n
+}
+
+// Validate 对已加载的配置执行语义校验
+func Validate(res *configload.Result, opts Options) *Report {
+	cfg := res.Config
+	report := &Report{Source: res.Origin}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(res.Raw, &doc); err == nil {
+		for _, k := range configload.UnknownKeys(doc) {
+			report.add(LevelWarn, k, "未知配置项，将被忽略（是否拼写错误？）")
+		}
+	}
+
+	checkPairs(cfg, report)
+	checkTimestampColumns(cfg, report)
+	checkCron(cfg, report)
+	checkBoundary(cfg, report)
+	checkTiers(cfg, report)
+	checkTableSchedules(cfg, report)
+	checkBlackout(cfg, report)
+	checkRollback(cfg, report)
+	checkUpdaterStrategy(cfg, report)
+	checkLock(cfg, report)
+	if opts.Offline {
+		logger.Info("离线模式，跳过 ignore_tables 存在性检查")
+	} else {
+		checkIgnoreTables(cfg, report)
+	}
+
+	report.Valid = report.Errors() == 0
+	return report
+}
+
+func checkPairs(cfg *mcfg.Config, report *Report) {
+	if len(cfg.DatabasePairs) == 0 {
+		report.add(LevelError, "database_pairs", "至少需要配置一个数据库对")
+		return
+	}
+	names := map[string]int{}
+	catalogs := map[string]int{}
+	for i, pair := range cfg.DatabasePairs {
+		prefix := "database_pairs." + strconv.Itoa(i)
+		if strings.TrimSpace(pair.Name) == "" {
+			report.add(LevelError, prefix+".name", "数据库对名称不能为空")
+		} else if j, ok := names[pair.Name]; ok {
+			report.add(LevelError, prefix+".name", "数据库对名称 %q 与 database_pairs.%d 重复", pair.Name, j)
+		} else {
+			names[pair.Name] = i
+		}
+		if strings.TrimSpace(pair.CatalogName) == "" {
+			report.add(LevelError, prefix+".catalog_name", "catalog_name 不能为空")
+		} else if j, ok := catalogs[pair.CatalogName]; ok {
+			report.add(LevelError, prefix+".catalog_name", "catalog_name %q 与 database_pairs.%d 重复", pair.CatalogName, j)
+		} else {
+			catalogs[pair.CatalogName] = i
+		}
+		if strings.TrimSpace(pair.SRTableSuffix) == "" {
+			report.add(LevelError, prefix+".sr_table_suffix", "sr_table_suffix 不能为空，否则初始化重命名后表名与视图名冲突")
+		}
+		if settings.Of(cfg).Pair(i).CatalogPassword != "" && pair.ClickHouse.HTTPPort == 0 {
+			report.add(LevelError, prefix+".clickhouse.http_port", "配置了 catalog_password_file 时需配置 http_port 作为 Catalog 的 JDBC 端口")
+		}
+	}
+}
+
+func checkTimestampColumns(cfg *mcfg.Config, report *Report) {
+	tables := make([]string, 0, len(cfg.TimestampColumns))
+	for t := range cfg.TimestampColumns {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	for _, t := range tables {
+		tc := cfg.TimestampColumns[t]
+		path := "timestamp_columns." + t
+		if strings.TrimSpace(tc.Column) == "" {
+			report.add(LevelError, path+".column", "时间戳列名不能为空")
+		}
+		switch strings.ToLower(strings.TrimSpace(tc.Type)) {
+		case "date", "datetime", "bigint":
+		default:
+			report.add(LevelError, path+".type", "不支持的时间戳列类型 %q，仅支持 date、datetime、bigint", tc.Type)
+		}
+	}
+}
+
+func checkCron(cfg *mcfg.Config, report *Report) {
+	expr := strings.TrimSpace(cfg.ViewUpdater.CronExpression)
+	if expr == "" {
+		report.add(LevelWarn, "view_updater.cron_expression", "未配置，auto-update 无法启动")
+		return
+	}
+	if _, err := cronParser.Parse(expr); err != nil {
+		report.add(LevelError, "view_updater.cron_expression", "cron 表达式非法（需包含秒字段）: %v", err)
+	}
+}
+
+// checkBoundary 校验全局与表级时间边界策略（表级按合并全局配置后的生效结果校验）
+func checkBoundary(cfg *mcfg.Config, report *Report) {
+	st := settings.Of(cfg)
+	if err := boundary.Validate(st.ViewUpdater.Boundary); err != nil {
+		report.add(LevelError, "view_updater.boundary", "%v", err)
+	}
+	tables := make([]string, 0, len(st.ViewUpdater.Tables))
+	for t := range st.ViewUpdater.Tables {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	for _, t := range tables {
+		if err := boundary.Validate(st.BoundaryFor(t)); err != nil {
+			report.add(LevelError, "view_updater.tables."+t+".boundary", "%v", err)
+		}
+	}
+}
+
+// checkTiers 校验表级数据层：类型、Catalog、数据库必填，边界策略按合并后的生效结果校验
+func checkTiers(cfg *mcfg.Config, report *Report) {
+	st := settings.Of(cfg)
+	tables := make([]string, 0, len(st.ViewUpdater.Tables))
+	for t := range st.ViewUpdater.Tables {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	for _, t := range tables {
+		for i, tier := range st.TiersFor(t) {
+			path := fmt.Sprintf("view_updater.tables.%s.tiers[%d]", t, i)
+			switch tier.TierKind() {
+			case settings.TierClickHouse, settings.TierExternal:
+			default:
+				report.add(LevelError, path+".kind", "未知的数据层类型 %q，仅支持 %s、%s", tier.Kind, settings.TierClickHouse, settings.TierExternal)
+			}
+			if strings.TrimSpace(tier.Catalog) == "" {
+				report.add(LevelError, path+".catalog", "数据层必须配置 catalog")
+			}
+			if strings.TrimSpace(tier.Database) == "" {
+				report.add(LevelError, path+".database", "数据层必须配置 database")
+			}
+			if err := boundary.Validate(st.TierBoundaryFor(t, i)); err != nil {
+				report.add(LevelError, path+".boundary", "%v", err)
+			}
+		}
+	}
+}
+
+// checkTableSchedules 校验表级独立调度与冻结时间
+func checkTableSchedules(cfg *mcfg.Config, report *Report) {
+	st := settings.Of(cfg)
+	tables := make([]string, 0, len(st.ViewUpdater.Tables))
+	for t := range st.ViewUpdater.Tables {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	for _, t := range tables {
+		tc := st.ViewUpdater.Tables[t]
+		path := "view_updater.tables." + t
+		if expr := strings.TrimSpace(tc.CronExpression); expr != "" {
+			if _, err := cronParser.Parse(expr); err != nil {
+				report.add(LevelError, path+".cron_expression", "cron 表达式非法（需包含秒字段）: %v", err)
+			}
+			if !tc.IsEnabled() {
+				report.add(LevelWarn, path+".cron_expression", "视图已禁用（enabled=false），独立调度不会生效")
+			}
+		}
+		if strings.TrimSpace(tc.FrozenUntil) != "" {
+			until, err := settings.ParseFrozenUntil(tc.FrozenUntil)
+			if err != nil {
+				report.add(LevelError, path+".frozen_until", "%v", err)
+			} else if !until.After(time.Now()) {
+				report.add(LevelWarn, path+".frozen_until", "冻结时间 %s 已过，配置不再生效", tc.FrozenUntil)
+			}
+		}
+	}
+}
+
+// checkBlackout 校验维护窗口，当前处于窗口内时给出提示
+func checkBlackout(cfg *mcfg.Config, report *Report) {
+	b := settings.Of(cfg).Blackout
+	valid := true
+	if tz := strings.TrimSpace(b.Timezone); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			report.add(LevelError, "blackout.timezone", "未知时区 %q: %v", tz, err)
+			valid = false
+		}
+	}
+	for i, w := range b.Windows {
+		if err := blackout.ValidateWindow(w); err != nil {
+			report.add(LevelError, fmt.Sprintf("blackout.windows.%d", i), "%v", err)
+			valid = false
+		}
+	}
+	if !valid {
+		return
+	}
+	windows, err := blackout.New(b)
+	if err != nil {
+		report.add(LevelError, "blackout", "%v", err)
+		return
+	}
+	if a, ok := windows.Active(); ok {
+		report.add(LevelWarn, "blackout", "当前处于维护窗口 %s（至 %s），auto-update 将跳过触发，init/update/rollback/apply 需 --ignore-blackout",
+			a.Name, a.Until.Format("2006-01-02 15:04:05"))
+	}
+}
+
+func checkRollback(cfg *mcfg.Config, report *Report) {
+	switch cfg.Rollback.Strategy {
+	case "", rollbackrun.StrategyFailFast, rollbackrun.StrategyContinueOnError:
+	default:
+		report.add(LevelError, "rollback.strategy", "未知的回滚策略 %q，仅支持 %s、%s",
+			cfg.Rollback.Strategy, rollbackrun.StrategyFailFast, rollbackrun.StrategyContinueOnError)
+	}
+}
+
+func checkLock(cfg *mcfg.Config, report *Report) {
+	if cfg.Lock.LockDurationSeconds > 0 {
+		return
+	}
+	// 调试模式使用进程内锁，不依赖租期
+	level := LevelError
+	if cfg.Lock.DebugMode {
+		level = LevelWarn
+	}
+	report.add(level, "lock.lock_duration_seconds", "锁租期必须为正数，当前为 %d", cfg.Lock.LockDurationSeconds)
+}
+
+func checkUpdaterStrategy(cfg *mcfg.Config, report *Report) {
+	vu := settings.Of(cfg).ViewUpdater
+	switch vu.Strategy {
+	case "", autoupdaterun.StrategyFailFast, autoupdaterun.StrategyContinueOnError:
+	default:
+		report.add(LevelError, "view_updater.strategy", "未知的失败处理策略 %q，仅支持 %s、%s",
+			vu.Strategy, autoupdaterun.StrategyFailFast, autoupdaterun.StrategyContinueOnError)
+	}
+	if vu.Concurrency < 0 {
+		report.add(LevelError, "view_updater.concurrency", "并发数不能为负数，当前为 %d", vu.Concurrency)
+	}
+	if vu.ViewTimeoutSeconds < 0 {
+		report.add(LevelError, "view_updater.view_timeout_seconds", "超时秒数不能为负数，当前为 %d", vu.ViewTimeoutSeconds)
+	}
+	if vu.JitterSeconds < 0 {
+		report.add(LevelError, "view_updater.jitter_seconds", "随机延迟秒数不能为负数，当前为 %d", vu.JitterSeconds)
+	}
+	switch strings.ToLower(strings.TrimSpace(vu.CatchUp)) {
+	case "", autoupdaterun.CatchUpSkip:
+	case autoupdaterun.CatchUpRunOnce:
+		if vu.StatePath(cfg.TempDir) == "" {
+			report.add(LevelWarn, "view_updater.catch_up", "未配置 state_file 且 temp_dir 为空，无法记录执行时间，补跑不会生效")
+		}
+	default:
+		report.add(LevelError, "view_updater.catch_up", "未知的补跑策略 %q，仅支持 %s、%s",
+			vu.CatchUp, autoupdaterun.CatchUpSkip, autoupdaterun.CatchUpRunOnce)
+	}
+	if vu.Metrics.FailedTicks < 0 {
+		report.add(LevelError, "view_updater.metrics.failed_ticks", "连续失败轮数不能为负数，当前为 %d", vu.Metrics.FailedTicks)
+	}
+	if vu.Metrics.Enabled && vu.Admin.Enabled && vu.Metrics.Address() == vu.Admin.Address() {
+		report.add(LevelError, "view_updater.metrics.listen", "指标接口与管理接口不能监听同一地址 %s", vu.Metrics.Address())
+	}
+	if vu.Admin.Enabled {
+		if _, err := vu.Admin.Token(); err != nil {
+			report.add(LevelError, "view_updater.admin.token_file", "%v", err)
+		} else if vu.Admin.TokenFile == "" && !vu.Admin.IsLoopback() {
+			report.add(LevelError, "view_updater.admin.listen", "管理接口监听 %s 不限于本机，需配置 token_file", vu.Admin.Address())
+		}
+	}
+	if vu.Reconcile.Enabled && !vu.Reconcile.IsDryRun() {
+		report.add(LevelWarn, "view_updater.reconcile.dry_run", "自愈将直接执行 DDL（创建 Catalog、重命名新表、重建视图），建议先以 dry_run 观察日志")
+	}
+}
+
+// checkIgnoreTables 连接各数据库对的 ClickHouse，确认 ignore_tables 中的表至少存在于一个数据库对
+func checkIgnoreTables(cfg *mcfg.Config, report *Report) {
+	if len(cfg.IgnoreTables) == 0 {
+		return
+	}
+	found := map[string]bool{}
+	for i, pair := range cfg.DatabasePairs {
+		dbManager := mdb.NewDatabasePairManager(cfg, i)
+		if err := dbManager.Init(); err != nil {
+			report.add(LevelWarn, "ignore_tables", "无法连接数据库对 %s，跳过 ignore_tables 存在性检查: %v", pair.Name, err)
+			return
+		}
+		ckTables, err := dbManager.ExportClickHouseTablesAsParserTables()
+		if err != nil {
+			report.add(LevelWarn, "ignore_tables", "读取数据库对 %s 的 ClickHouse 表失败，跳过 ignore_tables 存在性检查: %v", pair.Name, err)
+			return
+		}
+		for name := range ckTables {
+			found[name] = true
+		}
+	}
+	for i, t := range cfg.IgnoreTables {
+		if !found[t] {
+			report.add(LevelWarn, "ignore_tables."+strconv.Itoa(i), "表 %q 在任何数据库对中都不存在", t)
+		}
+	}
+}
+
+// Render 按格式输出校验结果
+func Render(w io.Writer, report *Report, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case FormatText, "":
+		for _, i := range report.Issues {
+			fmt.Fprintf(w, "%-5s %s: %s\n", strings.ToUpper(i.Level), i.Path, i.Message)
+		}
+		result := "通过"
+		if !report.Valid {
+			result = "未通过"
+		}
+		_, err := fmt.Fprintf(w, "配置 %s 校验%s: 错误 %d，警告 %d\n", report.Source, result, report.Errors(), len(report.Issues)-report.Errors())
+		return err
+	default:
+		return fmt.Errorf("不支持的输出格式: %s，仅支持 %s、%s", format, FormatText, FormatJSON)
+	}
+}
+
+// WriteSchema 输出配置的 JSON Schema
+func WriteSchema(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(configload.Schema())
+}
@@ -0,0 +1,450 @@
+package doctorrun
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cksr/builder"
+	"cksr/internal/common"
+	"cksr/lock"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+	mdb "example.com/migrationLib/database"
+	p "example.com/migrationLib/parser"
+	"example.com/migrationLib/retry"
+)
+
+// 检查结果
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// 检查项名称
+const (
+	CheckConnection      = "connection"
+	CheckCatalog         = "catalog"
+	CheckCatalogQuery    = "catalog_query"
+	CheckLease           = "lease_permission"
+	CheckTimestampColumn = "timestamp_column"
+)
+
+// 输出格式
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// probeCatalogSuffix Catalog 不存在时用于验证可创建性的临时 Catalog 后缀，检查结束即删除
+const probeCatalogSuffix = "_cksr_doctor_probe"
+
+// Check 单项检查结果
+type Check struct {
+	Pair    string `json:"pair,omitempty"`
+	Name    string `json:"name"`
+	Target  string `json:"target,omitempty"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// Report 全部检查结果
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Passed      int       `json:"passed"`
+	Warned      int       `json:"warned"`
+	Failed      int       `json:"failed"`
+	Skipped     int       `json:"skipped"`
+	Checks      []Check   `json:"checks"`
+}
+
+// Options 检查选项
+type Options struct {
+	// ProbeCatalog 为 true 时（--probe），Catalog 不存在则创建临时 Catalog 验证可创建性并随后删除；默认只读
+	ProbeCatalog bool
+}
+
+func (r *Report) add(c Check) {
+	switch c.Status {
+	case StatusPass:
+		r.Passed++
+	case StatusWarn:
+		r.Warned++
+	case StatusFail:
+		r.Failed++
+	case StatusSkip:
+		r.Skipped++
+	}
+	r.Checks = append(r.Checks, c)
+}
+
+// HasFailure 是否存在失败的检查项
+func (r *Report) HasFailure() bool {
+	return r.Failed > 0
+}
+
+// Collect 依次执行全部检查
+func Collect(cfg *mcfg.Config, opts Options) *Report {
+	report := &Report{GeneratedAt: time.Now()}
+	report.add(checkLease(cfg))
+
+	// 记录在任一数据库对中找到的时间戳列配置表
+	matched := make(map[string]bool)
+	complete := true
+	for i, pair := range cfg.DatabasePairs {
+		logger.Info("开始检查数据库对 %s", pair.Name)
+		checks, ok := checkPair(cfg, i, opts, matched)
+		complete = complete && ok
+		for _, c := range checks {
+			c.Pair = pair.Name
+			report.add(c)
+		}
+	}
+
+	// 有数据库对未能完成检查时，无法断定表不存在
+	for _, table := range sortedTimestampTables(cfg) {
+		if !complete || matched[table] {
+			continue
+		}
+		report.add(Check{
+			Name:    CheckTimestampColumn,
+			Target:  table,
+			Status:  StatusWarn,
+			Message: "timestamp_columns 中配置的表在所有数据库对中都不存在",
+			Hint:    "确认表名拼写，或从 timestamp_columns 中移除该表",
+		})
+	}
+	return report
+}
+
+func checkLease(cfg *mcfg.Config) Check {
+	c := Check{Name: CheckLease, Target: fmt.Sprintf("%s/%s", cfg.Lock.K8sNamespace, cfg.Lock.LeaseName)}
+	if cfg.Lock.DebugMode {
+		c.Status = StatusSkip
+		c.Message = "lock.debug_mode 为 true，使用进程内锁"
+		return c
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	missing, err := lock.CheckLeasePermissions(ctx, cfg.Lock.K8sNamespace, cfg.Lock.LeaseName)
+	if err != nil {
+		c.Status = StatusFail
+		c.Message = err.Error()
+		c.Hint = "确认运行在 k8s Pod 内且挂载了 ServiceAccount；本地调试可设置 lock.debug_mode = true"
+		return c
+	}
+	if len(missing) > 0 {
+		c.Status = StatusFail
+		c.Message = fmt.Sprintf("缺少 lease 权限: %s", strings.Join(missing, ", "))
+		c.Hint = fmt.Sprintf("为 ServiceAccount 绑定 Role，授予 coordination.k8s.io/leases 的 %s 权限", strings.Join(lock.LeaseVerbs, "/"))
+		return c
+	}
+	c.Status = StatusPass
+	c.Message = "lease 权限完整"
+	return c
+}
+
+// checkPair 执行单个数据库对的检查；第二个返回值表示是否完成了CK表结构的读取
+func checkPair(cfg *mcfg.Config, pairIndex int, opts Options, matched map[string]bool) ([]Check, bool) {
+	pair := cfg.DatabasePairs[pairIndex]
+	var checks []Check
+
+	dbManager := mdb.NewDatabasePairManager(cfg, pairIndex)
+	conn := Check{Name: CheckConnection, Target: fmt.Sprintf("sr %s:%d, ck %s:%d", pair.StarRocks.Host, pair.StarRocks.Port, pair.ClickHouse.Host, pair.ClickHouse.Port)}
+	if err := dbManager.Init(); err != nil {
+		conn.Status = StatusFail
+		conn.Message = err.Error()
+		conn.Hint = "检查 host/port/username/password 以及网络连通性"
+		// 连接失败时后续检查均无法进行
+		return append(checks,
+			conn,
+			Check{Name: CheckCatalog, Target: pair.CatalogName, Status: StatusSkip, Message: "连接失败，跳过"},
+			Check{Name: CheckCatalogQuery, Target: pair.CatalogName, Status: StatusSkip, Message: "连接失败，跳过"},
+			Check{Name: CheckTimestampColumn, Status: StatusSkip, Message: "连接失败，跳过"},
+		), false
+	}
+	conn.Status = StatusPass
+	conn.Message = "StarRocks 与 ClickHouse 连接正常"
+	checks = append(checks, conn)
+
+	srDB, err := dbManager.GetStarRocksConnection()
+	if err != nil {
+		return append(checks, Check{Name: CheckCatalog, Target: pair.CatalogName, Status: StatusFail, Message: fmt.Sprintf("获取StarRocks连接失败: %v", err)}), false
+	}
+	retryConfig := retry.Config{
+		MaxRetries: cfg.Retry.MaxRetries,
+		Delay:      time.Duration(cfg.Retry.DelayMs) * time.Millisecond,
+	}
+
+	ckTablesMap, err := dbManager.ExportClickHouseTablesAsParserTables()
+	if err != nil {
+		return append(checks, Check{Name: CheckTimestampColumn, Status: StatusFail, Message: fmt.Sprintf("导出ClickHouse表结构失败: %v", err)}), false
+	}
+
+	// Catalog 是否存在；不存在时按选项创建临时 Catalog 验证可创建性
+	catalogCheck := Check{Name: CheckCatalog, Target: pair.CatalogName}
+	queryCatalog := ""
+	exists, err := common.CatalogExists(srDB, retryConfig, pair.CatalogName)
+	switch {
+	case err != nil:
+		catalogCheck.Status = StatusFail
+		catalogCheck.Message = err.Error()
+		catalogCheck.Hint = "确认 StarRocks 账号可以执行 SHOW CATALOGS"
+	case exists:
+		catalogCheck.Status = StatusPass
+		catalogCheck.Message = "Catalog 已存在"
+		queryCatalog = pair.CatalogName
+	case !opts.ProbeCatalog:
+		catalogCheck.Status = StatusWarn
+		catalogCheck.Message = "Catalog 不存在（init 时创建），未验证可创建性"
+		catalogCheck.Hint = "加 --probe 以创建临时 Catalog 验证权限与 driver_url"
+	default:
+		probe := pair.CatalogName + probeCatalogSuffix
+		// 先删除上次异常退出时可能遗留的临时 Catalog，避免创建因已存在而失败
+		dropProbeCatalog(dbManager, probe)
+		if err := common.CreateCatalog(cfg, pairIndex, dbManager, probe); err != nil {
+			catalogCheck.Status = StatusFail
+			catalogCheck.Message = fmt.Sprintf("Catalog 不存在，且创建临时 Catalog %s 失败: %v", probe, err)
+			catalogCheck.Hint = "确认 StarRocks 账号具有 CREATE EXTERNAL CATALOG 权限，driver_url 可被 FE 下载"
+		} else {
+			catalogCheck.Status = StatusPass
+			catalogCheck.Message = fmt.Sprintf("Catalog 不存在，已验证可创建（临时 Catalog %s）", probe)
+			queryCatalog = probe
+			defer dropProbeCatalog(dbManager, probe)
+		}
+	}
+	checks = append(checks, catalogCheck)
+	checks = append(checks, checkCatalogQuery(srDB, retryConfig, pair, queryCatalog, ckTablesMap))
+
+	checks = append(checks, checkTimestampColumns(cfg, dbManager, pair, ckTablesMap, matched)...)
+	return checks, true
+}
+
+func dropProbeCatalog(dbManager *mdb.DatabasePairManager, probe string) {
+	dropSQL := builder.NewRollbackBuilder("", "").BuildDropCatalogSQL(probe)
+	if err := dbManager.ExecuteStarRocksSQL(dropSQL); err != nil {
+		logger.Error("删除临时Catalog %s 失败，请手动执行: %s; 错误: %v", probe, dropSQL, err)
+	}
+}
+
+// checkCatalogQuery 通过 Catalog 读取 CK 表，验证 SR BE 到 CK 的 JDBC 链路
+func checkCatalogQuery(srDB *sql.DB, retryConfig retry.Config, pair mcfg.DatabasePair, catalog string, ckTablesMap map[string]p.Table) Check {
+	c := Check{Name: CheckCatalogQuery, Target: catalog}
+	if catalog == "" {
+		c.Status = StatusSkip
+		c.Message = "无可用 Catalog，跳过"
+		return c
+	}
+	var q string
+	if names := sortedTableNames(ckTablesMap); len(names) > 0 {
+		q = fmt.Sprintf("SELECT 1 FROM `%s`.`%s`.`%s` LIMIT 1", catalog, pair.ClickHouse.Database, names[0])
+	} else {
+		q = fmt.Sprintf("SHOW TABLES FROM `%s`.`%s`", catalog, pair.ClickHouse.Database)
+	}
+	c.Target = q
+	rows, err := retry.QueryWithRetry(srDB, retryConfig, q)
+	if err != nil {
+		c.Status = StatusFail
+		c.Message = err.Error()
+		c.Hint = "确认 driver_url 可被所有 StarRocks BE/CN 节点访问，且 ClickHouse 账号可读该库"
+		return c
+	}
+	defer rows.Close()
+	// 消费结果集，确保查询真正经由 JDBC 执行
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		c.Status = StatusFail
+		c.Message = err.Error()
+		c.Hint = "确认 driver_url 可被所有 StarRocks BE/CN 节点访问，且 ClickHouse 账号可读该库"
+		return c
+	}
+	c.Status = StatusPass
+	c.Message = "通过 Catalog 查询成功"
+	return c
+}
+
+// checkTimestampColumns 校验 timestamp_columns 中的列在 SR 后缀表与 CK 表中存在且类型与声明一致
+func checkTimestampColumns(cfg *mcfg.Config, dbManager *mdb.DatabasePairManager, pair mcfg.DatabasePair, ckTablesMap map[string]p.Table, matched map[string]bool) []Check {
+	srTypes, err := dbManager.GetStarRocksTablesTypes()
+	if err != nil {
+		return []Check{{Name: CheckTimestampColumn, Status: StatusFail, Message: fmt.Sprintf("获取StarRocks表类型失败: %v", err)}}
+	}
+
+	var checks []Check
+	for _, table := range sortedTimestampTables(cfg) {
+		ckTable, inCK := ckTablesMap[table]
+		if !inCK {
+			continue
+		}
+		matched[table] = true
+		tc := cfg.TimestampColumns[table]
+		c := Check{Name: CheckTimestampColumn, Target: fmt.Sprintf("%s.%s (%s)", table, tc.Column, tc.Type)}
+
+		declared := strings.ToLower(strings.TrimSpace(tc.Type))
+		if declared != "date" && declared != "datetime" && declared != "bigint" {
+			c.Status = StatusFail
+			c.Message = fmt.Sprintf("不支持的时间戳列类型: %s", tc.Type)
+			c.Hint = "timestamp_columns.type 仅支持 date、datetime、bigint"
+			checks = append(checks, c)
+			continue
+		}
+
+		var problems []string
+		if ckType, ok := fieldType(ckTable.Field, tc.Column); !ok {
+			problems = append(problems, fmt.Sprintf("CK 表 %s 中不存在列 %s", table, tc.Column))
+		} else if !typeMatches(declared, ckType, ckTypeAliases) {
+			problems = append(problems, fmt.Sprintf("CK 列类型 %s 与声明的 %s 不一致", ckType, tc.Type))
+		}
+
+		// 优先检查后缀表；尚未初始化时检查基础名原生表
+		srTable := table + pair.SRTableSuffix
+		if _, ok := srTypes[srTable]; !ok {
+			if strings.ToUpper(srTypes[table]) == mdb.StarRocksTableTypeBaseTable {
+				srTable = table
+			} else {
+				srTable = ""
+			}
+		}
+		if srTable == "" {
+			problems = append(problems, fmt.Sprintf("SR 中不存在表 %s%s 或 %s", table, pair.SRTableSuffix, table))
+		} else if srFields, err := srTableFields(cfg, dbManager, pair, srTable); err != nil {
+			problems = append(problems, err.Error())
+		} else if srType, ok := fieldType(srFields, tc.Column); !ok {
+			problems = append(problems, fmt.Sprintf("SR 表 %s 中不存在列 %s", srTable, tc.Column))
+		} else if !typeMatches(declared, srType, srTypeAliases) {
+			problems = append(problems, fmt.Sprintf("SR 列类型 %s 与声明的 %s 不一致", srType, tc.Type))
+		}
+
+		if len(problems) > 0 {
+			c.Status = StatusFail
+			c.Message = strings.Join(problems, "; ")
+			c.Hint = "修正 timestamp_columns 中的列名/类型，使其与 SR 后缀表和 CK 表一致"
+		} else {
+			c.Status = StatusPass
+			c.Message = "SR 与 CK 中列存在且类型一致"
+		}
+		checks = append(checks, c)
+	}
+	return checks
+}
+
+func srTableFields(cfg *mcfg.Config, dbManager *mdb.DatabasePairManager, pair mcfg.DatabasePair, table string) ([]p.Field, error) {
+	ddl, err := dbManager.GetStarRocksTableDDL(table)
+	if err != nil {
+		return nil, fmt.Errorf("获取StarRocks表%s的DDL失败: %w", table, err)
+	}
+	t, err := common.ParseTableFromString(ddl, pair.StarRocks.Database, table, time.Duration(cfg.Parser.DDLParseTimeoutSeconds)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("解析StarRocks表%s失败: %w", table, err)
+	}
+	return t.Field, nil
+}
+
+func fieldType(fields []p.Field, name string) (string, bool) {
+	for _, f := range fields {
+		if f.Name == name {
+			return f.Type, true
+		}
+	}
+	return "", false
+}
+
+// 声明类型与两侧实际类型的对应关系（实际类型已去除 Nullable 包装与精度参数并转小写）
+var (
+	srTypeAliases = map[string][]string{
+		"date":     {"date"},
+		"datetime": {"datetime"},
+		"bigint":   {"bigint"},
+	}
+	ckTypeAliases = map[string][]string{
+		"date":     {"date", "date32"},
+		"datetime": {"datetime", "datetime64"},
+		"bigint":   {"int64", "uint64"},
+	}
+)
+
+func typeMatches(declared, actual string, aliases map[string][]string) bool {
+	t := strings.ToLower(strings.TrimSpace(actual))
+	if strings.HasPrefix(t, "nullable(") && strings.HasSuffix(t, ")") {
+		t = strings.TrimSuffix(strings.TrimPrefix(t, "nullable("), ")")
+	}
+	if i := strings.Index(t, "("); i >= 0 {
+		t = t[:i]
+	}
+	for _, a := range aliases[declared] {
+		if t == a {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedTimestampTables(cfg *mcfg.Config) []string {
+	tables := make([]string, 0, len(cfg.TimestampColumns))
+	for t := range cfg.TimestampColumns {
+		tables = append(tables, t)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+func sortedTableNames(m map[string]p.Table) []string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render 按格式输出检查结果
+func Render(w io.Writer, report *Report, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case FormatTable, "":
+		return renderTable(w, report)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s，仅支持 %s、%s", format, FormatTable, FormatJSON)
+	}
+}
+
+func renderTable(w io.Writer, report *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tPAIR\tCHECK\tTARGET\tMESSAGE")
+	for _, c := range report.Checks {
+		pair := c.Pair
+		if pair == "" {
+			pair = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", strings.ToUpper(c.Status), pair, c.Name, c.Target, c.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var hints []string
+	for _, c := range report.Checks {
+		if c.Hint != "" && (c.Status == StatusFail || c.Status == StatusWarn) {
+			hints = append(hints, fmt.Sprintf("  [%s] %s %s: %s", strings.ToUpper(c.Status), c.Name, c.Target, c.Hint))
+		}
+	}
+	if len(hints) > 0 {
+		fmt.Fprintf(w, "\n修复建议:\n%s\n", strings.Join(hints, "\n"))
+	}
+	fmt.Fprintf(w, "\n通过 %d，警告 %d，失败 %d，跳过 %d\n", report.Passed, report.Warned, report.Failed, report.Skipped)
+	return nil
+}
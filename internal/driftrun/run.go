@@ -0,0 +1,227 @@
+package driftrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"cksr/builder"
+	"cksr/internal/common"
+	"cksr/internal/rollbackrun"
+	"cksr/internal/statusrun"
+	"cksr/internal/updaterun"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+	"example.com/migrationLib/retry"
+)
+
+// 单视图比较结果
+const (
+	ResultOK    = "ok"
+	ResultDrift = "drift"
+	ResultError = "error"
+)
+
+// 输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// ViewResult 单个视图的漂移检测结果
+type ViewResult struct {
+	Pair     string                `json:"pair"`
+	View     string                `json:"view"`
+	Result   string                `json:"result"`
+	Boundary *builder.ViewBoundary `json:"boundary,omitempty"`
+	Drift    *builder.ViewDrift    `json:"drift,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+// Report 漂移检测报告
+type Report struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Checked     int          `json:"checked"`
+	Drifted     int          `json:"drifted"`
+	Errors      int          `json:"errors"`
+	Views       []ViewResult `json:"views"`
+}
+
+// HasDrift 是否存在漂移或无法比较的视图
+func (r *Report) HasDrift() bool {
+	return r.Drifted > 0 || r.Errors > 0
+}
+
+// Collect 对所有已初始化的视图执行漂移检测（只读）
+func Collect(cfg *mcfg.Config) (*Report, error) {
+	report := &Report{GeneratedAt: time.Now()}
+	for i, pair := range cfg.DatabasePairs {
+		logger.Info("开始检测数据库对 %s 的视图漂移", pair.Name)
+		results, err := collectPair(cfg, i)
+		if err != nil {
+			return nil, fmt.Errorf("检测数据库对 %s 的视图漂移失败: %w", pair.Name, err)
+		}
+		for _, r := range results {
+			report.Checked++
+			switch r.Result {
+			case ResultDrift:
+				report.Drifted++
+			case ResultError:
+				report.Errors++
+			}
+			report.Views = append(report.Views, r)
+		}
+	}
+	return report, nil
+}
+
+func collectPair(cfg *mcfg.Config, pairIndex int) ([]ViewResult, error) {
+	pair := cfg.DatabasePairs[pairIndex]
+	facts, dbManager, err := rollbackrun.DiscoverPair(cfg, pairIndex)
+	if err != nil {
+		return nil, err
+	}
+	srDB, err := dbManager.GetStarRocksConnection()
+	if err != nil {
+		return nil, fmt.Errorf("获取StarRocks连接失败: %w", err)
+	}
+	retryConfig := retry.Config{
+		MaxRetries: cfg.Retry.MaxRetries,
+		Delay:      time.Duration(cfg.Retry.DelayMs) * time.Millisecond,
+	}
+	viewDefs, err := common.ViewDefinitions(srDB, retryConfig, pair.StarRocks.Database)
+	if err != nil {
+		return nil, err
+	}
+	ckTablesMap, err := dbManager.ExportClickHouseTablesAsParserTables()
+	if err != nil {
+		return nil, fmt.Errorf("导出ClickHouse表结构失败: %w", err)
+	}
+
+	var results []ViewResult
+	for _, f := range facts {
+		// 仅检查完整初始化的视图（后缀表与基础名视图均存在）
+		if statusrun.Classify(f) != statusrun.StateInitialized {
+			continue
+		}
+		r := ViewResult{Pair: pair.Name, View: f.BaseTable}
+		live := viewDefs[f.BaseTable]
+		boundary, ok := builder.ParseViewBoundary(live)
+		if !ok {
+			r.Result = ResultError
+			r.Error = "线上视图定义中未解析到时间边界"
+			results = append(results, r)
+			continue
+		}
+		r.Boundary = &boundary
+
+		// 以线上边界重新生成期望SQL，使差异只反映结构变化；数据层个数与线上不一致时按最后一个边界对齐，差异体现为分支数变化
+		values := alignBoundaries(builder.ParseViewBoundaries(live), len(common.ViewTiers(cfg, f.BaseTable))+1)
+		expectedSQL, err := updaterun.BuildAlterViewSQL(cfg, dbManager, pair, ckTablesMap, f.BaseTable, values)
+		if err != nil {
+			r.Result = ResultError
+			r.Error = err.Error()
+			results = append(results, r)
+			continue
+		}
+		expected, err := builder.ParseViewStructure(expectedSQL)
+		if err != nil {
+			r.Result = ResultError
+			r.Error = fmt.Sprintf("解析期望视图失败: %v", err)
+			results = append(results, r)
+			continue
+		}
+		actual, err := builder.ParseViewStructure(live)
+		if err != nil {
+			r.Result = ResultError
+			r.Error = fmt.Sprintf("解析线上视图失败: %v", err)
+			results = append(results, r)
+			continue
+		}
+
+		drift := builder.CompareViews(expected, actual)
+		if drift.HasDrift() {
+			r.Result = ResultDrift
+			r.Drift = &drift
+			logger.Warn("视图 %s.%s 存在漂移", pair.StarRocks.Database, f.BaseTable)
+		} else {
+			r.Result = ResultOK
+			logger.Debug("视图 %s.%s 无漂移", pair.StarRocks.Database, f.BaseTable)
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// Render 按格式输出漂移报告
+func Render(w io.Writer, report *Report, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case FormatText, "":
+		return renderText(w, report)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s，仅支持 %s、%s", format, FormatText, FormatJSON)
+	}
+}
+
+func renderText(w io.Writer, report *Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "漂移检测: 视图 %d，漂移 %d，无法比较 %d\n", report.Checked, report.Drifted, report.Errors)
+	for _, v := range report.Views {
+		if v.Result == ResultOK {
+			continue
+		}
+		fmt.Fprintf(&b, "\n[%s] %s.%s\n", v.Result, v.Pair, v.View)
+		if v.Error != "" {
+			fmt.Fprintf(&b, "  错误: %s\n", v.Error)
+		}
+		if v.Drift == nil {
+			continue
+		}
+		d := v.Drift
+		if d.BranchCount != nil {
+			fmt.Fprintf(&b, "  分支数量: 期望 %s，实际 %s\n", d.BranchCount.Expected, d.BranchCount.Actual)
+		}
+		if len(d.MissingColumns) > 0 {
+			fmt.Fprintf(&b, "  缺失列: %s\n", strings.Join(d.MissingColumns, ", "))
+		}
+		if len(d.ExtraColumns) > 0 {
+			fmt.Fprintf(&b, "  多余列: %s\n", strings.Join(d.ExtraColumns, ", "))
+		}
+		if d.CatalogChange != nil {
+			fmt.Fprintf(&b, "  Catalog: 期望 %s，实际 %s\n", d.CatalogChange.Expected, d.CatalogChange.Actual)
+		}
+		for _, c := range d.ReferenceChanges {
+			fmt.Fprintf(&b, "  分支 %d 引用: 期望 %s，实际 %s\n", c.Branch, c.Expected, c.Actual)
+		}
+		for _, c := range d.PredicateChanges {
+			fmt.Fprintf(&b, "  分支 %d 条件: 期望 %s，实际 %s\n", c.Branch, c.Expected, c.Actual)
+		}
+		for _, c := range d.ChangedExpressions {
+			fmt.Fprintf(&b, "  分支 %d 列 %s: 期望 %s，实际 %s\n", c.Branch, c.Column, c.Expected, c.Actual)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// alignBoundaries 将线上边界（从早到晚）对齐为 want 个：多余的舍去较早的，不足的在前面补第一个边界
+func alignBoundaries(bounds []builder.ViewBoundary, want int) []string {
+	if len(bounds) > want {
+		bounds = bounds[len(bounds)-want:]
+	}
+	values := make([]string, 0, want)
+	for len(values)+len(bounds) < want {
+		values = append(values, bounds[0].Value)
+	}
+	for _, b := range bounds {
+		values = append(values, b.Value)
+	}
+	return values
+}
@@ -0,0 +1,171 @@
+package explainrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	vbuilder "cksr/builder"
+	"cksr/internal/common"
+	"cksr/logger"
+
+	mlcommon "example.com/migrationLib/common"
+	mcfg "example.com/migrationLib/config"
+	ckc "example.com/migrationLib/convert"
+	mdb "example.com/migrationLib/database"
+)
+
+// 输出格式
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Explanation 单表映射说明
+type Explanation struct {
+	Pair    string `json:"pair"`
+	Table   string `json:"table"`
+	SRTable string `json:"sr_table"`
+	*vbuilder.MappingExplanation
+}
+
+// ResolvePair 按名称查找数据库对；名称为空且仅配置了一个数据库对时返回该对
+func ResolvePair(cfg *mcfg.Config, name string) (int, error) {
+	if name == "" {
+		if len(cfg.DatabasePairs) == 1 {
+			return 0, nil
+		}
+		return -1, fmt.Errorf("配置了 %d 个数据库对，需通过 --pair 指定", len(cfg.DatabasePairs))
+	}
+	for i, pair := range cfg.DatabasePairs {
+		if pair.Name == name {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("未找到数据库对: %s", name)
+}
+
+// Run 统一入口：生成映射说明并按格式输出
+func Run(cfg *mcfg.Config, pairIndex int, table string, format string, w io.Writer) error {
+	exp, err := Explain(cfg, pairIndex, table)
+	if err != nil {
+		return err
+	}
+	return Render(w, exp, format)
+}
+
+// Explain 对指定表执行与建视图相同的字段映射（只读，不做任何变更）
+func Explain(cfg *mcfg.Config, pairIndex int, table string) (*Explanation, error) {
+	pair := cfg.DatabasePairs[pairIndex]
+	dbManager := mdb.NewDatabasePairManager(cfg, pairIndex)
+	if err := dbManager.Init(); err != nil {
+		return nil, fmt.Errorf("初始化数据库连接失败: %w", err)
+	}
+
+	ckTablesMap, err := dbManager.ExportClickHouseTablesAsParserTables()
+	if err != nil {
+		return nil, fmt.Errorf("导出ClickHouse表结构失败: %w", err)
+	}
+	ckTable, ok := ckTablesMap[table]
+	if !ok {
+		return nil, fmt.Errorf("ClickHouse 中不存在表 %s", table)
+	}
+
+	// 已初始化时读取后缀表；尚未重命名时读取基础名原生表，视图中的表名仍按后缀名生成
+	suffixed := table + pair.SRTableSuffix
+	srTypes, err := dbManager.GetStarRocksTablesTypes()
+	if err != nil {
+		return nil, fmt.Errorf("获取StarRocks表类型失败: %w", err)
+	}
+	source := suffixed
+	if _, exists := srTypes[suffixed]; !exists {
+		if strings.ToUpper(srTypes[table]) != mdb.StarRocksTableTypeBaseTable {
+			return nil, fmt.Errorf("StarRocks 中既无后缀表 %s 也无原生表 %s", suffixed, table)
+		}
+		source = table
+	}
+	logger.Debug("表 %s 的映射说明使用StarRocks表 %s 的DDL", table, source)
+
+	srDDL, err := dbManager.GetStarRocksTableDDL(source)
+	if err != nil {
+		return nil, fmt.Errorf("获取StarRocks表%s的DDL失败: %w", source, err)
+	}
+	srTable, err := common.ParseTableFromString(srDDL, pair.StarRocks.Database, suffixed, time.Duration(cfg.Parser.DDLParseTimeoutSeconds)*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("解析StarRocks表%s失败: %w", source, err)
+	}
+	fieldConverters, err := ckc.NewConverters(ckTable, mlcommon.ScenarioView)
+	if err != nil {
+		return nil, fmt.Errorf("创建字段转换器失败: %w", err)
+	}
+
+	viewBuilder := vbuilder.NewBuilder(
+		fieldConverters,
+		srTable.Field,
+		ckTable.DDL.DBName, ckTable.DDL.TableName, pair.CatalogName,
+		srTable.DDL.DBName, srTable.DDL.TableName,
+		dbManager,
+		cfg,
+	)
+	return &Explanation{
+		Pair:               pair.Name,
+		Table:              table,
+		SRTable:            source,
+		MappingExplanation: viewBuilder.ExplainMapping(),
+	}, nil
+}
+
+// Render 按格式输出映射说明
+func Render(w io.Writer, exp *Explanation, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exp)
+	case FormatTable, "":
+		return renderTable(w, exp)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s，仅支持 %s、%s", format, FormatTable, FormatJSON)
+	}
+}
+
+func renderTable(w io.Writer, exp *Explanation) error {
+	fmt.Fprintf(w, "== 数据库对: %s，表: %s (SR DDL 来源: %s)，视图列: %d\n", exp.Pair, exp.Table, exp.SRTable, len(exp.Columns))
+	if exp.ValidationError != "" {
+		fmt.Fprintf(w, "映射校验失败: %s\n", exp.ValidationError)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "#\tCK_COLUMN\tCK_TYPE\tSR_COLUMN\tSR_TYPE\tRULE\tCK_CLAUSE\tSR_CLAUSE")
+	for i, c := range exp.Columns {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", i+1,
+			dash(c.CKColumn), dash(c.CKType), c.SRColumn, c.SRType, c.Rule, oneLine(c.CKClause), oneLine(c.SRClause))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n跳过的CK列: %d\n", len(exp.SkippedCK))
+	for _, s := range exp.SkippedCK {
+		fmt.Fprintf(w, "  %s (%s) -> %s: %s\n", s.CKColumn, s.CKType, s.SRColumn, s.Reason)
+	}
+	fmt.Fprintf(w, "\n以默认值补齐的SR独有列: %d\n", len(exp.DefaultedSR))
+	for _, d := range exp.DefaultedSR {
+		fmt.Fprintf(w, "  %s (%s) 默认值: %s\n", d.SRColumn, d.SRType, d.Default)
+	}
+	return nil
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// oneLine 将多行子句压缩为一行，便于表格展示
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
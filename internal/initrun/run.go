@@ -2,6 +2,7 @@ package initrun
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -9,21 +10,38 @@ import (
 	"cksr/internal/common"
 	"cksr/logger"
 
-	mlcommon "example.com/migrationLib/common"
 	mcfg "example.com/migrationLib/config"
-	ckc "example.com/migrationLib/convert"
 	mdb "example.com/migrationLib/database"
-	ckf "example.com/migrationLib/factory"
 	p2 "example.com/migrationLib/parser"
 )
 
 // TableInitPlan 单表初始化计划
 type TableInitPlan struct {
-	BaseTable     string // 基础表名（CK/SR共同的表名）
-	SuffixedTable string // 若SR已重命名，则为加后缀的表名
-	NeedRename    bool   // SR是否需要从基础名重命名为后缀名
-	RenameReason  string // 决策原因：为何需要/不需要重命名
-	ViewReason    string // 决策原因：为何需要/如何创建视图
+	BaseTable     string `json:"base_table"`     // 基础表名（CK/SR共同的表名）
+	SuffixedTable string `json:"suffixed_table"` // 若SR已重命名，则为加后缀的表名
+	NeedRename    bool   `json:"need_rename"`    // SR是否需要从基础名重命名为后缀名
+	RenameReason  string `json:"rename_reason"`  // 决策原因：为何需要/不需要重命名
+	ViewReason    string `json:"view_reason"`    // 决策原因：为何需要/如何创建视图
+}
+
+// TableInitSQL 单表初始化将要执行的SQL（按执行顺序：CK增列 -> SR重命名 -> 创建视图）
+type TableInitSQL struct {
+	CKAlterSQLs   []string `json:"ck_alter_sqls,omitempty"` // CK 新增别名列（逐条执行），为空表示无需增列或无需重命名
+	SRRenameSQL   string   `json:"sr_rename_sql,omitempty"` // SR 基础名重命名为后缀名，为空表示已重命名
+	CreateViewSQL string   `json:"create_view_sql"`         // 基础名视图
+}
+
+// TableInitPlanWithSQL 单表计划及其SQL，供 plan 命令输出
+type TableInitPlanWithSQL struct {
+	TableInitPlan
+	SQL TableInitSQL `json:"sql"`
+}
+
+// PairInitPlan 单个数据库对的初始化计划
+type PairInitPlan struct {
+	Pair        string                 `json:"pair"`
+	CatalogName string                 `json:"catalog_name"`
+	Tables      []TableInitPlanWithSQL `json:"tables"`
 }
 
 // InitManager 初始化管理器，负责单个数据库对的完整流程
@@ -31,7 +49,10 @@ type InitManager struct {
 	dbManager   *mdb.DatabasePairManager
 	cfg         *mcfg.Config
 	pair        mcfg.DatabasePair
+	pairIndex   int
 	catalogName string
+	scope       common.Scope      // 命令行指定的处理范围，零值表示全部
+	filtered    []common.Filtered // 本次被过滤的表及原因
 }
 
 // NewInitManager 创建初始化管理器
@@ -40,23 +61,120 @@ func NewInitManager(cfg *mcfg.Config, pairIndex int) *InitManager {
 		dbManager:   mdb.NewDatabasePairManager(cfg, pairIndex),
 		cfg:         cfg,
 		pair:        cfg.DatabasePairs[pairIndex],
+		pairIndex:   pairIndex,
 		catalogName: cfg.DatabasePairs[pairIndex].CatalogName,
 	}
 }
 
-// Run 处理多个数据库对（创建/同步视图）
-func Run(cfg *mcfg.Config) error {
+// NewInitManagerWithConn 复用已初始化的连接管理器创建初始化管理器（auto-update 自愈阶段使用），不再重复初始化连接池
+func NewInitManagerWithConn(cfg *mcfg.Config, pairIndex int, dbManager *mdb.DatabasePairManager, scope common.Scope) *InitManager {
+	im := NewInitManager(cfg, pairIndex)
+	im.dbManager = dbManager
+	im.scope = scope
+	return im
+}
+
+// Run 处理范围内的数据库对（创建/同步视图），结束时汇总被过滤的表
+func Run(cfg *mcfg.Config, scope common.Scope) error {
+	var filtered []common.Filtered
 	for i, pair := range cfg.DatabasePairs {
+		if !scope.MatchPair(pair.Name) {
+			logger.Info("跳过数据库对 %s (未匹配 --pair)", pair.Name)
+			continue
+		}
 		logger.Info("开始处理数据库对 %s (索引: %d)", pair.Name, i)
-		if err := NewInitManager(cfg, i).ExecuteInit(); err != nil {
+		im := NewInitManager(cfg, i)
+		im.scope = scope
+		err := im.ExecuteInit()
+		filtered = append(filtered, im.filtered...)
+		if err != nil {
 			return fmt.Errorf("处理数据库对 %s 失败: %w", pair.Name, err)
 		}
 		logger.Info("数据库对 %s 处理完成", pair.Name)
 	}
+	common.LogFiltered(filtered)
 	logger.Info("所有数据库对处理完成 (init)")
 	return nil
 }
 
+// Plan 仅执行发现阶段，生成所有数据库对的初始化计划及SQL，不做任何变更
+func Plan(cfg *mcfg.Config) ([]PairInitPlan, error) {
+	var result []PairInitPlan
+	for i, pair := range cfg.DatabasePairs {
+		logger.Info("开始生成数据库对 %s 的初始化计划 (索引: %d)", pair.Name, i)
+		pp, err := NewInitManager(cfg, i).BuildPlan()
+		if err != nil {
+			return nil, fmt.Errorf("生成数据库对 %s 的初始化计划失败: %w", pair.Name, err)
+		}
+		result = append(result, pp)
+	}
+	return result, nil
+}
+
+// BuildPlan 生成单个数据库对的初始化计划及每张表将要执行的SQL（只读）
+func (im *InitManager) BuildPlan() (PairInitPlan, error) {
+	pp := PairInitPlan{Pair: im.pair.Name, CatalogName: im.catalogName}
+	if err := im.dbManager.Init(); err != nil {
+		return pp, fmt.Errorf("初始化数据库连接失败: %w", err)
+	}
+	tables, err := im.PlanTables()
+	if err != nil {
+		return pp, err
+	}
+	pp.Tables = tables
+	return pp, nil
+}
+
+// PlanTables 在已初始化的连接上生成各表的初始化计划及SQL（只读）
+func (im *InitManager) PlanTables() ([]TableInitPlanWithSQL, error) {
+	ckTablesMap, err := im.dbManager.ExportClickHouseTablesAsParserTables()
+	if err != nil {
+		return nil, fmt.Errorf("导出ClickHouse表结构失败: %w", err)
+	}
+	srTableNames, err := im.dbManager.GetStarRocksTableNames()
+	if err != nil {
+		return nil, fmt.Errorf("获取StarRocks表名列表失败: %w", err)
+	}
+	plans, err := im.findInitPlans(ckTablesMap, srTableNames)
+	if err != nil {
+		return nil, err
+	}
+	var tables []TableInitPlanWithSQL
+	for _, plan := range plans {
+		stmts, err := im.buildTableSQL(plan, ckTablesMap)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, TableInitPlanWithSQL{TableInitPlan: plan, SQL: stmts})
+	}
+	return tables, nil
+}
+
+// ApplyTable 执行单表计划中记录的 CK ALTER、SR 重命名与创建视图
+func (im *InitManager) ApplyTable(t TableInitPlanWithSQL) error {
+	return im.executeTableSQL(t.TableInitPlan, t.SQL)
+}
+
+// ApplyPlan 按已保存的计划执行单个数据库对的初始化，只执行计划中记录的SQL
+func (im *InitManager) ApplyPlan(pp PairInitPlan) error {
+	if err := im.dbManager.Init(); err != nil {
+		return fmt.Errorf("初始化数据库连接失败: %w", err)
+	}
+	logger.Info("正在创建StarRocks Catalog...")
+	if err := common.CreateCatalog(im.cfg, im.pairIndex, im.dbManager, im.catalogName); err != nil {
+		return fmt.Errorf("创建StarRocks Catalog失败: %w", err)
+	}
+	for idx, t := range pp.Tables {
+		logger.Info("[%d/%d] 按计划处理表: %s", idx+1, len(pp.Tables), t.BaseTable)
+		if err := im.executeTableSQL(t.TableInitPlan, t.SQL); err != nil {
+			return err
+		}
+		logger.Info("表 %s 处理完成", t.BaseTable)
+	}
+	logger.Info("数据库对 %s 按计划处理完成", im.pair.Name)
+	return nil
+}
+
 // ExecuteInit 执行单个数据库对的完整初始化流程
 func (im *InitManager) ExecuteInit() error {
 	// 主动初始化数据库连接（池）
@@ -72,7 +190,7 @@ func (im *InitManager) ExecuteInit() error {
 
 	// 2) 确保 SR Catalog 存在
 	logger.Info("正在创建StarRocks Catalog...")
-	if err := im.dbManager.CreateStarRocksCatalog(im.catalogName); err != nil {
+	if err := common.CreateCatalog(im.cfg, im.pairIndex, im.dbManager, im.catalogName); err != nil {
 		return fmt.Errorf("创建StarRocks Catalog失败: %w", err)
 	}
 
@@ -128,13 +246,21 @@ func (im *InitManager) findInitPlans(ckTablesMap map[string]p2.Table, srTableNam
 	for t := range ckTablesMap {
 		ckTables = append(ckTables, t)
 	}
+	// 排序保证计划顺序稳定，便于审计与比对
+	sort.Strings(ckTables)
 	logger.Debug("ClickHouse表名列表: %v", ckTables)
 	logger.Debug("StarRocks表名列表: %v", srTableNames)
 
 	var plans []TableInitPlan
 	for _, ckTable := range ckTables {
 		if ignore[ckTable] {
-			logger.Info("忽略表: %s (在配置的忽略列表中)", ckTable)
+			logger.Info("忽略表: %s (%s)", ckTable, common.ReasonIgnored)
+			im.filtered = append(im.filtered, common.Filtered{Pair: im.pair.Name, Table: ckTable, Reason: common.ReasonIgnored})
+			continue
+		}
+		if ok, reason := im.scope.MatchTable(ckTable); !ok {
+			logger.Debug("过滤表: %s (%s)", ckTable, reason)
+			im.filtered = append(im.filtered, common.Filtered{Pair: im.pair.Name, Table: ckTable, Reason: reason})
 			continue
 		}
 
@@ -190,71 +316,97 @@ func (im *InitManager) processTable(plan TableInitPlan, ckTablesMap map[string]p
 		logger.Info("计划：仅创建视图 - 表: %s，原因: %s", plan.BaseTable, plan.ViewReason)
 	}
 
+	stmts, err := im.buildTableSQL(plan, ckTablesMap)
+	if err != nil {
+		return err
+	}
+	return im.executeTableSQL(plan, stmts)
+}
+
+// executeTableSQL 按顺序执行单表的 CK ALTER、SR 重命名与创建视图
+func (im *InitManager) executeTableSQL(plan TableInitPlan, stmts TableInitSQL) error {
+	// 执行 CK ALTER 以新增别名列（必要时）
+	if len(stmts.CKAlterSQLs) > 0 {
+		ckDB, errConn := im.dbManager.GetClickHouseConnection()
+		if errConn != nil {
+			return fmt.Errorf("获取ClickHouse连接失败: %w", errConn)
+		}
+		if err := im.dbManager.ExecuteBatchSQLWithDB(ckDB, stmts.CKAlterSQLs, true); err != nil {
+			return fmt.Errorf("执行ClickHouse ALTER TABLE失败(表 %s): %w", plan.BaseTable, err)
+		}
+	}
+
+	srDBConn, errConn := im.dbManager.GetStarRocksConnection()
+	if errConn != nil {
+		return fmt.Errorf("获取StarRocks连接失败: %w", errConn)
+	}
+	if strings.TrimSpace(stmts.SRRenameSQL) != "" {
+		if err := im.dbManager.ExecuteBatchSQLWithDB(srDBConn, []string{stmts.SRRenameSQL}, false); err != nil {
+			return fmt.Errorf("执行StarRocks重命名失败(%s -> %s): %w", plan.BaseTable, plan.SuffixedTable, err)
+		}
+	}
+
+	// 执行视图 SQL
+	if strings.TrimSpace(stmts.CreateViewSQL) != "" {
+		if err := im.dbManager.ExecuteBatchSQLWithDB(srDBConn, []string{stmts.CreateViewSQL}, false); err != nil {
+			return fmt.Errorf("执行CREATE VIEW失败(%s.%s): %w", im.pair.StarRocks.Database, plan.BaseTable, err)
+		}
+	}
+	return nil
+}
+
+// buildTableSQL 生成单表的 CK ALTER、SR 重命名与创建视图 SQL（只读，不执行）
+// 重命名前 SR 表仍为基础名，此时从基础名读取 DDL 与时间边界，视图仍引用后缀表名
+func (im *InitManager) buildTableSQL(plan TableInitPlan, ckTablesMap map[string]p2.Table) (TableInitSQL, error) {
+	var stmts TableInitSQL
+
 	// 获取 CK 表结构（已直接构造，无需解析DDL）
 	ckTable, ok := ckTablesMap[plan.BaseTable]
 	if !ok {
-		return fmt.Errorf("未获取到ClickHouse表结构: %s", plan.BaseTable)
+		return stmts, fmt.Errorf("未获取到ClickHouse表结构: %s", plan.BaseTable)
 	}
 
-	// 生成并执行 CK ALTER 以新增别名列（必要时）
-	fieldConverters, err := ckc.NewConverters(ckTable, mlcommon.ScenarioView)
+	coldSource, err := builder.NewClickHouseSource(ckTablesMap, im.catalogName, plan.BaseTable)
 	if err != nil {
-		return fmt.Errorf("创建字段转换器失败(表 %s): %w", plan.BaseTable, err)
+		return stmts, err
 	}
 
-	// 确定 SR 实际表名：
-	// - 若需要重命名：基础名 -> 后缀名
+	// 确定 SR 当前实际表名：
+	// - 若需要重命名：当前仍为基础名，执行后为后缀名
 	// - 若无需重命名（仅创建视图，SR侧已存在后缀表）：使用后缀表名
+	currentSRTable := plan.SuffixedTable
 	if plan.NeedRename {
-		alterBuilder := ckf.NewAddColumnsBuilder(mlcommon.ScenarioView, fieldConverters, ckTable.DDL.DBName, ckTable.DDL.TableName)
-		alterSQL := alterBuilder.Build()
-		if strings.TrimSpace(alterSQL) != "" {
-			ckDB, errConn := im.dbManager.GetClickHouseConnection()
-			if errConn != nil {
-				return fmt.Errorf("获取ClickHouse连接失败: %w", errConn)
-			}
-			if err = im.dbManager.ExecuteBatchSQLWithDB(ckDB, []string{alterSQL}, true); err != nil {
-				return fmt.Errorf("执行ClickHouse ALTER TABLE失败(表 %s): %w", plan.BaseTable, err)
-			}
-		}
-
-		renameSQL := fmt.Sprintf("ALTER TABLE `%s`.`%s` RENAME `%s`", im.pair.StarRocks.Database, plan.BaseTable, plan.SuffixedTable)
-		if err = im.dbManager.ExecuteStarRocksSQL(renameSQL); err != nil {
-			return fmt.Errorf("执行StarRocks重命名失败(%s -> %s): %w", plan.BaseTable, plan.SuffixedTable, err)
-		}
+		stmts.CKAlterSQLs = coldSource.PrepareDDL()
+		stmts.SRRenameSQL = fmt.Sprintf("ALTER TABLE `%s`.`%s` RENAME `%s`", im.pair.StarRocks.Database, plan.BaseTable, plan.SuffixedTable)
+		currentSRTable = plan.BaseTable
 	}
 
-	// 获取并解析 SR DDL（可能是重命名后的表名）
-	srDDL, err := im.dbManager.GetStarRocksTableDDL(plan.SuffixedTable)
+	// 获取并解析 SR DDL，表名统一按后缀名解析
+	srDDL, err := im.dbManager.GetStarRocksTableDDL(currentSRTable)
 	if err != nil {
-		return fmt.Errorf("获取StarRocks表DDL失败(%s): %w", plan.SuffixedTable, err)
+		return stmts, fmt.Errorf("获取StarRocks表DDL失败(%s): %w", currentSRTable, err)
 	}
 	srTable, err := common.ParseTableFromString(srDDL, im.pair.StarRocks.Database, plan.SuffixedTable, time.Duration(im.cfg.Parser.DDLParseTimeoutSeconds)*time.Second)
 	if err != nil {
-		return fmt.Errorf("解析StarRocks表失败(%s): %w", plan.SuffixedTable, err)
+		return stmts, fmt.Errorf("解析StarRocks表失败(%s): %w", currentSRTable, err)
 	}
 
-	// 生成并执行视图 SQL
+	// 生成视图 SQL
 	viewBuilder := builder.NewBuilder(
-		fieldConverters,
+		coldSource.Converters(),
 		srTable.Field,
 		ckTable.DDL.DBName, ckTable.DDL.TableName, im.catalogName,
 		srTable.DDL.DBName, srTable.DDL.TableName,
 		im.dbManager,
 		im.cfg,
 	)
+	viewBuilder.SetColdSource(coldSource)
+	viewBuilder.SetBoundarySourceTable(currentSRTable)
+	viewBuilder.SetTiers(common.ViewTiers(im.cfg, plan.BaseTable))
 	viewSQL, err := viewBuilder.Build()
 	if err != nil {
-		return fmt.Errorf("构建视图失败(%s.%s): %w", im.pair.StarRocks.Database, plan.BaseTable, err)
-	}
-	if strings.TrimSpace(viewSQL) != "" {
-		srDBConn, errConn := im.dbManager.GetStarRocksConnection()
-		if errConn != nil {
-			return fmt.Errorf("获取StarRocks连接失败: %w", errConn)
-		}
-		if err := im.dbManager.ExecuteBatchSQLWithDB(srDBConn, []string{viewSQL}, false); err != nil {
-			return fmt.Errorf("执行CREATE VIEW失败(%s.%s): %w", im.pair.StarRocks.Database, plan.BaseTable, err)
-		}
+		return stmts, fmt.Errorf("构建视图失败(%s.%s): %w", im.pair.StarRocks.Database, plan.BaseTable, err)
 	}
-	return nil
+	stmts.CreateViewSQL = viewSQL
+	return stmts, nil
 }
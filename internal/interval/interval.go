@@ -0,0 +1,41 @@
+// Package interval 解析带天数单位的时长，供视图边界的保留时长/推进间隔与停更窗口共用
+package interval
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pattern 支持在 Go duration 之前加天数，例如 7d、1d12h、36h、90m
+var pattern = regexp.MustCompile(`^(?:(\d+)d)?(.*)$`)
+
+// Parse 解析时长，必须为正数
+func Parse(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("不能为空")
+	}
+	m := pattern.FindStringSubmatch(s)
+	var d time.Duration
+	if m[1] != "" {
+		days, err := strconv.Atoi(m[1])
+		if err != nil {
+			return 0, fmt.Errorf("%q 不是合法的时长: %w", s, err)
+		}
+		d = time.Duration(days) * 24 * time.Hour
+	}
+	if m[2] != "" {
+		rest, err := time.ParseDuration(m[2])
+		if err != nil {
+			return 0, fmt.Errorf("%q 不是合法的时长（示例: 7d、36h、1d12h）", s)
+		}
+		d += rest
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%q 必须为正数", s)
+	}
+	return d, nil
+}
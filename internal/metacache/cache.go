@@ -0,0 +1,128 @@
+// Package metacache 单次更新运行内的元数据缓存：同一数据库对的 CK 表结构只导出一次，SR 表类型与 DDL 只查询一次
+package metacache
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"cksr/internal/common"
+	"cksr/logger"
+
+	mdb "example.com/migrationLib/database"
+	p "example.com/migrationLib/parser"
+)
+
+// Cache 单个数据库对的元数据缓存，并发安全。
+// 方法与 *mdb.DatabasePairManager 同名，可在生成视图SQL时替代后者；缓存不会自动过期，需调用 Invalidate 显式失效
+type Cache struct {
+	pair      string
+	dbManager *mdb.DatabasePairManager
+
+	ckMu     sync.Mutex
+	ckTables map[string]p.Table
+
+	srMu    sync.Mutex
+	srTypes map[string]string
+	srDDL   map[string]string
+}
+
+// New 创建数据库对的元数据缓存
+func New(pair string, dbManager *mdb.DatabasePairManager) *Cache {
+	return &Cache{pair: pair, dbManager: dbManager, srDDL: make(map[string]string)}
+}
+
+// GetStarRocksConnection 返回数据库对的 SR 连接池（不缓存，连接池本身即可复用）
+func (c *Cache) GetStarRocksConnection() (*sql.DB, error) {
+	return c.dbManager.GetStarRocksConnection()
+}
+
+// ExportClickHouseTablesAsParserTables 返回 CK 全库表结构，首次调用时导出，并发调用方等待同一次导出
+func (c *Cache) ExportClickHouseTablesAsParserTables() (map[string]p.Table, error) {
+	c.ckMu.Lock()
+	defer c.ckMu.Unlock()
+	if c.ckTables != nil {
+		logger.Debug("元数据缓存命中: CK 表结构 (数据库对: %s)", c.pair)
+		return c.ckTables, nil
+	}
+	logger.Debug("元数据缓存未命中: 导出 CK 表结构 (数据库对: %s)", c.pair)
+	tables, err := c.dbManager.ExportClickHouseTablesAsParserTables()
+	if err != nil {
+		return nil, err
+	}
+	c.ckTables = tables
+	return tables, nil
+}
+
+// GetStarRocksTablesTypes 返回 SR 表类型映射，首次调用时查询
+func (c *Cache) GetStarRocksTablesTypes() (map[string]string, error) {
+	c.srMu.Lock()
+	defer c.srMu.Unlock()
+	if c.srTypes != nil {
+		logger.Debug("元数据缓存命中: SR 表类型 (数据库对: %s)", c.pair)
+		return c.srTypes, nil
+	}
+	logger.Debug("元数据缓存未命中: 查询 SR 表类型 (数据库对: %s)", c.pair)
+	types, err := c.dbManager.GetStarRocksTablesTypes()
+	if err != nil {
+		return nil, err
+	}
+	c.srTypes = types
+	return types, nil
+}
+
+// GetStarRocksTableDDL 返回 SR 表 DDL，未预取的表按需查询后缓存
+func (c *Cache) GetStarRocksTableDDL(table string) (string, error) {
+	c.srMu.Lock()
+	ddl, ok := c.srDDL[table]
+	c.srMu.Unlock()
+	if ok {
+		logger.Debug("元数据缓存命中: SR 表 %s 的 DDL (数据库对: %s)", table, c.pair)
+		return ddl, nil
+	}
+	logger.Debug("元数据缓存未命中: 查询 SR 表 %s 的 DDL (数据库对: %s)", table, c.pair)
+	ddl, err := c.dbManager.GetStarRocksTableDDL(table)
+	if err != nil {
+		return "", err
+	}
+	c.srMu.Lock()
+	c.srDDL[table] = ddl
+	c.srMu.Unlock()
+	return ddl, nil
+}
+
+// PrefetchSRDDL 一次性并行预取一组 SR 表的 DDL；单表失败仅告警，使用时会按需重试
+func (c *Cache) PrefetchSRDDL(tables []string, concurrency int) {
+	start := time.Now()
+	errs := common.RunBounded(len(tables), concurrency, 0, false, func(_ context.Context, i int) error {
+		ddl, err := c.dbManager.GetStarRocksTableDDL(tables[i])
+		if err != nil {
+			return err
+		}
+		c.srMu.Lock()
+		c.srDDL[tables[i]] = ddl
+		c.srMu.Unlock()
+		return nil
+	})
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			logger.Warn("预取 SR 表 %s 的 DDL 失败: %v", tables[i], err)
+		}
+	}
+	logger.Debug("预取 SR DDL 完成 (数据库对: %s)，表: %d，失败: %d，耗时: %s", c.pair, len(tables), failed, time.Since(start).Round(time.Millisecond))
+}
+
+// Invalidate 清空全部缓存，下次访问时重新查询
+func (c *Cache) Invalidate() {
+	c.ckMu.Lock()
+	c.ckTables = nil
+	c.ckMu.Unlock()
+	c.srMu.Lock()
+	c.srTypes = nil
+	c.srDDL = make(map[string]string)
+	c.srMu.Unlock()
+	logger.Debug("元数据缓存已失效 (数据库对: %s)", c.pair)
+}
@@ -0,0 +1,295 @@
+// Package metrics 以 Prometheus 文本格式（0.0.4）输出指标的最小实现：计数器、仪表盘与直方图，支持标签
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry 指标注册表，并发安全
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// metric 注册表中的一个指标族
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.metrics {
+		if existing.name() == m.name() {
+			panic("metrics: 重复注册指标 " + m.name())
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteText 按指标名排序输出全部指标
+func (r *Registry) WriteText(w io.Writer) {
+	r.mu.Lock()
+	ms := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+	sort.Slice(ms, func(i, j int) bool { return ms[i].name() < ms[j].name() })
+	for _, m := range ms {
+		m.write(w)
+	}
+}
+
+// ContentType Prometheus 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// family 指标族的公共部分：名称、说明、标签名与按标签值索引的序列
+type family struct {
+	fname  string
+	help   string
+	typ    string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	values []string
+	value  float64
+	// 直方图
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func newFamily(name, help, typ string, labels []string) *family {
+	return &family{fname: name, help: help, typ: typ, labels: labels, series: make(map[string]*series)}
+}
+
+func (f *family) name() string { return f.fname }
+
+// get 返回标签值对应的序列，不存在时创建；调用方需持有 f.mu
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: 指标 %s 需要 %d 个标签值，实际 %d 个", f.fname, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		f.series[key] = s
+	}
+	return s
+}
+
+// initUnlabeled 无标签的指标在首次更新前也输出 0
+func (f *family) initUnlabeled() {
+	if len(f.labels) == 0 {
+		f.get(nil)
+	}
+}
+
+// sorted 返回按标签值排序的序列；调用方需持有 f.mu
+func (f *family) sorted() []*series {
+	out := make([]*series, 0, len(f.series))
+	for _, s := range f.series {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return strings.Join(out[i].values, "\xff") < strings.Join(out[j].values, "\xff")
+	})
+	return out
+}
+
+func (f *family) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.fname, escapeHelp(f.help), f.fname, f.typ)
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct{ *family }
+
+// NewCounterVec 创建并注册计数器
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newFamily(name, help, "counter", labels)}
+	c.initUnlabeled()
+	r.register(c)
+	return c
+}
+
+// Add 增加计数，delta 必须非负
+func (c *CounterVec) Add(delta float64, values ...string) {
+	if delta < 0 {
+		panic("metrics: 计数器不能减少")
+	}
+	c.mu.Lock()
+	c.get(values).value += delta
+	c.mu.Unlock()
+}
+
+// Inc 计数加一
+func (c *CounterVec) Inc(values ...string) { c.Add(1, values...) }
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w)
+	for _, s := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", c.fname, labelString(c.labels, s.values, "", ""), formatFloat(s.value))
+	}
+}
+
+// GaugeVec 带标签的仪表盘
+type GaugeVec struct{ *family }
+
+// NewGaugeVec 创建并注册仪表盘
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newFamily(name, help, "gauge", labels)}
+	g.initUnlabeled()
+	r.register(g)
+	return g
+}
+
+// Set 设置当前值
+func (g *GaugeVec) Set(v float64, values ...string) {
+	g.mu.Lock()
+	g.get(values).value = v
+	g.mu.Unlock()
+}
+
+// Delete 删除标签值对应的序列
+func (g *GaugeVec) Delete(values ...string) {
+	g.mu.Lock()
+	delete(g.series, strings.Join(values, "\xff"))
+	g.mu.Unlock()
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w)
+	for _, s := range g.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", g.fname, labelString(g.labels, s.values, "", ""), formatFloat(s.value))
+	}
+}
+
+// GaugeFunc 在输出时计算取值的仪表盘，collect 返回各序列的标签值与取值
+type GaugeFunc struct {
+	*family
+	collect func(emit func(v float64, values ...string))
+}
+
+// NewGaugeFunc 创建并注册按需计算的仪表盘
+func (r *Registry) NewGaugeFunc(name, help string, collect func(emit func(v float64, values ...string)), labels ...string) *GaugeFunc {
+	g := &GaugeFunc{family: newFamily(name, help, "gauge", labels), collect: collect}
+	r.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.series = make(map[string]*series)
+	g.collect(func(v float64, values ...string) { g.get(values).value = v })
+	g.header(w)
+	for _, s := range g.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", g.fname, labelString(g.labels, s.values, "", ""), formatFloat(s.value))
+	}
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	*family
+	buckets []float64
+}
+
+// NewHistogramVec 创建并注册直方图，buckets 为升序的上界（不含 +Inf）
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{family: newFamily(name, help, "histogram", labels), buckets: buckets}
+	r.register(h)
+	return h
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.get(values)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+	for i, le := range h.buckets {
+		if v <= le {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, s := range h.sorted() {
+		for i, le := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.fname, labelString(h.labels, s.values, "le", formatFloat(le)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.fname, labelString(h.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.fname, labelString(h.labels, s.values, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.fname, labelString(h.labels, s.values, "", ""), s.count)
+	}
+}
+
+// labelString 生成 {k="v",...}，extraName 非空时追加一个标签（直方图的 le）
+func labelString(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, n := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s=\"%s\"", n, escapeLabel(values[i]))
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, "%s=\"%s\"", extraName, extraValue)
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func render(r *Registry) string {
+	var buf bytes.Buffer
+	r.WriteText(&buf)
+	return buf.String()
+}
+
+func TestCounterAndGaugeText(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("cksr_b_total", "计数器说明\n第二行 \\ 反斜杠", "pair", "view")
+	c.Inc("p1", `a"b`)
+	c.Add(2, "p1", "line\nbreak")
+	c.Inc("p0", `back\slash`)
+	g := r.NewGaugeVec("cksr_a", "仪表盘")
+	g.Set(math.Inf(1))
+	r.NewGaugeFunc("cksr_c", "按需计算", func(emit func(float64, ...string)) {
+		emit(0.5, "x")
+		emit(-1, "a")
+	}, "k")
+
+	want := strings.Join([]string{
+		"# HELP cksr_a 仪表盘",
+		"# TYPE cksr_a gauge",
+		"cksr_a +Inf",
+		`# HELP cksr_b_total 计数器说明\n第二行 \\ 反斜杠`,
+		"# TYPE cksr_b_total counter",
+		`cksr_b_total{pair="p0",view="back\\slash"} 1`,
+		`cksr_b_total{pair="p1",view="a\"b"} 1`,
+		`cksr_b_total{pair="p1",view="line\nbreak"} 2`,
+		"# HELP cksr_c 按需计算",
+		"# TYPE cksr_c gauge",
+		`cksr_c{k="a"} -1`,
+		`cksr_c{k="x"} 0.5`,
+		"",
+	}, "\n")
+	if got := render(r); got != want {
+		t.Fatalf("输出不一致:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestUnlabeledCounterStartsAtZero(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("cksr_retries_total", "重试")
+	if got := render(r); !strings.Contains(got, "\ncksr_retries_total 0\n") {
+		t.Fatalf("无标签计数器未更新前应输出 0:\n%s", got)
+	}
+}
+
+func TestHistogramText(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("cksr_tick_duration_seconds", "耗时", []float64{1, 5, 10}, "job")
+	for _, v := range []float64{0.5, 1, 3, 7, 100} {
+		h.Observe(v, "global")
+	}
+	h.Observe(2, `table:"x"`)
+
+	want := strings.Join([]string{
+		"# HELP cksr_tick_duration_seconds 耗时",
+		"# TYPE cksr_tick_duration_seconds histogram",
+		`cksr_tick_duration_seconds_bucket{job="global",le="1"} 2`,
+		`cksr_tick_duration_seconds_bucket{job="global",le="5"} 3`,
+		`cksr_tick_duration_seconds_bucket{job="global",le="10"} 4`,
+		`cksr_tick_duration_seconds_bucket{job="global",le="+Inf"} 5`,
+		`cksr_tick_duration_seconds_sum{job="global"} 111.5`,
+		`cksr_tick_duration_seconds_count{job="global"} 5`,
+		`cksr_tick_duration_seconds_bucket{job="table:\"x\"",le="1"} 0`,
+		`cksr_tick_duration_seconds_bucket{job="table:\"x\"",le="5"} 1`,
+		`cksr_tick_duration_seconds_bucket{job="table:\"x\"",le="10"} 1`,
+		`cksr_tick_duration_seconds_bucket{job="table:\"x\"",le="+Inf"} 1`,
+		`cksr_tick_duration_seconds_sum{job="table:\"x\""} 2`,
+		`cksr_tick_duration_seconds_count{job="table:\"x\""} 1`,
+		"",
+	}, "\n")
+	if got := render(r); got != want {
+		t.Fatalf("输出不一致:\n%s\n期望:\n%s", got, want)
+	}
+}
+
+func TestGaugeDelete(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("cksr_view_boundary_seconds", "边界", "pair", "view")
+	g.Set(1, "p", "a")
+	g.Set(2, "p", "b")
+	g.Delete("p", "a")
+	got := render(r)
+	if strings.Contains(got, `view="a"`) || !strings.Contains(got, `cksr_view_boundary_seconds{pair="p",view="b"} 2`) {
+		t.Fatalf("删除序列后输出不正确:\n%s", got)
+	}
+}
+
+func TestMisuse(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(r *Registry)
+	}{
+		{"重复注册", func(r *Registry) {
+			r.NewCounterVec("dup", "")
+			r.NewGaugeVec("dup", "")
+		}},
+		{"标签个数不符", func(r *Registry) { r.NewCounterVec("c", "", "a").Inc() }},
+		{"计数器减少", func(r *Registry) { r.NewCounterVec("c", "").Add(-1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("期望 panic")
+				}
+			}()
+			tt.fn(NewRegistry())
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package planrun
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cksr/internal/common"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+	mdb "example.com/migrationLib/database"
+)
+
+// PairFingerprint 单个数据库对的元数据指纹，用于在 apply 时判断环境是否已变化
+// 指纹覆盖：库对配置（库名/后缀/Catalog）、CK 全部表的列（名称+类型，按列顺序）、
+// SR 表类型映射，以及与 CK 同名（或加后缀）的 SR 原生表的列（名称+类型）。
+// 不直接哈希 SHOW CREATE TABLE：其中的动态分区、副本数等 PROPERTIES 会随 SR 自动调整而变化，与计划无关
+type PairFingerprint struct {
+	Pair     string `json:"pair"`
+	Hash     string `json:"hash"`
+	CKTables int    `json:"ck_tables"`
+	SRTables int    `json:"sr_tables"`
+}
+
+// ComputeFingerprints 计算所有数据库对的元数据指纹（只读）
+func ComputeFingerprints(cfg *mcfg.Config) ([]PairFingerprint, error) {
+	var result []PairFingerprint
+	for i := range cfg.DatabasePairs {
+		fp, err := computePairFingerprint(cfg, i)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, fp)
+	}
+	return result, nil
+}
+
+// computePairFingerprint 按固定顺序拼接元数据并计算 sha256
+func computePairFingerprint(cfg *mcfg.Config, pairIndex int) (PairFingerprint, error) {
+	pair := cfg.DatabasePairs[pairIndex]
+	fp := PairFingerprint{Pair: pair.Name}
+
+	dbManager := mdb.NewDatabasePairManager(cfg, pairIndex)
+	if err := dbManager.Init(); err != nil {
+		return fp, fmt.Errorf("初始化数据库连接失败(库对 %s): %w", pair.Name, err)
+	}
+	ckTablesMap, err := dbManager.ExportClickHouseTablesAsParserTables()
+	if err != nil {
+		return fp, fmt.Errorf("导出ClickHouse表结构失败(库对 %s): %w", pair.Name, err)
+	}
+	srTypes, err := dbManager.GetStarRocksTablesTypes()
+	if err != nil {
+		return fp, fmt.Errorf("查询StarRocks表类型失败(库对 %s): %w", pair.Name, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "pair=%s\nck_db=%s\nsr_db=%s\nsuffix=%s\ncatalog=%s\n",
+		pair.Name, pair.ClickHouse.Database, pair.StarRocks.Database, pair.SRTableSuffix, pair.CatalogName)
+
+	ckNames := make([]string, 0, len(ckTablesMap))
+	for name := range ckTablesMap {
+		ckNames = append(ckNames, name)
+	}
+	sort.Strings(ckNames)
+	for _, name := range ckNames {
+		fmt.Fprintf(&b, "ck_table=%s\n", name)
+		for _, f := range ckTablesMap[name].Field {
+			fmt.Fprintf(&b, "  %s %s\n", f.Name, f.Type)
+		}
+	}
+
+	srNames := make([]string, 0, len(srTypes))
+	for name := range srTypes {
+		srNames = append(srNames, name)
+	}
+	sort.Strings(srNames)
+	for _, name := range srNames {
+		fmt.Fprintf(&b, "sr_type=%s %s\n", name, strings.ToUpper(srTypes[name]))
+	}
+
+	// 仅对与 CK 表对应的 SR 原生表取列，视图定义含时间边界，会随更新器变化，不纳入指纹
+	for _, name := range ckNames {
+		for _, srName := range []string{name, name + pair.SRTableSuffix} {
+			t, ok := srTypes[srName]
+			if !ok || strings.ToUpper(t) != mdb.StarRocksTableTypeBaseTable {
+				continue
+			}
+			ddl, err := dbManager.GetStarRocksTableDDL(srName)
+			if err != nil {
+				return fp, fmt.Errorf("获取StarRocks表DDL失败(%s): %w", srName, err)
+			}
+			srTable, err := common.ParseTableFromString(ddl, pair.StarRocks.Database, srName, time.Duration(cfg.Parser.DDLParseTimeoutSeconds)*time.Second)
+			if err != nil {
+				return fp, fmt.Errorf("解析StarRocks表%s失败: %w", srName, err)
+			}
+			fmt.Fprintf(&b, "sr_table=%s\n", srName)
+			for _, f := range srTable.Field {
+				fmt.Fprintf(&b, "  %s %s\n", f.Name, f.Type)
+			}
+		}
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	fp.Hash = hex.EncodeToString(sum[:])
+	fp.CKTables = len(ckNames)
+	fp.SRTables = len(srNames)
+	logger.Debug("数据库对 %s 元数据指纹: %s (CK表: %d, SR表: %d)", pair.Name, fp.Hash, fp.CKTables, fp.SRTables)
+	return fp, nil
+}
+
+// DiffFingerprints 比较计划中的指纹与当前指纹，返回不一致的描述；为空表示一致
+func DiffFingerprints(planned, current []PairFingerprint) []string {
+	cur := make(map[string]PairFingerprint, len(current))
+	for _, fp := range current {
+		cur[fp.Pair] = fp
+	}
+	var diffs []string
+	for _, fp := range planned {
+		c, ok := cur[fp.Pair]
+		if !ok {
+			diffs = append(diffs, fmt.Sprintf("数据库对 %s 不在当前配置中", fp.Pair))
+			continue
+		}
+		if c.Hash != fp.Hash {
+			diffs = append(diffs, fmt.Sprintf("数据库对 %s 元数据已变化 (计划: %s, 当前: %s; CK表 %d -> %d, SR表 %d -> %d)",
+				fp.Pair, shortHash(fp.Hash), shortHash(c.Hash), fp.CKTables, c.CKTables, fp.SRTables, c.SRTables))
+		}
+	}
+	return diffs
+}
+
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
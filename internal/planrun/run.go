@@ -0,0 +1,192 @@
+package planrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"cksr/internal/initrun"
+	"cksr/internal/rollbackrun"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+)
+
+// 计划类型
+const (
+	KindInit     = "init"
+	KindRollback = "rollback"
+)
+
+// 输出格式
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// DocumentVersion 计划文件格式版本，格式不兼容变更时递增
+const DocumentVersion = 1
+
+// Document 计划文档：发现阶段的结果、将要执行的SQL以及生成计划时的元数据指纹
+type Document struct {
+	Version      int                            `json:"version"`
+	Kind         string                         `json:"kind"`
+	GeneratedAt  time.Time                      `json:"generated_at"`
+	Fingerprints []PairFingerprint              `json:"fingerprints"`
+	Init         []initrun.PairInitPlan         `json:"init,omitempty"`
+	Rollback     []rollbackrun.PairRollbackPlan `json:"rollback,omitempty"`
+}
+
+// Build 生成指定类型的计划文档（只读，不做任何变更）
+func Build(cfg *mcfg.Config, kind string) (*Document, error) {
+	doc := &Document{Version: DocumentVersion, Kind: kind, GeneratedAt: time.Now()}
+	// 先计算指纹再生成计划：两者之间若发生变更，apply 时会因指纹不一致而拒绝执行
+	fps, err := ComputeFingerprints(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("计算元数据指纹失败: %w", err)
+	}
+	doc.Fingerprints = fps
+	switch kind {
+	case KindInit:
+		plans, err := initrun.Plan(cfg)
+		if err != nil {
+			return nil, err
+		}
+		doc.Init = plans
+	case KindRollback:
+		plans, err := rollbackrun.Plan(cfg)
+		if err != nil {
+			return nil, err
+		}
+		doc.Rollback = plans
+	default:
+		return nil, fmt.Errorf("不支持的计划类型: %s，仅支持 %s、%s", kind, KindInit, KindRollback)
+	}
+	return doc, nil
+}
+
+// Run 统一入口：生成计划并按格式输出；outPath 非空时同时保存计划文件供 apply 使用
+func Run(cfg *mcfg.Config, kind string, format string, outPath string, w io.Writer) error {
+	doc, err := Build(cfg, kind)
+	if err != nil {
+		return err
+	}
+	if outPath != "" {
+		if err := WriteFile(outPath, doc); err != nil {
+			return err
+		}
+		logger.Info("计划文件已保存: %s", outPath)
+	}
+	return Render(w, doc, format)
+}
+
+// WriteFile 将计划文档以 JSON 保存到文件
+func WriteFile(path string, doc *Document) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化计划失败: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入计划文件 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+// ReadFile 读取并校验计划文件
+func ReadFile(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取计划文件 %s 失败: %w", path, err)
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("解析计划文件 %s 失败: %w", path, err)
+	}
+	if doc.Version != DocumentVersion {
+		return nil, fmt.Errorf("计划文件版本不支持: %d，当前版本: %d", doc.Version, DocumentVersion)
+	}
+	if doc.Kind != KindInit && doc.Kind != KindRollback {
+		return nil, fmt.Errorf("计划文件类型未知: %q", doc.Kind)
+	}
+	if len(doc.Fingerprints) == 0 {
+		return nil, fmt.Errorf("计划文件缺少元数据指纹")
+	}
+	return &doc, nil
+}
+
+// Render 按格式输出计划文档
+func Render(w io.Writer, doc *Document, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(doc)
+	case FormatText, "":
+		return renderText(w, doc)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s，仅支持 %s、%s", format, FormatText, FormatJSON)
+	}
+}
+
+func renderText(w io.Writer, doc *Document) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s 计划 (生成时间: %s)\n", doc.Kind, doc.GeneratedAt.Format("2006-01-02 15:04:05"))
+	for _, fp := range doc.Fingerprints {
+		fmt.Fprintf(&b, "# 指纹 %s: %s (CK表: %d, SR表: %d)\n", fp.Pair, fp.Hash, fp.CKTables, fp.SRTables)
+	}
+	for _, pp := range doc.Init {
+		fmt.Fprintf(&b, "\n== 数据库对: %s (Catalog: %s)，待处理表: %d\n", pp.Pair, pp.CatalogName, len(pp.Tables))
+		fmt.Fprintf(&b, "-- 确保 Catalog %s 存在（不存在时创建）\n", pp.CatalogName)
+		for i, t := range pp.Tables {
+			fmt.Fprintf(&b, "\n[%d/%d] 表: %s -> %s\n", i+1, len(pp.Tables), t.BaseTable, t.SuffixedTable)
+			fmt.Fprintf(&b, "  重命名: %v，原因: %s\n", t.NeedRename, t.RenameReason)
+			fmt.Fprintf(&b, "  视图: %s\n", t.ViewReason)
+			stmts := append(append([]string(nil), t.SQL.CKAlterSQLs...), t.SQL.SRRenameSQL, t.SQL.CreateViewSQL)
+			writeSQL(&b, stmts...)
+		}
+	}
+	for _, pp := range doc.Rollback {
+		fmt.Fprintf(&b, "\n== 数据库对: %s (Catalog: %s)，待回滚表: %d\n", pp.Pair, pp.CatalogName, len(pp.Tables))
+		for i, t := range pp.Tables {
+			fmt.Fprintf(&b, "\n[%d/%d] 表: %s <- %s\n", i+1, len(pp.Tables), t.BaseTable, t.SuffixedTable)
+			fmt.Fprintf(&b, "  删除视图: %v，原因: %s\n", t.NeedDropView, t.DropViewReason)
+			fmt.Fprintf(&b, "  去后缀重命名: %v，原因: %s\n", t.NeedRename, t.RenameReason)
+			if t.RenameBlockReason != "" {
+				fmt.Fprintf(&b, "  阻止执行: %s\n", t.RenameBlockReason)
+			}
+			if len(t.CKAddedColumns) > 0 {
+				fmt.Fprintf(&b, "  CK新增列: %s\n", strings.Join(t.CKAddedColumns, ", "))
+			}
+			if len(t.TierSources) > 0 {
+				fmt.Fprintf(&b, "  数据层(保留): %s\n", strings.Join(t.TierSources, ", "))
+			}
+			stmts := append([]string{t.SQL.DropViewSQL, t.SQL.RenameSQL}, t.SQL.DropCKColumnSQLs...)
+			writeSQL(&b, stmts...)
+		}
+	}
+	if len(doc.Rollback) > 0 {
+		fmt.Fprintf(&b, "\n== 所有数据库对处理完成后删除Catalog\n")
+		for _, pp := range doc.Rollback {
+			writeSQL(&b, pp.DropCatalogSQL)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeSQL 输出非空SQL，统一以分号结尾
+func writeSQL(b *strings.Builder, stmts ...string) {
+	for _, s := range stmts {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.HasSuffix(s, ";") {
+			s += ";"
+		}
+		fmt.Fprintf(b, "%s\n", s)
+	}
+}
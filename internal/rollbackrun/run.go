@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -19,8 +20,8 @@ import (
 )
 
 // Run 统一入口：执行回滚逻辑（与 initrun 保持一致的接口风格）
-func Run(cfg *mcfg.Config) error {
-	return ExecuteRollbackForAllPairs(cfg)
+func Run(cfg *mcfg.Config, scope common.Scope) error {
+	return ExecuteRollbackForAllPairs(cfg, scope)
 }
 
 // RollbackManager 回退管理器
@@ -29,6 +30,8 @@ type RollbackManager struct {
 	cfg       *mcfg.Config
 	pair      mcfg.DatabasePair
 	stats     RollbackStats
+	scope     common.Scope      // 命令行指定的处理范围，零值表示全部
+	filtered  []common.Filtered // 本次被过滤的表及原因
 }
 
 // RollbackStep 步骤枚举
@@ -42,17 +45,45 @@ const (
 	StepUnknown      RollbackStep = "unknown"
 )
 
+// 回滚策略（rollback.strategy），为空时等同 fail_fast
+const (
+	StrategyFailFast        = "fail_fast"         // 任一表失败即中止
+	StrategyContinueOnError = "continue_on_error" // 记录失败并继续处理下一张表
+)
+
 // TableRollbackPlan 单表回滚计划（由发现阶段一次性生成，执行阶段直接使用）
 type TableRollbackPlan struct {
-	BaseTable         string   // 基础表名（不含后缀）
-	SuffixedTable     string   // 后缀表名（BaseTable + suffix）
-	NeedDropView      bool     // 是否需要删除基础名对应的视图
-	NeedRename        bool     // 是否需要将后缀表重命名为基础名
-	CanRename         bool     // 是否允许重命名（基础名不存在或为视图）
-	DropViewReason    string   // 决策原因：为何需要/不需要删除视图
-	RenameReason      string   // 决策原因：为何需要/不需要重命名
-	RenameBlockReason string   // 决策原因：为何重命名被阻止
-	CKAddedColumns    []string // CK 中需要删除的新增列
+	BaseTable         string   `json:"base_table"`                    // 基础表名（不含后缀）
+	SuffixedTable     string   `json:"suffixed_table"`                // 后缀表名（BaseTable + suffix）
+	NeedDropView      bool     `json:"need_drop_view"`                // 是否需要删除基础名对应的视图
+	NeedRename        bool     `json:"need_rename"`                   // 是否需要将后缀表重命名为基础名
+	CanRename         bool     `json:"can_rename"`                    // 是否允许重命名（基础名不存在或为视图）
+	DropViewReason    string   `json:"drop_view_reason"`              // 决策原因：为何需要/不需要删除视图
+	RenameReason      string   `json:"rename_reason"`                 // 决策原因：为何需要/不需要重命名
+	RenameBlockReason string   `json:"rename_block_reason,omitempty"` // 决策原因：为何重命名被阻止
+	CKAddedColumns    []string `json:"ck_added_columns,omitempty"`    // CK 中需要删除的新增列
+	TierSources       []string `json:"tier_sources,omitempty"`        // 视图引用的更早数据层（由用户维护，回退不删除）
+}
+
+// TableRollbackSQL 单表回滚将要执行的SQL（按执行顺序：删视图 -> 去后缀 -> 删CK列）
+type TableRollbackSQL struct {
+	DropViewSQL      string   `json:"drop_view_sql,omitempty"`
+	RenameSQL        string   `json:"rename_sql,omitempty"`
+	DropCKColumnSQLs []string `json:"drop_ck_column_sqls,omitempty"`
+}
+
+// TableRollbackPlanWithSQL 单表计划及其SQL，供 plan 命令输出
+type TableRollbackPlanWithSQL struct {
+	TableRollbackPlan
+	SQL TableRollbackSQL `json:"sql"`
+}
+
+// PairRollbackPlan 单个数据库对的回滚计划；Catalog 在所有库对的表处理完成后删除
+type PairRollbackPlan struct {
+	Pair           string                     `json:"pair"`
+	CatalogName    string                     `json:"catalog_name"`
+	Tables         []TableRollbackPlanWithSQL `json:"tables"`
+	DropCatalogSQL string                     `json:"drop_catalog_sql"`
 }
 
 // FailureRecord 失败记录（可用于库对内和全局）
@@ -85,6 +116,40 @@ func NewRollbackManager(cfg *mcfg.Config, pairIndex int) *RollbackManager {
 	}
 }
 
+// Plan 仅执行发现阶段，生成所有数据库对的回滚计划及SQL，不做任何变更，也不获取锁
+func Plan(cfg *mcfg.Config) ([]PairRollbackPlan, error) {
+	var result []PairRollbackPlan
+	for i, pair := range cfg.DatabasePairs {
+		logger.Info("开始生成数据库对 %s 的回滚计划", pair.Name)
+		pp, err := NewRollbackManager(cfg, i).BuildPlan()
+		if err != nil {
+			return nil, fmt.Errorf("生成数据库对 %s 的回滚计划失败: %w", pair.Name, err)
+		}
+		result = append(result, pp)
+	}
+	return result, nil
+}
+
+// BuildPlan 生成单个数据库对的回滚计划及每张表将要执行的SQL（只读）
+func (rm *RollbackManager) BuildPlan() (PairRollbackPlan, error) {
+	pp := PairRollbackPlan{Pair: rm.pair.Name, CatalogName: rm.pair.CatalogName}
+	if strings.TrimSpace(rm.pair.CatalogName) == "" {
+		return pp, fmt.Errorf("数据库对 %s 未配置 CatalogName", rm.pair.Name)
+	}
+	if err := rm.dbManager.Init(); err != nil {
+		return pp, fmt.Errorf("初始化数据库连接失败: %w", err)
+	}
+	plans, err := rm.findRollbackPlans()
+	if err != nil {
+		return pp, fmt.Errorf("确认共同表及回退计划失败: %w", err)
+	}
+	for _, plan := range plans {
+		pp.Tables = append(pp.Tables, TableRollbackPlanWithSQL{TableRollbackPlan: plan, SQL: rm.buildRollbackSQL(plan)})
+	}
+	pp.DropCatalogSQL = builder.NewRollbackBuilder("", "").BuildDropCatalogSQL(rm.pair.CatalogName)
+	return pp, nil
+}
+
 // ExecuteRollback 执行完整的回退操作
 func (rm *RollbackManager) ExecuteRollback() error {
 	// 主动初始化数据库连接（池）
@@ -103,12 +168,40 @@ func (rm *RollbackManager) ExecuteRollback() error {
 	}
 	logger.Info("确认到 %d 个共同表: %v", len(plans), baseTables)
 
-	rm.stats = RollbackStats{PairName: rm.pair.Name, TotalTables: len(plans)}
+	tables := make([]TableRollbackPlanWithSQL, 0, len(plans))
+	for _, plan := range plans {
+		tables = append(tables, TableRollbackPlanWithSQL{TableRollbackPlan: plan, SQL: rm.buildRollbackSQL(plan)})
+	}
+	if err := rm.runTablePlans(tables); err != nil {
+		return err
+	}
+
+	logger.Info("数据库对 %s 两阶段回退完成", rm.pair.Name)
+	return nil
+}
+
+// ApplyPlan 按已保存的计划执行单个数据库对的回退，只执行计划中记录的SQL
+func (rm *RollbackManager) ApplyPlan(pp PairRollbackPlan) error {
+	if err := rm.dbManager.Init(); err != nil {
+		return fmt.Errorf("初始化数据库连接失败: %w", err)
+	}
+	logger.Info("开始按计划执行回退操作，数据库对: %s，表数量: %d", rm.pair.Name, len(pp.Tables))
+	if err := rm.runTablePlans(pp.Tables); err != nil {
+		return err
+	}
+	logger.Info("数据库对 %s 按计划回退完成", rm.pair.Name)
+	return nil
+}
+
+// runTablePlans 逐表执行回退计划并记录统计，失败时按 rollback.strategy 决定继续或中止
+func (rm *RollbackManager) runTablePlans(tables []TableRollbackPlanWithSQL) error {
+	rm.stats = RollbackStats{PairName: rm.pair.Name, TotalTables: len(tables)}
 
 	// 针对每个共同表执行计划：先删视图，再改表名，最后删CK列
-	for idx, plan := range plans {
-		logger.Info("[%d/%d] 回退表: %s", idx+1, len(plans), plan.BaseTable)
-		if err := rm.executeRollbackPlan(plan); err != nil {
+	for idx, t := range tables {
+		plan := t.TableRollbackPlan
+		logger.Info("[%d/%d] 回退表: %s", idx+1, len(tables), plan.BaseTable)
+		if err := rm.executeRollbackPlan(plan, t.SQL); err != nil {
 			// 现场打印失败信息
 			var fr *FailureRecord
 			if errors.As(err, &fr) {
@@ -116,7 +209,7 @@ func (rm *RollbackManager) ExecuteRollback() error {
 			} else {
 				rm.stats.Failed = append(rm.stats.Failed, FailureRecord{Table: plan.BaseTable, Step: StepUnknown, Err: err})
 			}
-			if rm.cfg.Rollback.Strategy == "continue_on_error" {
+			if rm.cfg.Rollback.Strategy == StrategyContinueOnError {
 				logger.Error("表 %s 回退失败(继续下一表): %v", plan.BaseTable, err)
 				continue
 			}
@@ -124,13 +217,28 @@ func (rm *RollbackManager) ExecuteRollback() error {
 		}
 		rm.stats.SuccessTables++
 	}
-
-	logger.Info("数据库对 %s 两阶段回退完成", rm.pair.Name)
 	return nil
 }
 
-// 两阶段第一步：确认共同表并生成回退计划
-func (rm *RollbackManager) findRollbackPlans() ([]TableRollbackPlan, error) {
+// TableFacts 单张CK表在SR/CK两侧的现状（发现阶段一次性采集，回滚计划与 status 共用）
+type TableFacts struct {
+	BaseTable        string   // 基础表名（CK表名）
+	SuffixedTable    string   // 后缀表名（BaseTable + suffix）
+	Ignored          bool     // 是否在 ignore_tables 中
+	SRBaseExists     bool     // SR 是否存在基础名
+	SRBaseType       string   // SR 基础名类型（大写），不存在时为空
+	SRSuffixedExists bool     // SR 是否存在后缀名
+	SRSuffixedType   string   // SR 后缀名类型（大写），不存在时为空
+	CKAddedColumns   []string // CK 中通过 add column 新增的列
+}
+
+// SRBaseIsView 基础名在SR中是否为视图
+func (f TableFacts) SRBaseIsView() bool {
+	return f.SRBaseType == mdb.StarRocksTableTypeView
+}
+
+// DiscoverTables 采集当前库对所有CK表的现状（只读，按表名排序；忽略表只标记不采集CK新增列）
+func (rm *RollbackManager) DiscoverTables() ([]TableFacts, error) {
 	suffix := rm.pair.SRTableSuffix
 
 	// 预取 SR 表列表与类型映射
@@ -159,31 +267,83 @@ func (rm *RollbackManager) findRollbackPlans() ([]TableRollbackPlan, error) {
 		ignore[t] = true
 	}
 
-	var plans []TableRollbackPlan
+	// 排序保证计划顺序稳定，便于审计与比对
+	ckTables := make([]string, 0, len(ckTablesMap))
 	for table := range ckTablesMap {
-		if ignore[table] {
-			logger.Info("忽略表: %s (在配置的忽略列表中)", table)
-			continue
-		}
+		ckTables = append(ckTables, table)
+	}
+	sort.Strings(ckTables)
 
+	facts := make([]TableFacts, 0, len(ckTables))
+	for _, table := range ckTables {
 		suffixed := table + suffix
-		_, srSuffixedExists := srTableSet[suffixed]
-		_, srBaseExists := srTableSet[table]
-		srBaseType := srTypes[table]
-		srBaseIsView := strings.ToUpper(srBaseType) == mdb.StarRocksTableTypeView
-		suffixedType := srTypes[suffixed]
+		f := TableFacts{BaseTable: table, SuffixedTable: suffixed, Ignored: ignore[table]}
+		_, f.SRBaseExists = srTableSet[table]
+		_, f.SRSuffixedExists = srTableSet[suffixed]
+		f.SRBaseType = strings.ToUpper(srTypes[table])
+		f.SRSuffixedType = strings.ToUpper(srTypes[suffixed])
+		// 忽略或不在处理范围内的表无需解析CK新增列
+		if inScope, _ := rm.scope.MatchTable(table); f.Ignored || !inScope {
+			facts = append(facts, f)
+			continue
+		}
 
 		// CK新增列清单
-		var ckAddedCols []string
 		converters, err := ckc.NewConverters(ckTablesMap[table], mlcommon.ScenarioView)
 		if err != nil {
 			return nil, fmt.Errorf("构建ClickHouse字段转换器失败: %w", err)
 		}
 		for _, c := range converters {
 			if c.IsAddedColumn() {
-				ckAddedCols = append(ckAddedCols, c.Field.Name)
+				f.CKAddedColumns = append(f.CKAddedColumns, c.Field.Name)
 			}
 		}
+		facts = append(facts, f)
+	}
+	return facts, nil
+}
+
+// DiscoverPair 初始化连接并采集指定库对的现状（只读），返回已初始化的库对管理器供调用方继续查询
+func DiscoverPair(cfg *mcfg.Config, pairIndex int) ([]TableFacts, *mdb.DatabasePairManager, error) {
+	rm := NewRollbackManager(cfg, pairIndex)
+	if err := rm.dbManager.Init(); err != nil {
+		return nil, nil, fmt.Errorf("初始化数据库连接失败: %w", err)
+	}
+	facts, err := rm.DiscoverTables()
+	if err != nil {
+		return nil, nil, err
+	}
+	return facts, rm.dbManager, nil
+}
+
+// 两阶段第一步：确认共同表并生成回退计划
+func (rm *RollbackManager) findRollbackPlans() ([]TableRollbackPlan, error) {
+	facts, err := rm.DiscoverTables()
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []TableRollbackPlan
+	for _, f := range facts {
+		table := f.BaseTable
+		if f.Ignored {
+			logger.Info("忽略表: %s (%s)", table, common.ReasonIgnored)
+			rm.filtered = append(rm.filtered, common.Filtered{Pair: rm.pair.Name, Table: table, Reason: common.ReasonIgnored})
+			continue
+		}
+		if ok, reason := rm.scope.MatchTable(table); !ok {
+			logger.Debug("过滤表: %s (%s)", table, reason)
+			rm.filtered = append(rm.filtered, common.Filtered{Pair: rm.pair.Name, Table: table, Reason: reason})
+			continue
+		}
+
+		suffixed := f.SuffixedTable
+		srSuffixedExists := f.SRSuffixedExists
+		srBaseExists := f.SRBaseExists
+		srBaseType := f.SRBaseType
+		srBaseIsView := f.SRBaseIsView()
+		suffixedType := f.SRSuffixedType
+		ckAddedCols := f.CKAddedColumns
 
 		// 认定为共同表的条件与原逻辑一致
 		isCommon := false
@@ -207,6 +367,9 @@ func (rm *RollbackManager) findRollbackPlans() ([]TableRollbackPlan, error) {
 			CanRename:      (!srBaseExists) || srBaseIsView,
 			CKAddedColumns: ckAddedCols,
 		}
+		for _, t := range common.ViewTiers(rm.cfg, table) {
+			plan.TierSources = append(plan.TierSources, t.Ref())
+		}
 
 		// 决策原因填充
 		if plan.NeedDropView {
@@ -237,8 +400,28 @@ func (rm *RollbackManager) findRollbackPlans() ([]TableRollbackPlan, error) {
 	return plans, nil
 }
 
+// buildRollbackSQL 依据计划生成单表回滚SQL（只读，不执行）
+func (rm *RollbackManager) buildRollbackSQL(plan TableRollbackPlan) TableRollbackSQL {
+	var stmts TableRollbackSQL
+	srDB := rm.pair.StarRocks.Database
+	ckDB := rm.pair.ClickHouse.Database
+	if plan.NeedDropView {
+		stmts.DropViewSQL = builder.NewRollbackBuilder(srDB, plan.BaseTable).BuildDropViewSQL()
+	}
+	if plan.NeedRename {
+		stmts.RenameSQL = builder.NewRollbackBuilder(srDB, plan.SuffixedTable).BuildRenameSRTableSQL(rm.pair.SRTableSuffix)
+	}
+	if len(plan.CKAddedColumns) > 0 {
+		rb := builder.NewRollbackBuilder(ckDB, plan.BaseTable)
+		for _, c := range plan.CKAddedColumns {
+			stmts.DropCKColumnSQLs = append(stmts.DropCKColumnSQLs, rb.BuildDropCKColumnSQL(c))
+		}
+	}
+	return stmts
+}
+
 // 针对单表执行回退计划：删视图 -> 去后缀 -> 删CK列
-func (rm *RollbackManager) executeRollbackPlan(plan TableRollbackPlan) error {
+func (rm *RollbackManager) executeRollbackPlan(plan TableRollbackPlan, stmts TableRollbackSQL) error {
 	// 复用已初始化的连接
 	srDBConn, err := rm.dbManager.GetStarRocksConnection()
 	if err != nil {
@@ -249,8 +432,6 @@ func (rm *RollbackManager) executeRollbackPlan(plan TableRollbackPlan) error {
 		return fmt.Errorf("获取ClickHouse连接失败: %w", err)
 	}
 	srDB := rm.pair.StarRocks.Database
-	ckDB := rm.pair.ClickHouse.Database
-	suffix := rm.pair.SRTableSuffix
 
 	// 0. 预检：若需要重命名但不允许重命名（基础名存在且不是视图），则直接失败并不做任何破坏性操作
 	if plan.NeedRename && !plan.CanRename {
@@ -262,38 +443,32 @@ func (rm *RollbackManager) executeRollbackPlan(plan TableRollbackPlan) error {
 	}
 
 	// 1. 删除VIEW（仅当计划指示需要删除视图时执行）
-	if plan.NeedDropView {
-		dropViewSQL := builder.NewRollbackBuilder(srDB, plan.BaseTable).BuildDropViewSQL()
-		if err := rm.dbManager.ExecuteRollbackSQLWithDB(srDBConn, []string{dropViewSQL}, false); err != nil {
+	if stmts.DropViewSQL != "" {
+		if err := rm.dbManager.ExecuteRollbackSQLWithDB(srDBConn, []string{stmts.DropViewSQL}, false); err != nil {
 			return &FailureRecord{Table: plan.BaseTable, Step: StepDropView, Err: fmt.Errorf("删除视图 %s 失败(原因: %s): %w", plan.BaseTable, plan.DropViewReason, err)}
 		}
 		logger.Info("已删除视图(若存在): %s.%s，原因: %s", srDB, plan.BaseTable, plan.DropViewReason)
+		if len(plan.TierSources) > 0 {
+			logger.Warn("视图 %s 引用的数据层 %s 不再能通过基础名查询，其数据与 Catalog 由用户自行处理", plan.BaseTable, strings.Join(plan.TierSources, "、"))
+		}
 	} else {
 		logger.Info("跳过删除视图: %s.%s，原因: %s", srDB, plan.BaseTable, plan.DropViewReason)
 	}
 
 	// 2. 去除SR表后缀（仅在需要且允许时执行；此时视图已删除，避免冲突）
-	if plan.NeedRename {
-		renameSQL := builder.NewRollbackBuilder(srDB, plan.SuffixedTable).BuildRenameSRTableSQL(suffix)
-		if renameSQL != "" {
-			if err := rm.dbManager.ExecuteRollbackSQLWithDB(srDBConn, []string{renameSQL}, false); err != nil {
-				return &FailureRecord{Table: plan.BaseTable, Step: StepRenameSuffix, Err: fmt.Errorf("去除后缀重命名 %s -> %s 失败(原因: %s): %w", plan.SuffixedTable, plan.BaseTable, plan.RenameReason, err)}
-			}
-			logger.Info("已去除后缀并重命名: %s.%s -> %s，原因: %s", srDB, plan.SuffixedTable, plan.BaseTable, plan.RenameReason)
+	if stmts.RenameSQL != "" {
+		if err := rm.dbManager.ExecuteRollbackSQLWithDB(srDBConn, []string{stmts.RenameSQL}, false); err != nil {
+			return &FailureRecord{Table: plan.BaseTable, Step: StepRenameSuffix, Err: fmt.Errorf("去除后缀重命名 %s -> %s 失败(原因: %s): %w", plan.SuffixedTable, plan.BaseTable, plan.RenameReason, err)}
 		}
+		logger.Info("已去除后缀并重命名: %s.%s -> %s，原因: %s", srDB, plan.SuffixedTable, plan.BaseTable, plan.RenameReason)
 	}
 
 	// 3. 删除CK表中通过 add column 新增的列（使用计划中预先识别出的列名）
-	if len(plan.CKAddedColumns) > 0 {
-		var dropCKSQLs []string
-		rb := builder.NewRollbackBuilder(ckDB, plan.BaseTable)
-		for _, c := range plan.CKAddedColumns {
-			dropCKSQLs = append(dropCKSQLs, rb.BuildDropCKColumnSQL(c))
-		}
-		if err := rm.dbManager.ExecuteRollbackSQLWithDB(ckDBConn, dropCKSQLs, true); err != nil {
+	if len(stmts.DropCKColumnSQLs) > 0 {
+		if err := rm.dbManager.ExecuteRollbackSQLWithDB(ckDBConn, stmts.DropCKColumnSQLs, true); err != nil {
 			return &FailureRecord{Table: plan.BaseTable, Step: StepDropCKCols, Err: fmt.Errorf("删除CK列失败(表 %s): %w", plan.BaseTable, err)}
 		}
-		logger.Info("已删除CK表 %s 的 %d 个新增列", plan.BaseTable, len(dropCKSQLs))
+		logger.Info("已删除CK表 %s 的 %d 个新增列", plan.BaseTable, len(stmts.DropCKColumnSQLs))
 	}
 
 	return nil
@@ -312,8 +487,59 @@ func (rm *RollbackManager) printPairSummary() {
 	}
 }
 
-// ExecuteRollbackForAllPairs 对所有数据库对执行回退操作
-func ExecuteRollbackForAllPairs(cfg *mcfg.Config) error {
+// ExecuteRollbackForAllPairs 对处理范围内的所有数据库对执行回退操作
+// 指定了表级过滤时，未回退的表仍通过视图引用 Catalog，因此保留 Catalog
+func ExecuteRollbackForAllPairs(cfg *mcfg.Config, scope common.Scope) error {
+	jobs := make([]pairJob, 0, len(cfg.DatabasePairs))
+	for i, pair := range cfg.DatabasePairs {
+		if !scope.MatchPair(pair.Name) {
+			logger.Info("跳过数据库对 %s (未匹配 --pair)", pair.Name)
+			continue
+		}
+		jobs = append(jobs, pairJob{
+			pairIndex:   i,
+			run:         (*RollbackManager).ExecuteRollback,
+			scope:       scope,
+			keepCatalog: scope.HasTableFilter(),
+		})
+	}
+	return runPairJobs(cfg, jobs)
+}
+
+// ApplyPlans 按已保存的计划对各数据库对执行回退（持有与 rollback 相同的锁）
+func ApplyPlans(cfg *mcfg.Config, plans []PairRollbackPlan) error {
+	jobs := make([]pairJob, 0, len(plans))
+	for _, pp := range plans {
+		idx := -1
+		for i, pair := range cfg.DatabasePairs {
+			if pair.Name == pp.Pair {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("计划中的数据库对 %s 不在当前配置中", pp.Pair)
+		}
+		jobs = append(jobs, pairJob{
+			pairIndex:      idx,
+			run:            func(rm *RollbackManager) error { return rm.ApplyPlan(pp) },
+			dropCatalogSQL: pp.DropCatalogSQL,
+		})
+	}
+	return runPairJobs(cfg, jobs)
+}
+
+// pairJob 单个数据库对的回退任务
+type pairJob struct {
+	pairIndex      int
+	run            func(rm *RollbackManager) error
+	dropCatalogSQL string // 为空时按配置生成 DROP CATALOG
+	scope          common.Scope
+	keepCatalog    bool // 为 true 时不删除 Catalog（仅回退了部分表）
+}
+
+// runPairJobs 持锁逐库对执行回退任务，汇总统计后统一删除各库对的Catalog
+func runPairJobs(cfg *mcfg.Config, jobs []pairJob) error {
 	lockManager, err := lock.CreateLockManager(
 		cfg.Lock.DebugMode,
 		cfg.Lock.K8sNamespace,
@@ -341,11 +567,18 @@ func ExecuteRollbackForAllPairs(cfg *mcfg.Config) error {
 	// 缓存每个库对的管理器，便于在全部表处理完成后统一删除各自的Catalog
 	var managers []*RollbackManager
 
-	for i, pair := range cfg.DatabasePairs {
+	var catalogSQLs []string
+	var keepCatalogs []bool
+	var filteredAll []common.Filtered
+
+	for _, job := range jobs {
+		pair := cfg.DatabasePairs[job.pairIndex]
 		logger.Info("开始回退数据库对: %s", pair.Name)
 
-		rollbackManager := NewRollbackManager(cfg, i)
-		err := rollbackManager.ExecuteRollback()
+		rollbackManager := NewRollbackManager(cfg, job.pairIndex)
+		rollbackManager.scope = job.scope
+		err := job.run(rollbackManager)
+		filteredAll = append(filteredAll, rollbackManager.filtered...)
 		// 汇总当前库对的统计到全局
 		totalTables += rollbackManager.stats.TotalTables
 		successTables += rollbackManager.stats.SuccessTables
@@ -362,6 +595,8 @@ func ExecuteRollbackForAllPairs(cfg *mcfg.Config) error {
 
 		// 保存管理器以便稍后删除本库对的Catalog（复用已初始化的连接）
 		managers = append(managers, rollbackManager)
+		catalogSQLs = append(catalogSQLs, job.dropCatalogSQL)
+		keepCatalogs = append(keepCatalogs, job.keepCatalog)
 	}
 
 	// 全局统计打印
@@ -373,9 +608,26 @@ func ExecuteRollbackForAllPairs(cfg *mcfg.Config) error {
 			logger.Error("[%d] 库对: %s, 表: %s, 步骤: %s, 错误: %v", i+1, f.Pair, f.Table, string(f.Step), f.Err)
 		}
 	}
+	common.LogFiltered(filteredAll)
 	// 第二阶段：所有数据库对表处理完成后，逐库对删除各自的Catalog（DROP CATALOG IF EXISTS）
-	for _, m := range managers {
-		if err := m.DropCatalogIfExists(); err != nil {
+	// 被数据层引用的 Catalog 同时服务于其他视图，保留
+	tierCatalogs := common.TierCatalogs(cfg)
+	for i, m := range managers {
+		if keepCatalogs[i] {
+			logger.Info("数据库对 %s 仅回退了部分表，保留 Catalog %s", m.pair.Name, m.pair.CatalogName)
+			continue
+		}
+		if tierCatalogs[m.pair.CatalogName] {
+			logger.Warn("数据库对 %s 的 Catalog %s 被 view_updater.tables.*.tiers 引用，保留", m.pair.Name, m.pair.CatalogName)
+			continue
+		}
+		if catalogSQLs[i] == "" {
+			if err := m.DropCatalogIfExists(); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := m.executeDropCatalogSQL(catalogSQLs[i]); err != nil {
 			return err
 		}
 	}
@@ -389,12 +641,17 @@ func (rm *RollbackManager) DropCatalogIfExists() error {
 	if strings.TrimSpace(name) == "" {
 		return fmt.Errorf("数据库对 %s 未配置 CatalogName", rm.pair.Name)
 	}
+	return rm.executeDropCatalogSQL(builder.NewRollbackBuilder("", "").BuildDropCatalogSQL(name))
+}
+
+// executeDropCatalogSQL 执行删除当前库对Catalog的SQL（复用连接）
+func (rm *RollbackManager) executeDropCatalogSQL(sql string) error {
+	name := rm.pair.CatalogName
 	// 复用已初始化的 StarRocks 连接
 	srDB, err := rm.dbManager.GetStarRocksConnection()
 	if err != nil {
 		return fmt.Errorf("获取StarRocks连接失败: %w", err)
 	}
-	sql := builder.NewRollbackBuilder("", "").BuildDropCatalogSQL(name)
 	logger.Info("开始删除库对 %s 的Catalog: %s", rm.pair.Name, name)
 	if err := rm.dbManager.ExecuteRollbackSQLWithDB(srDB, []string{sql}, false); err != nil {
 		return fmt.Errorf("删除库对 %s 的Catalog %s 失败: %w", rm.pair.Name, name, err)
@@ -0,0 +1,389 @@
+package settings
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	mcfg "example.com/migrationLib/config"
+)
+
+// Settings cksr 自有的扩展配置：migrationLib 的 config.Config 中没有的字段，
+// 由配置加载器从同一份配置文档解析后与 *mcfg.Config 绑定
+type Settings struct {
+	Pairs       []Pair
+	ViewUpdater ViewUpdater
+	Blackout    Blackout
+}
+
+// Blackout 禁止执行 DDL 的维护窗口（顶层 blackout），规则见 internal/blackout
+type Blackout struct {
+	// Timezone 窗口时间所在的 IANA 时区，例如 Asia/Shanghai；为空时使用本地时区
+	Timezone string `json:"timezone"`
+	// Windows 维护窗口，任一生效即处于维护期
+	Windows []BlackoutWindow `json:"windows"`
+}
+
+// BlackoutWindow 单个维护窗口：cron + duration，或 weekdays + start/end，二者择一
+type BlackoutWindow struct {
+	// Name 窗口名称，用于日志与错误信息
+	Name string `json:"name"`
+	// Cron 窗口开始时间，带秒字段的 cron 表达式，例如 0 0 0 1 * * 表示每月 1 日零点
+	Cron string `json:"cron"`
+	// Duration 与 cron 搭配的窗口时长，例如 2h、3d
+	Duration string `json:"duration"`
+	// Weekdays 与 start/end 搭配的星期（mon、tue、...、sun），为空表示每天
+	Weekdays []string `json:"weekdays"`
+	// Start、End 每天的开始与结束时间（HH:MM），end 不晚于 start 时跨越午夜
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Pair 单个数据库对的扩展配置
+type Pair struct {
+	// CatalogPassword 创建 Catalog 时使用的 JDBC 密码，为空时沿用 clickhouse.password
+	CatalogPassword string
+}
+
+// ViewUpdater auto-update 的扩展配置（view_updater 下的 boundary 与 tables）
+type ViewUpdater struct {
+	// Strategy 视图更新失败时的处理：fail_fast（默认）、continue_on_error
+	Strategy string `json:"strategy"`
+	// Concurrency 单个数据库对内并行更新视图的协程数，<=1 时串行
+	Concurrency int `json:"concurrency"`
+	// ViewTimeoutSeconds 单个视图更新的超时秒数，<=0 表示不限制
+	ViewTimeoutSeconds int `json:"view_timeout_seconds"`
+	// JitterSeconds 每次触发后随机延迟 [0, JitterSeconds) 秒再开始，避免多实例或多表同时打到数据库
+	JitterSeconds int `json:"jitter_seconds"`
+	// CatchUp 进程停机期间错过调度时的处理：skip（默认，等待下次触发）、run_once（启动后立即补跑一次）
+	CatchUp string `json:"catch_up"`
+	// StateFile 记录各调度项最近执行时间的文件，为空时使用 <temp_dir>/auto_update_state.json
+	StateFile string `json:"state_file"`
+	// LeaderElection 多副本常驻时在 K8s Lease 上持续选主，只有 leader 执行调度
+	LeaderElection bool `json:"leader_election"`
+	// LeaderLeaseName 选主使用的 Lease 名称，为空时使用 <lock.lease_name>-leader
+	LeaderLeaseName string `json:"leader_lease_name"`
+	// Admin auto-update 常驻进程的 HTTP 管理接口
+	Admin Admin `json:"admin"`
+	// Metrics auto-update 常驻进程的 Prometheus 指标与健康检查接口
+	Metrics Metrics `json:"metrics"`
+	// Reconcile 每轮全局调度前的自愈阶段
+	Reconcile Reconcile `json:"reconcile"`
+	// Boundary 全局时间边界策略
+	Boundary Boundary `json:"boundary"`
+	// Tables 按视图名（基础表名）覆盖的配置
+	Tables map[string]Table `json:"tables"`
+}
+
+// Admin HTTP 管理接口配置
+type Admin struct {
+	// Enabled 为 true 时启动管理接口
+	Enabled bool `json:"enabled"`
+	// Listen 监听地址，默认 127.0.0.1:9480（仅本机可访问）；需从 Pod 外访问时显式配置如 0.0.0.0:9480，此时必须配置 TokenFile
+	Listen string `json:"listen"`
+	// TokenFile 访问令牌文件，配置后 POST 接口需携带 Authorization: Bearer <令牌>
+	TokenFile string `json:"token_file"`
+}
+
+// DefaultAdminListen 管理接口的默认监听地址
+const DefaultAdminListen = "127.0.0.1:9480"
+
+// Address 返回管理接口的监听地址
+func (a Admin) Address() string {
+	if strings.TrimSpace(a.Listen) == "" {
+		return DefaultAdminListen
+	}
+	return strings.TrimSpace(a.Listen)
+}
+
+// IsLoopback 判断监听地址是否仅本机可访问；主机为空（所有网卡）或无法解析时视为否
+func (a Admin) IsLoopback() bool {
+	host, _, err := net.SplitHostPort(a.Address())
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Token 读取访问令牌，去除末尾换行；未配置 token_file 时返回空串
+func (a Admin) Token() (string, error) {
+	if strings.TrimSpace(a.TokenFile) == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(a.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("读取管理接口令牌文件 %s 失败: %w", a.TokenFile, err)
+	}
+	token := strings.TrimRight(string(data), "\r\n")
+	if token == "" {
+		return "", fmt.Errorf("管理接口令牌文件 %s 为空", a.TokenFile)
+	}
+	return token, nil
+}
+
+// Metrics 指标与健康检查接口配置（/metrics、/healthz、/readyz）
+type Metrics struct {
+	// Enabled 为 true 时启动指标接口
+	Enabled bool `json:"enabled"`
+	// Listen 监听地址，默认 :9481（供 Prometheus 抓取与 kubelet 探针访问，不含任何写操作）
+	Listen string `json:"listen"`
+	// FailedTicks 连续失败多少轮后 /readyz 返回 503，默认 3
+	FailedTicks int `json:"failed_ticks"`
+}
+
+// DefaultMetricsListen 指标接口的默认监听地址
+const DefaultMetricsListen = ":9481"
+
+// DefaultReadinessFailedTicks 就绪检查默认允许的连续失败轮数
+const DefaultReadinessFailedTicks = 3
+
+// Address 返回指标接口的监听地址
+func (m Metrics) Address() string {
+	if strings.TrimSpace(m.Listen) == "" {
+		return DefaultMetricsListen
+	}
+	return strings.TrimSpace(m.Listen)
+}
+
+// ReadinessFailedTicks 返回就绪检查允许的连续失败轮数
+func (m Metrics) ReadinessFailedTicks() int {
+	if m.FailedTicks <= 0 {
+		return DefaultReadinessFailedTicks
+	}
+	return m.FailedTicks
+}
+
+// Reconcile 自愈阶段配置：纳管新的共同表、补建 Catalog 与缺失的视图、隔离依赖缺失的视图
+type Reconcile struct {
+	// Enabled 为 true 时在每轮全局调度（及不限定视图的手动触发）更新视图前执行自愈
+	Enabled bool `json:"enabled"`
+	// DryRun 只记录将执行的动作，不做任何变更；未配置时为 true，需显式设为 false 才会执行
+	DryRun *bool `json:"dry_run"`
+}
+
+// IsDryRun 返回自愈阶段是否只记录不执行
+func (r Reconcile) IsDryRun() bool {
+	return r.DryRun == nil || *r.DryRun
+}
+
+// Table 单个视图的扩展配置
+type Table struct {
+	// CronExpression 非空时该视图按独立的 cron 表达式（带秒字段）更新，不再参与全局调度
+	CronExpression string `json:"cron_expression"`
+	// Enabled 为 false 时 auto-update 不再更新该视图，未配置时视为启用
+	Enabled *bool `json:"enabled"`
+	// FrozenUntil 在该时间之前保持视图当前边界，格式 2006-01-02 15:04:05（本地时区）或 RFC3339
+	FrozenUntil string `json:"frozen_until"`
+	// Boundary 非空字段覆盖全局策略中的同名字段
+	Boundary Boundary `json:"boundary"`
+	// Tiers 比 CK 更早的数据层，按从早到晚排列；视图依次由各层、CK、SR 分支组成
+	Tiers []Tier `json:"tiers"`
+}
+
+// 数据层类型（Tier.Kind），取值与 builder 中的常量一致
+const (
+	TierClickHouse = "clickhouse" // 经 SR JDBC Catalog 访问的 CK 表（如另一个 CK 集群）
+	TierExternal   = "external"   // SR 外部 Catalog（Hive、Iceberg、Paimon 等）中的表，按列名对齐到 SR 表
+)
+
+// Tier 多层视图中比 CK 更早的一层数据源
+type Tier struct {
+	// Kind clickhouse（默认）或 external
+	Kind string `json:"kind"`
+	// Catalog SR 中访问该层的 Catalog，需预先创建，回滚不会删除
+	Catalog string `json:"catalog"`
+	// Database 该层表所在的库
+	Database string `json:"database"`
+	// Table 该层的表名，默认与视图同名
+	Table string `json:"table"`
+	// Boundary 该层与下一层之间的边界策略；不继承表级策略（bigint_unit 除外），未配置时为 sr_min（下一层的最小时间戳）
+	Boundary Boundary `json:"boundary"`
+}
+
+// TierKind 返回数据层类型，未配置时为 clickhouse
+func (t Tier) TierKind() string {
+	if k := strings.ToLower(strings.TrimSpace(t.Kind)); k != "" {
+		return k
+	}
+	return TierClickHouse
+}
+
+// TableName 返回该层的表名，未配置时与视图同名
+func (t Tier) TableName(view string) string {
+	if n := strings.TrimSpace(t.Table); n != "" {
+		return n
+	}
+	return view
+}
+
+// frozenLayouts frozen_until 支持的时间格式
+var frozenLayouts = []string{"2006-01-02 15:04:05", time.RFC3339, "2006-01-02"}
+
+// IsEnabled 返回视图是否参与 auto-update
+func (t Table) IsEnabled() bool {
+	return t.Enabled == nil || *t.Enabled
+}
+
+// FrozenAt 返回视图在 now 时刻是否处于冻结期，以及冻结截止时间
+func (t Table) FrozenAt(now time.Time) (bool, time.Time, error) {
+	if strings.TrimSpace(t.FrozenUntil) == "" {
+		return false, time.Time{}, nil
+	}
+	until, err := ParseFrozenUntil(t.FrozenUntil)
+	if err != nil {
+		return false, time.Time{}, err
+	}
+	return now.Before(until), until, nil
+}
+
+// ParseFrozenUntil 解析 frozen_until，未带时区的格式按本地时区解释
+func ParseFrozenUntil(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range frozenLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析冻结时间 %q（示例: 2025-06-01 08:00:00 或 2025-06-01T08:00:00+08:00）", s)
+}
+
+// Boundary 时间边界策略，字段含义见 internal/boundary
+type Boundary struct {
+	Strategy    string `json:"strategy"`    // sr_min（默认）、retention、partition_aligned、ck_max、fixed_lag
+	Retention   string `json:"retention"`   // retention：保留时长，例如 7d、36h
+	Granularity string `json:"granularity"` // partition_aligned：hour、day、month
+	Interval    string `json:"interval"`    // fixed_lag：每次推进的间隔，例如 1h
+	EmptySR     string `json:"empty_sr"`    // SR 表为空时的处理：route_to_ck（默认）、keep、fail
+	BigintUnit  string `json:"bigint_unit"` // bigint 时间戳单位：s（默认）、ms
+}
+
+// Workers 返回生效的并发数（至少为 1）
+func (v ViewUpdater) Workers() int {
+	if v.Concurrency < 1 {
+		return 1
+	}
+	return v.Concurrency
+}
+
+// ViewTimeout 返回单个视图更新的超时时间，0 表示不限制
+func (v ViewUpdater) ViewTimeout() time.Duration {
+	if v.ViewTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(v.ViewTimeoutSeconds) * time.Second
+}
+
+// Jitter 返回随机延迟的上限，0 表示不延迟
+func (v ViewUpdater) Jitter() time.Duration {
+	if v.JitterSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(v.JitterSeconds) * time.Second
+}
+
+// StatePath 返回调度状态文件路径；未配置且 temp_dir 为空时返回空串（不记录状态）
+func (v ViewUpdater) StatePath(tempDir string) string {
+	if strings.TrimSpace(v.StateFile) != "" {
+		return v.StateFile
+	}
+	if strings.TrimSpace(tempDir) == "" {
+		return ""
+	}
+	return filepath.Join(tempDir, "auto_update_state.json")
+}
+
+// LeaderLease 返回选主使用的 Lease 名称；与每轮更新使用的锁分开，一次性命令仍可在两轮之间获取锁
+func (v ViewUpdater) LeaderLease(lockLease string) string {
+	if strings.TrimSpace(v.LeaderLeaseName) != "" {
+		return v.LeaderLeaseName
+	}
+	return lockLease + "-leader"
+}
+
+var registry sync.Map // *mcfg.Config -> *Settings
+
+// Attach 将扩展配置与配置对象绑定
+func Attach(cfg *mcfg.Config, s *Settings) {
+	registry.Store(cfg, s)
+}
+
+// Of 返回与配置对象绑定的扩展配置；未绑定时返回零值（全部使用默认行为）
+func Of(cfg *mcfg.Config) *Settings {
+	if v, ok := registry.Load(cfg); ok {
+		return v.(*Settings)
+	}
+	return &Settings{}
+}
+
+// Pair 返回指定下标数据库对的扩展配置
+func (s *Settings) Pair(index int) Pair {
+	if index < 0 || index >= len(s.Pairs) {
+		return Pair{}
+	}
+	return s.Pairs[index]
+}
+
+// Table 返回指定视图的表级配置，未配置时返回零值（启用、参与全局调度）
+func (s *Settings) Table(view string) Table {
+	return s.ViewUpdater.Tables[view]
+}
+
+// ScheduledTables 返回配置了独立 cron 表达式的视图（按名称排序）
+func (s *Settings) ScheduledTables() []string {
+	var names []string
+	for name, t := range s.ViewUpdater.Tables {
+		if strings.TrimSpace(t.CronExpression) != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BoundaryFor 返回指定视图生效的时间边界策略：表级非空字段覆盖全局配置
+func (s *Settings) BoundaryFor(view string) Boundary {
+	b := s.ViewUpdater.Boundary
+	t, ok := s.ViewUpdater.Tables[view]
+	if !ok {
+		return b
+	}
+	for _, f := range []struct {
+		dst *string
+		src string
+	}{
+		{&b.Strategy, t.Boundary.Strategy},
+		{&b.Retention, t.Boundary.Retention},
+		{&b.Granularity, t.Boundary.Granularity},
+		{&b.Interval, t.Boundary.Interval},
+		{&b.EmptySR, t.Boundary.EmptySR},
+		{&b.BigintUnit, t.Boundary.BigintUnit},
+	} {
+		if f.src != "" {
+			*f.dst = f.src
+		}
+	}
+	return b
+}
+
+// TiersFor 返回视图配置的更早数据层（从早到晚），未配置时为空
+func (s *Settings) TiersFor(view string) []Tier {
+	return s.ViewUpdater.Tables[view].Tiers
+}
+
+// TierBoundaryFor 返回视图第 i 个数据层与下一层之间的边界策略：只取该层配置，bigint_unit 未配置时沿用视图的设置
+func (s *Settings) TierBoundaryFor(view string, i int) Boundary {
+	b := s.TiersFor(view)[i].Boundary
+	if b.BigintUnit == "" {
+		b.BigintUnit = s.BoundaryFor(view).BigintUnit
+	}
+	return b
+}
@@ -0,0 +1,201 @@
+package statusrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"cksr/builder"
+	"cksr/internal/common"
+	"cksr/internal/rollbackrun"
+	"cksr/logger"
+
+	mcfg "example.com/migrationLib/config"
+	"example.com/migrationLib/retry"
+)
+
+// 表状态
+const (
+	StateIgnored             = "ignored"               // 在 ignore_tables 中
+	StateUntouched           = "untouched"             // SR 基础名为原生表，CK 未增列
+	StateCKColumnsAdded      = "ck_columns_added"      // SR 基础名为原生表，CK 已增列（init 中断于重命名前）
+	StateRenamedNoView       = "renamed_no_view"       // 已重命名为后缀表，基础名视图不存在
+	StateInitialized         = "initialized"           // 后缀表与基础名视图均存在
+	StateViewWithoutSuffixed = "view_without_suffixed" // 基础名视图存在，但后缀表缺失
+	StateConflict            = "conflict"              // 基础名原生表与后缀表同时存在
+	StateNotInSR             = "not_in_sr"             // SR 中既无基础名也无后缀名
+)
+
+// 输出格式
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// TableStatus 单表状态
+type TableStatus struct {
+	Table          string                `json:"table"`
+	State          string                `json:"state"`
+	SRBaseType     string                `json:"sr_base_type,omitempty"`
+	SRSuffixedType string                `json:"sr_suffixed_type,omitempty"`
+	CKAddedColumns []string              `json:"ck_added_columns,omitempty"`
+	Boundary       *builder.ViewBoundary `json:"boundary,omitempty"`
+}
+
+// PairStatus 单个数据库对的状态
+type PairStatus struct {
+	Pair          string         `json:"pair"`
+	CatalogName   string         `json:"catalog_name"`
+	CatalogExists bool           `json:"catalog_exists"`
+	Summary       map[string]int `json:"summary"`
+	Tables        []TableStatus  `json:"tables"`
+}
+
+// Report 全部数据库对的状态报告
+type Report struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Pairs       []PairStatus `json:"pairs"`
+}
+
+// Run 统一入口：采集所有数据库对的状态并按格式输出
+func Run(cfg *mcfg.Config, format string, w io.Writer) error {
+	report, err := Collect(cfg)
+	if err != nil {
+		return err
+	}
+	return Render(w, report, format)
+}
+
+// Collect 采集所有数据库对的状态（只读）
+func Collect(cfg *mcfg.Config) (*Report, error) {
+	report := &Report{GeneratedAt: time.Now()}
+	for i, pair := range cfg.DatabasePairs {
+		logger.Info("开始采集数据库对 %s 的状态", pair.Name)
+		ps, err := collectPair(cfg, i)
+		if err != nil {
+			return nil, fmt.Errorf("采集数据库对 %s 的状态失败: %w", pair.Name, err)
+		}
+		report.Pairs = append(report.Pairs, ps)
+	}
+	return report, nil
+}
+
+func collectPair(cfg *mcfg.Config, pairIndex int) (PairStatus, error) {
+	pair := cfg.DatabasePairs[pairIndex]
+	ps := PairStatus{Pair: pair.Name, CatalogName: pair.CatalogName, Summary: make(map[string]int)}
+
+	facts, dbManager, err := rollbackrun.DiscoverPair(cfg, pairIndex)
+	if err != nil {
+		return ps, err
+	}
+	srDB, err := dbManager.GetStarRocksConnection()
+	if err != nil {
+		return ps, fmt.Errorf("获取StarRocks连接失败: %w", err)
+	}
+	retryConfig := retry.Config{
+		MaxRetries: cfg.Retry.MaxRetries,
+		Delay:      time.Duration(cfg.Retry.DelayMs) * time.Millisecond,
+	}
+
+	ps.CatalogExists, err = common.CatalogExists(srDB, retryConfig, pair.CatalogName)
+	if err != nil {
+		return ps, err
+	}
+	viewDefs, err := common.ViewDefinitions(srDB, retryConfig, pair.StarRocks.Database)
+	if err != nil {
+		return ps, err
+	}
+
+	for _, f := range facts {
+		ts := TableStatus{
+			Table:          f.BaseTable,
+			State:          Classify(f),
+			SRBaseType:     f.SRBaseType,
+			SRSuffixedType: f.SRSuffixedType,
+			CKAddedColumns: f.CKAddedColumns,
+		}
+		if f.SRBaseIsView() {
+			if b, ok := builder.ParseViewBoundary(viewDefs[f.BaseTable]); ok {
+				ts.Boundary = &b
+			} else {
+				logger.Debug("视图 %s 的定义中未解析到时间边界", f.BaseTable)
+			}
+		}
+		ps.Summary[ts.State]++
+		ps.Tables = append(ps.Tables, ts)
+	}
+	return ps, nil
+}
+
+// Classify 按现状判定单表状态
+func Classify(f rollbackrun.TableFacts) string {
+	switch {
+	case f.Ignored:
+		return StateIgnored
+	case f.SRBaseIsView() && f.SRSuffixedExists:
+		return StateInitialized
+	case f.SRBaseIsView():
+		return StateViewWithoutSuffixed
+	case f.SRSuffixedExists && f.SRBaseExists:
+		return StateConflict
+	case f.SRSuffixedExists:
+		return StateRenamedNoView
+	case f.SRBaseExists && len(f.CKAddedColumns) > 0:
+		return StateCKColumnsAdded
+	case f.SRBaseExists:
+		return StateUntouched
+	default:
+		return StateNotInSR
+	}
+}
+
+// Render 按格式输出状态报告
+func Render(w io.Writer, report *Report, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	case FormatTable, "":
+		return renderTable(w, report)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s，仅支持 %s、%s", format, FormatTable, FormatJSON)
+	}
+}
+
+func renderTable(w io.Writer, report *Report) error {
+	for _, ps := range report.Pairs {
+		fmt.Fprintf(w, "== 数据库对: %s  Catalog: %s (存在: %v)\n", ps.Pair, ps.CatalogName, ps.CatalogExists)
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "TABLE\tSTATE\tBOUNDARY\tCK_ADDED_COLUMNS")
+		for _, t := range ps.Tables {
+			boundary := "-"
+			if t.Boundary != nil {
+				boundary = fmt.Sprintf("%s >= %s", t.Boundary.Column, t.Boundary.Value)
+			}
+			added := "-"
+			if len(t.CKAddedColumns) > 0 {
+				added = strings.Join(t.CKAddedColumns, ",")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.Table, t.State, boundary, added)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		states := make([]string, 0, len(ps.Summary))
+		for s := range ps.Summary {
+			states = append(states, s)
+		}
+		sort.Strings(states)
+		var parts []string
+		for _, s := range states {
+			parts = append(parts, fmt.Sprintf("%s=%d", s, ps.Summary[s]))
+		}
+		fmt.Fprintf(w, "汇总: %s\n\n", strings.Join(parts, ", "))
+	}
+	return nil
+}
@@ -3,12 +3,16 @@ package updaterun
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	vbuilder "cksr/builder"
+	"cksr/internal/boundary"
 	"cksr/internal/common"
+	"cksr/internal/metacache"
+	"cksr/internal/settings"
 	"cksr/lock"
 	"cksr/logger"
 
@@ -16,18 +20,35 @@ import (
 	mcfg "example.com/migrationLib/config"
 	ckc "example.com/migrationLib/convert"
 	mdb "example.com/migrationLib/database"
+	p "example.com/migrationLib/parser"
 	"example.com/migrationLib/retry"
 )
 
 // UpdateTarget 单次更新目标
 type UpdateTarget struct {
-	ViewName     string
-	Partition    string
-	HasPartition bool
+	ViewName string
+	// Boundaries 各边界值（从早到晚）；普通视图只有一个，多层视图为数据层数加一，最后一个为 CK 与 SR 之间的边界
+	Boundaries []string
 }
 
-// RunOnceForTargets 一次性更新：按数据库对与视图名+分区值列表更新对应视图
-func RunOnceForTargets(cfg *mcfg.Config, pairName string, targets []UpdateTarget) error {
+// 单个视图的更新结果
+const (
+	OutcomeUpdated   = "updated"   // 已执行 ALTER VIEW
+	OutcomeUnchanged = "unchanged" // 边界与列均未变化，跳过 ALTER VIEW
+)
+
+// Metadata 生成视图SQL所需的元数据来源；*mdb.DatabasePairManager 与 *metacache.Cache 均满足
+type Metadata interface {
+	vbuilder.DatabaseManager
+	ExportClickHouseTablesAsParserTables() (map[string]p.Table, error)
+	GetStarRocksTableDDL(table string) (string, error)
+}
+
+// ErrBoundaryRegression 新边界早于视图当前边界，单调性保护拒绝执行（update 可用 --force 跳过保护）
+var ErrBoundaryRegression = errors.New("新边界早于视图当前边界")
+
+// RunOnceForTargets 一次性更新：按数据库对与视图名+分区值列表更新对应视图；force 为 true 时允许边界回退
+func RunOnceForTargets(cfg *mcfg.Config, pairName string, targets []UpdateTarget, force bool) error {
 	// 查找数据库对索引
 	var pairIndex int
 	var pair mcfg.DatabasePair
@@ -75,68 +96,193 @@ func RunOnceForTargets(cfg *mcfg.Config, pairName string, targets []UpdateTarget
 	}
 	defer releaseLock()
 
-	// 逐个更新指定视图
 	for _, t := range targets {
 		viewName := strings.TrimSpace(t.ViewName)
 		if viewName == "" {
 			return fmt.Errorf("存在空的视图名")
 		}
-		if !t.HasPartition {
+		if len(t.Boundaries) == 0 {
 			return fmt.Errorf("视图 %s 缺少分区时间值", viewName)
 		}
-		if err := UpdateSingleView(cfg, srDB, chDB, dbManager, pair, viewName, t.Partition, t.HasPartition); err != nil {
+	}
+
+	// 本次运行内共用元数据缓存：CK 表结构只导出一次，SR DDL 一次性预取
+	vs := settings.Of(cfg).ViewUpdater
+	meta := metacache.New(pair.Name, dbManager)
+	defer meta.Invalidate()
+	srTables := make([]string, len(targets))
+	for i, t := range targets {
+		srTables[i] = strings.TrimSpace(t.ViewName) + pair.SRTableSuffix
+	}
+	meta.PrefetchSRDDL(srTables, vs.Workers())
+
+	// 按 view_updater.concurrency 并行更新，任一失败后不再派发新视图
+	outcomes := make([]string, len(targets))
+	errs := common.RunBounded(len(targets), vs.Workers(), vs.ViewTimeout(), true, func(ctx context.Context, i int) error {
+		t := targets[i]
+		outcome, err := UpdateSingleView(ctx, cfg, srDB, chDB, meta, pair, strings.TrimSpace(t.ViewName), t.Boundaries, force)
+		outcomes[i] = outcome
+		return err
+	})
+
+	// 按传入顺序输出结果
+	var firstErr error
+	for i, t := range targets {
+		viewName := strings.TrimSpace(t.ViewName)
+		outcome, err := outcomes[i], errs[i]
+		if errors.Is(err, common.ErrNotRun) {
+			logger.Warn("视图 %s 未执行（前序视图失败）", viewName)
+			continue
+		}
+		if err != nil {
 			logger.Error("更新视图 %s 失败: %v", viewName, err)
-			return err
+			if errors.Is(err, ErrBoundaryRegression) {
+				logger.Error("如确需回退边界，请使用 --force")
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if outcome == OutcomeUnchanged {
+			logger.Info("视图 %s 无变化，已跳过", viewName)
+			continue
 		}
 		logger.Info("视图 %s 更新成功", viewName)
 	}
 
-	return nil
+	return firstErr
 }
 
-// UpdateSingleView 通用更新单个视图的逻辑，可选传入分区时间值
-func UpdateSingleView(cfg *mcfg.Config, srDB, chDB *sql.DB, dbManager *mdb.DatabasePairManager, pair mcfg.DatabasePair, viewName string, partitionValue string, hasPartition bool) error {
+// UpdateSingleView 通用更新单个视图的逻辑，boundaries 为从早到晚的各边界值（多层视图为数据层数加一个）
+// 与线上视图相比边界与列均未变化时跳过 ALTER VIEW；任一边界早于当前对应边界时返回 ErrBoundaryRegression，force 为 true 时不做该检查。
+// 批量更新时应传入 *metacache.Cache，避免每个视图重复导出 CK 表结构。
+// ctx 超时后不再开始新的步骤并返回超时错误；ALTER VIEW 一旦开始即执行完毕，不受 ctx 超时影响，避免结果不确定
+func UpdateSingleView(ctx context.Context, cfg *mcfg.Config, srDB, chDB *sql.DB, meta Metadata, pair mcfg.DatabasePair, viewName string, boundaries []string, force bool) (string, error) {
 	// 一次性更新必须显式提供分区值，不允许走自动推断逻辑
-	if !hasPartition {
-		return fmt.Errorf("一次性更新缺少分区时间值")
+	if len(boundaries) == 0 {
+		return "", fmt.Errorf("一次性更新缺少分区时间值")
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	// 获取ClickHouse表结构（直接构造 parser.Table）
+	ckTablesMap, err := meta.ExportClickHouseTablesAsParserTables()
+	if err != nil {
+		return "", fmt.Errorf("导出ClickHouse表结构失败: %w", err)
+	}
+
+	alterViewSQL, err := BuildAlterViewSQL(cfg, meta, pair, ckTablesMap, viewName, boundaries)
+	if err != nil {
+		return "", err
+	}
+
+	retryConfig := retry.Config{
+		MaxRetries: cfg.Retry.MaxRetries,
+		Delay:      time.Duration(cfg.Retry.DelayMs) * time.Millisecond,
+	}
+
+	// 与线上视图比较：无变化则跳过，边界回退则拒绝
+	live, exists, err := common.ViewDefinition(ctx, srDB, retryConfig, pair.StarRocks.Database, viewName)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		unchanged, err := checkAgainstLive(alterViewSQL, live, viewName, force)
+		if err != nil {
+			return "", err
+		}
+		if unchanged {
+			logger.Debug("视图 %s 的边界与列均未变化，跳过ALTER VIEW", viewName)
+			return OutcomeUnchanged, nil
+		}
+	}
+
+	// 超时前尚未开始 ALTER VIEW 时放弃，视图保持原状
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("执行ALTER VIEW前已超时: %w", err)
 	}
+	// 执行ALTER VIEW语句（带重试）
+	if err := common.ExecContextWithRetry(context.WithoutCancel(ctx), srDB, retryConfig, alterViewSQL); err != nil {
+		return "", fmt.Errorf("执行ALTER VIEW语句失败: %w", err)
+	}
+	if ctx.Err() != nil {
+		logger.Warn("视图 %s 的 ALTER VIEW 在超时后完成，已按更新成功处理", viewName)
+	}
+
+	logger.Info("视图 %s 已使用ALTER VIEW更新", viewName)
+	return OutcomeUpdated, nil
+}
+
+// checkAgainstLive 比较待执行的视图SQL与线上定义，返回是否无变化；
+// 任一新边界早于线上对应边界（且线上不是哨兵最大值）时返回 ErrBoundaryRegression。
+// 边界个数不同（增减了数据层）时只比较 CK 与 SR 之间的边界
+func checkAgainstLive(alterViewSQL, live, viewName string, force bool) (bool, error) {
+	next := vbuilder.ParseViewBoundaries(alterViewSQL)
+	cur := vbuilder.ParseViewBoundaries(live)
+	if len(next) != len(cur) && len(next) > 0 && len(cur) > 0 {
+		next, cur = next[len(next)-1:], cur[len(cur)-1:]
+	}
+	for i := 0; i < len(next) && i < len(cur) && !force; i++ {
+		if boundary.IsSentinel(cur[i].Value) {
+			continue
+		}
+		cmp, err := boundary.Compare(next[i].Value, cur[i].Value)
+		if err != nil {
+			logger.Warn("视图 %s 的边界无法比较，跳过单调性检查: %v", viewName, err)
+		} else if cmp < 0 {
+			logger.Warn("异常：视图 %s 新边界 %s 早于当前边界 %s，拒绝执行", viewName, next[i].Value, cur[i].Value)
+			return false, fmt.Errorf("视图 %s: %w（新边界 %s，当前边界 %s）", viewName, ErrBoundaryRegression, next[i].Value, cur[i].Value)
+		}
+	}
+
+	expected, err := vbuilder.ParseViewStructure(alterViewSQL)
+	if err != nil {
+		return false, nil
+	}
+	actual, err := vbuilder.ParseViewStructure(live)
+	if err != nil {
+		// 线上定义无法解析时按有变化处理，由 ALTER VIEW 覆盖
+		logger.Debug("解析视图 %s 的线上定义失败，按有变化处理: %v", viewName, err)
+		return false, nil
+	}
+	return !vbuilder.CompareViews(expected, actual).HasDrift(), nil
+}
+
+// BuildAlterViewSQL 基于当前CK/SR表结构、配置的数据层与给定边界值（从早到晚）生成 ALTER VIEW SQL（只读，不执行）
+func BuildAlterViewSQL(cfg *mcfg.Config, meta Metadata, pair mcfg.DatabasePair, ckTablesMap map[string]p.Table, viewName string, boundaries []string) (string, error) {
 	// 根据视图名和配置后缀生成StarRocks表名
 	srTableName := viewName + pair.SRTableSuffix
 
 	originalTableName := viewName
 
-	// 获取ClickHouse表结构（直接构造 parser.Table）
-	ckTablesMap, err := dbManager.ExportClickHouseTablesAsParserTables()
-	if err != nil {
-		return fmt.Errorf("导出ClickHouse表结构失败: %w", err)
-	}
 	ckTable, exists := ckTablesMap[originalTableName]
 	if !exists {
-		return fmt.Errorf("未找到ClickHouse表 %s 的结构", originalTableName)
+		return "", fmt.Errorf("未找到ClickHouse表 %s 的结构", originalTableName)
 	}
 
 	// 获取StarRocks表结构
-	srDDL, err := dbManager.GetStarRocksTableDDL(srTableName)
+	srDDL, err := meta.GetStarRocksTableDDL(srTableName)
 	if err != nil {
-		return fmt.Errorf("获取StarRocks表%s的DDL失败: %w", srTableName, err)
+		return "", fmt.Errorf("获取StarRocks表%s的DDL失败: %w", srTableName, err)
 	}
 
 	// 解析StarRocks表结构
 	srTable, err := common.ParseTableFromString(srDDL, pair.StarRocks.Database, srTableName, time.Duration(cfg.Parser.DDLParseTimeoutSeconds)*time.Second)
 	if err != nil {
-		return fmt.Errorf("解析StarRocks表%s失败: %w", srTableName, err)
+		return "", fmt.Errorf("解析StarRocks表%s失败: %w", srTableName, err)
 	}
 
 	// 创建字段转换器
 	fieldConverters, err := ckc.NewConverters(ckTable, mlcommon.ScenarioView)
 	if err != nil {
-		return fmt.Errorf("创建字段转换器失败: %w", err)
+		return "", fmt.Errorf("创建字段转换器失败: %w", err)
 	}
 
 	// 获取catalog名称
 	catalogName := pair.CatalogName
 	if catalogName == "" {
-		return fmt.Errorf("catalog名称为空")
+		return "", fmt.Errorf("catalog名称为空")
 	}
 
 	// 创建ViewBuilder并生成ALTER VIEW SQL
@@ -145,25 +291,15 @@ func UpdateSingleView(cfg *mcfg.Config, srDB, chDB *sql.DB, dbManager *mdb.Datab
 		srTable.Field,
 		ckTable.DDL.DBName, ckTable.DDL.TableName, catalogName,
 		srTable.DDL.DBName, srTable.DDL.TableName,
-		dbManager,
+		meta,
 		cfg,
 	)
 
-	alterViewSQL, err := viewBuilder.BuildAlterWithPartition(partitionValue)
-	if err != nil {
-		return fmt.Errorf("构建ALTER VIEW SQL失败: %w", err)
-	}
+	viewBuilder.SetTiers(common.ViewTiers(cfg, viewName))
 
-	// 执行ALTER VIEW语句（带重试）
-	retryConfig := retry.Config{
-		MaxRetries: cfg.Retry.MaxRetries,
-		Delay:      time.Duration(cfg.Retry.DelayMs) * time.Millisecond,
-	}
-	if err := retry.ExecWithRetry(srDB, retryConfig, alterViewSQL); err != nil {
-		return fmt.Errorf("执行ALTER VIEW语句失败: %w", err)
+	alterViewSQL, err := viewBuilder.BuildAlterWithBoundaries(boundaries)
+	if err != nil {
+		return "", fmt.Errorf("构建ALTER VIEW SQL失败: %w", err)
 	}
-
-	logger.Info("视图 %s 已使用ALTER VIEW更新", viewName)
-	return nil
+	return alterViewSQL, nil
 }
-
@@ -0,0 +1,161 @@
+/*
+ * @File : leader
+ * @Description: 基于 k8s Lease 的持续选主，多副本常驻时只有 leader 执行调度
+ */
+
+package lock
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cksr/logger"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderStatus 选主状态快照
+type LeaderStatus struct {
+	Identity string    `json:"identity"`  // 本实例身份
+	Leader   string    `json:"leader"`    // 当前观察到的 leader，未知时为空
+	IsLeader bool      `json:"is_leader"` // 本实例是否为 leader
+	Since    time.Time `json:"since"`     // 最近一次 leader 变化的时间
+}
+
+// LeaderCallbacks 选主回调：成为 leader 时 OnStartedLeading 的 ctx 在失去 leader 身份时取消；
+// OnNewLeader 在观察到的 leader 变化时调用（包括本实例成为 leader），可为空
+type LeaderCallbacks struct {
+	OnStartedLeading func(ctx context.Context)
+	OnStoppedLeading func()
+	OnNewLeader      func(identity string)
+}
+
+// LeaderElection 在指定 Lease 上持续参与选主；失去 leader 身份后自动重新成为候选者，直到 ctx 取消
+type LeaderElection struct {
+	client    kubernetes.Interface
+	namespace string
+	leaseName string
+	identity  string
+	duration  time.Duration
+
+	mu     sync.RWMutex
+	status LeaderStatus
+	// leading 是否处于 OnStartedLeading 与 OnStoppedLeading 之间；续约失败前可能已观察到新 leader，
+	// 此时 status.IsLeader 已为 false，不能据此判断是否需要回调 OnStoppedLeading
+	leading bool
+}
+
+// NewLeaderElection 创建选主器；client 可传入 fake clientset 用于测试
+func NewLeaderElection(client kubernetes.Interface, namespace, leaseName, identity string, duration time.Duration) (*LeaderElection, error) {
+	if duration <= 0 {
+		return nil, fmt.Errorf("选主租期必须为正数，当前为 %s", duration)
+	}
+	return &LeaderElection{
+		client:    client,
+		namespace: namespace,
+		leaseName: leaseName,
+		identity:  identity,
+		duration:  duration,
+		status:    LeaderStatus{Identity: identity},
+	}, nil
+}
+
+// NewInClusterLeaderElection 使用 Pod 内的 ServiceAccount 创建选主器
+func NewInClusterLeaderElection(namespace, leaseName, identity string, duration time.Duration) (*LeaderElection, error) {
+	client, err := newInClusterClient()
+	if err != nil {
+		return nil, err
+	}
+	return NewLeaderElection(client, namespace, leaseName, identity, duration)
+}
+
+// Status 返回当前选主状态
+func (l *LeaderElection) Status() LeaderStatus {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.status
+}
+
+// Run 持续参与选主直到 ctx 取消；ctx 取消时若为 leader 会主动释放 Lease，便于备实例立即接管。
+// 续约期限为租期的 2/3，重试间隔为租期的 1/5：leader 异常退出后备实例最迟在一个租期后接管
+func (l *LeaderElection) Run(ctx context.Context, cb LeaderCallbacks) error {
+	rl := &resourcelock.LeaseLock{
+		LeaseMeta:  metav1.ObjectMeta{Name: l.leaseName, Namespace: l.namespace},
+		Client:     l.client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{Identity: l.identity},
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            rl,
+		LeaseDuration:   l.duration,
+		RenewDeadline:   l.duration * 2 / 3,
+		RetryPeriod:     l.duration / 5,
+		ReleaseOnCancel: true,
+		Name:            l.leaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leadCtx context.Context) {
+				l.mu.Lock()
+				l.leading = true
+				l.mu.Unlock()
+				if l.setLeader(l.identity) && cb.OnNewLeader != nil {
+					cb.OnNewLeader(l.identity)
+				}
+				logger.Info("成为 leader (lease: %s/%s, 身份: %s)", l.namespace, l.leaseName, l.identity)
+				if cb.OnStartedLeading != nil {
+					cb.OnStartedLeading(leadCtx)
+				}
+			},
+			OnStoppedLeading: func() {
+				// client-go 在每次 Run 返回时都会调用，未曾成为 leader 时忽略
+				l.mu.Lock()
+				wasLeading := l.leading
+				l.leading = false
+				l.status.IsLeader = false
+				l.mu.Unlock()
+				if !wasLeading {
+					return
+				}
+				logger.Warn("失去 leader 身份 (lease: %s/%s, 身份: %s)", l.namespace, l.leaseName, l.identity)
+				if cb.OnStoppedLeading != nil {
+					cb.OnStoppedLeading()
+				}
+			},
+			OnNewLeader: func(identity string) {
+				if identity == l.identity {
+					return
+				}
+				if l.setLeader(identity) && cb.OnNewLeader != nil {
+					cb.OnNewLeader(identity)
+				}
+				logger.Info("当前 leader: %s，本实例 %s 待命", identity, l.identity)
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("创建选主器失败: %w", err)
+	}
+
+	logger.Info("参与选主 (lease: %s/%s, 身份: %s, 租期: %s)", l.namespace, l.leaseName, l.identity, l.duration)
+	for ctx.Err() == nil {
+		// Run 在失去 leader 身份或 ctx 取消时返回，未取消则重新参与选主
+		elector.Run(ctx)
+	}
+	return nil
+}
+
+// setLeader 记录观察到的 leader 及变化时间，返回 leader 是否变化
+func (l *LeaderElection) setLeader(identity string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	changed := l.status.Leader != identity
+	if changed {
+		l.status.Since = time.Now()
+	}
+	l.status.Leader = identity
+	l.status.IsLeader = identity == l.identity
+	return changed
+}
@@ -0,0 +1,262 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const (
+	testNamespace = "default"
+	testLease     = "cksr-test"
+	testDuration  = time.Second
+	waitTimeout   = 10 * time.Second
+)
+
+// recorder 将选主回调转为 channel，便于按顺序断言
+type recorder struct {
+	started chan context.Context
+	stopped chan struct{}
+	leaders chan string
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		started: make(chan context.Context, 4),
+		stopped: make(chan struct{}, 4),
+		leaders: make(chan string, 8),
+	}
+}
+
+func (r *recorder) callbacks() LeaderCallbacks {
+	return LeaderCallbacks{
+		OnStartedLeading: func(ctx context.Context) { r.started <- ctx },
+		OnStoppedLeading: func() { r.stopped <- struct{}{} },
+		OnNewLeader:      func(identity string) { r.leaders <- identity },
+	}
+}
+
+// runElection 启动选主并返回停止函数，停止函数等待 Run 返回
+func runElection(t *testing.T, client kubernetes.Interface, identity string, cb LeaderCallbacks) (*LeaderElection, func()) {
+	t.Helper()
+	le, err := NewLeaderElection(client, testNamespace, testLease, identity, testDuration)
+	if err != nil {
+		t.Fatalf("创建选主器失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := le.Run(ctx, cb); err != nil {
+			t.Errorf("Run 返回错误: %v", err)
+		}
+	}()
+	stop := func() {
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(waitTimeout):
+			t.Fatalf("%s 的 Run 未在 ctx 取消后返回", identity)
+		}
+	}
+	t.Cleanup(stop)
+	return le, stop
+}
+
+var leasesResource = coordinationv1.SchemeGroupVersion.WithResource("leases")
+
+// putLease 以 holder 的身份写入 Lease（刚续约、租期 seconds 秒），模拟另一个实例持有后不再续约；
+// 直接写入 tracker，不经过 rejectForeignRenew
+func putLease(t *testing.T, client *fake.Clientset, holder string, seconds int32) {
+	t.Helper()
+	now := metav1.NewMicroTime(time.Now())
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: testLease, Namespace: testNamespace},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &seconds,
+			AcquireTime:          &now,
+			RenewTime:            &now,
+		},
+	}
+	if _, err := client.Tracker().Get(leasesResource, testNamespace, testLease); err != nil {
+		if err := client.Tracker().Create(leasesResource, lease, testNamespace); err != nil {
+			t.Fatalf("创建 Lease 失败: %v", err)
+		}
+		return
+	}
+	if err := client.Tracker().Update(leasesResource, lease, testNamespace); err != nil {
+		t.Fatalf("更新 Lease 失败: %v", err)
+	}
+}
+
+// rejectForeignRenew 模拟 API Server 的乐观并发：fake clientset 不校验 resourceVersion，
+// leader 续约会直接覆盖他人写入的 Lease；此处在 Lease 由其他未过期的持有者持有时拒绝更新
+func rejectForeignRenew(client *fake.Clientset) {
+	client.PrependReactor("update", "leases", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		incoming, ok := action.(k8stesting.UpdateAction).GetObject().(*coordinationv1.Lease)
+		if !ok || incoming.Spec.HolderIdentity == nil || *incoming.Spec.HolderIdentity == "" {
+			return false, nil, nil
+		}
+		obj, err := client.Tracker().Get(leasesResource, incoming.Namespace, incoming.Name)
+		if err != nil {
+			return false, nil, nil
+		}
+		current := obj.(*coordinationv1.Lease)
+		if current.Spec.HolderIdentity == nil || *current.Spec.HolderIdentity == *incoming.Spec.HolderIdentity ||
+			current.Spec.RenewTime == nil || current.Spec.LeaseDurationSeconds == nil {
+			return false, nil, nil
+		}
+		expiry := current.Spec.RenewTime.Add(time.Duration(*current.Spec.LeaseDurationSeconds) * time.Second)
+		if time.Now().After(expiry) {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewConflict(coordinationv1.Resource("leases"), incoming.Name, errors.New("Lease 由其他实例持有"))
+	})
+}
+
+func holderOf(t *testing.T, client *fake.Clientset) string {
+	t.Helper()
+	lease, err := client.CoordinationV1().Leases(testNamespace).Get(context.Background(), testLease, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("读取 Lease 失败: %v", err)
+	}
+	if lease.Spec.HolderIdentity == nil {
+		return ""
+	}
+	return *lease.Spec.HolderIdentity
+}
+
+func waitStarted(t *testing.T, r *recorder, who string) context.Context {
+	t.Helper()
+	select {
+	case ctx := <-r.started:
+		return ctx
+	case <-time.After(waitTimeout):
+		t.Fatalf("%s 未成为 leader", who)
+	}
+	return nil
+}
+
+func waitLeader(t *testing.T, r *recorder, want string) {
+	t.Helper()
+	deadline := time.After(waitTimeout)
+	for {
+		select {
+		case got := <-r.leaders:
+			if got == want {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("未观察到 leader %s", want)
+		}
+	}
+}
+
+func TestLeaderElectionAcquire(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	r := newRecorder()
+	le, stop := runElection(t, client, "a", r.callbacks())
+
+	waitStarted(t, r, "a")
+	waitLeader(t, r, "a")
+	st := le.Status()
+	if !st.IsLeader || st.Leader != "a" || st.Since.IsZero() {
+		t.Fatalf("成为 leader 后状态不正确: %+v", st)
+	}
+	if h := holderOf(t, client); h != "a" {
+		t.Fatalf("Lease 持有者为 %q，期望 a", h)
+	}
+
+	// ctx 取消时释放 Lease 并回调 OnStoppedLeading
+	stop()
+	select {
+	case <-r.stopped:
+	default:
+		t.Fatal("ctx 取消后未回调 OnStoppedLeading")
+	}
+	if h := holderOf(t, client); h != "" {
+		t.Fatalf("ctx 取消后 Lease 应已释放，持有者为 %q", h)
+	}
+	if le.Status().IsLeader {
+		t.Fatal("ctx 取消后不应仍为 leader")
+	}
+}
+
+func TestLeaderElectionStandbyTakesOver(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	putLease(t, client, "dead", 1)
+
+	r := newRecorder()
+	le, _ := runElection(t, client, "b", r.callbacks())
+
+	// 持有者未过期前待命，并记录观察到的 leader
+	waitLeader(t, r, "dead")
+	if st := le.Status(); st.IsLeader || st.Leader != "dead" {
+		t.Fatalf("待命时状态不正确: %+v", st)
+	}
+	select {
+	case <-r.started:
+		t.Fatal("持有者租期未过期时不应接管")
+	default:
+	}
+
+	// 持有者不再续约，租期过后备实例接管
+	waitStarted(t, r, "b")
+	waitLeader(t, r, "b")
+	if st := le.Status(); !st.IsLeader || st.Leader != "b" {
+		t.Fatalf("接管后状态不正确: %+v", st)
+	}
+	if h := holderOf(t, client); h != "b" {
+		t.Fatalf("Lease 持有者为 %q，期望 b", h)
+	}
+}
+
+func TestLeaderElectionLoseAndRegain(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	rejectForeignRenew(client)
+	r := newRecorder()
+	le, _ := runElection(t, client, "a", r.callbacks())
+
+	leadCtx := waitStarted(t, r, "a")
+
+	// 另一实例抢占 Lease：续约失败后失去 leader 身份，OnStartedLeading 的 ctx 被取消；
+	// 抢占者的租期需长于续约期限，否则本实例可能在放弃前等到租期过期并重新续约成功
+	putLease(t, client, "other", 3)
+	select {
+	case <-r.stopped:
+	case <-time.After(waitTimeout):
+		t.Fatal("Lease 被抢占后未回调 OnStoppedLeading")
+	}
+	select {
+	case <-leadCtx.Done():
+	case <-time.After(waitTimeout):
+		t.Fatal("失去 leader 身份后 OnStartedLeading 的 ctx 未取消")
+	}
+	waitLeader(t, r, "other")
+	if le.Status().IsLeader {
+		t.Fatal("失去 leader 身份后状态仍为 leader")
+	}
+
+	// 抢占者不再续约，本实例重新参与选主并再次成为 leader
+	waitStarted(t, r, "a")
+	waitLeader(t, r, "a")
+	if st := le.Status(); !st.IsLeader || st.Leader != "a" {
+		t.Fatalf("重新成为 leader 后状态不正确: %+v", st)
+	}
+}
+
+func TestNewLeaderElectionRejectsNonPositiveDuration(t *testing.T) {
+	if _, err := NewLeaderElection(fake.NewSimpleClientset(), testNamespace, testLease, "a", 0); err == nil {
+		t.Fatal("租期为 0 时应返回错误")
+	}
+}
@@ -16,6 +16,7 @@ import (
 
 	"cksr/logger"
 
+	authorizationv1 "k8s.io/api/authorization/v1"
 	coordinationv1 "k8s.io/api/coordination/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -77,14 +78,9 @@ type K8sLeaseLockManager struct {
 
 // NewK8sLeaseLockManager 创建k8s lease锁管理器
 func NewK8sLeaseLockManager(namespace, leaseName, identity string, duration time.Duration) (*K8sLeaseLockManager, error) {
-	config, err := rest.InClusterConfig()
-	if err != nil {
-		return nil, fmt.Errorf("获取k8s集群配置失败: %w", err)
-	}
-
-	client, err := kubernetes.NewForConfig(config)
+	client, err := newInClusterClient()
 	if err != nil {
-		return nil, fmt.Errorf("创建k8s客户端失败: %w", err)
+		return nil, err
 	}
 
 	return &K8sLeaseLockManager{
@@ -96,6 +92,57 @@ func NewK8sLeaseLockManager(namespace, leaseName, identity string, duration time
 	}, nil
 }
 
+// newInClusterClient 使用 Pod 内的 ServiceAccount 创建k8s客户端
+func newInClusterClient() (kubernetes.Interface, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, fmt.Errorf("获取k8s集群配置失败: %w", err)
+	}
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("创建k8s客户端失败: %w", err)
+	}
+	return client, nil
+}
+
+// LeaseVerbs 获取与释放 lease 锁所需的全部操作
+var LeaseVerbs = []string{"get", "create", "update", "delete"}
+
+// CheckLeasePermissions 通过 SelfSubjectAccessReview 检查当前身份对 lease 的权限，返回缺失的操作
+// 只做权限询问，不会创建或修改 lease
+func CheckLeasePermissions(ctx context.Context, namespace, leaseName string) ([]string, error) {
+	client, err := newInClusterClient()
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, verb := range LeaseVerbs {
+		attrs := &authorizationv1.ResourceAttributes{
+			Namespace: namespace,
+			Verb:      verb,
+			Group:     coordinationv1.GroupName,
+			Resource:  "leases",
+		}
+		// create 无法按资源名授权，其余操作限定到具体 lease
+		if verb != "create" {
+			attrs.Name = leaseName
+		}
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attrs},
+		}
+		result, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("检查lease %s 权限失败: %w", verb, err)
+		}
+		if !result.Status.Allowed {
+			missing = append(missing, verb)
+		}
+	}
+	return missing, nil
+}
+
 func (k *K8sLeaseLockManager) AcquireLock(ctx context.Context) (func(), error) {
 	leaseClient := k.client.CoordinationV1().Leases(k.namespace)
 
@@ -5,7 +5,9 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -43,6 +45,7 @@ const (
 	ModeInit     LogMode = "INIT"
 	ModeRollback LogMode = "ROLLBACK"
 	ModeUpdate   LogMode = "UPDATE"
+	ModePlan     LogMode = "PLAN"
 )
 
 // 当前日志模式
@@ -114,6 +117,16 @@ func InitFileLogging(enableFileLog bool, logFilePath string, tempDir string) err
 	return nil
 }
 
+// RedirectToStderr 将普通日志也输出到标准错误，保证标准输出只承载命令结果（如 JSON）
+// 已启用文件日志时不做改变
+func RedirectToStderr() {
+	if logFile != nil {
+		return
+	}
+	logOutput = os.Stderr
+	errorOutput = os.Stderr
+}
+
 // CloseLogFile 关闭日志文件
 func CloseLogFile() {
 	if logFile != nil {
@@ -158,6 +171,62 @@ func LogLevelString(level LogLevel) string {
 	}
 }
 
+// 需要在日志中脱敏的敏感值（如从文件或环境变量解析出的密码）
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+)
+
+const redactedMask = "******"
+
+// RegisterSecret 登记敏感值，之后所有日志输出中出现该值时替换为掩码；任意长度的非空值都会脱敏
+func RegisterSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, s := range secrets {
+		if s == secret {
+			return
+		}
+	}
+	secrets = append(secrets, secret)
+	// 先替换较长的值，避免较短的值是其子串时只替换一部分而泄露其余字符
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// Redact 将文本中已登记的敏感值替换为掩码
+func Redact(text string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	for _, s := range secrets {
+		text = strings.ReplaceAll(text, s, redactedMask)
+	}
+	return text
+}
+
+type redactWriter struct {
+	w io.Writer
+}
+
+func (r redactWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// RedactWriter 返回对写入内容脱敏的 Writer（用于命令行框架输出的错误信息等）
+func RedactWriter(w io.Writer) io.Writer {
+	return redactWriter{w: w}
+}
+
+// emit 格式化并脱敏后输出一行日志
+func emit(w io.Writer, level string, format string, args ...interface{}) {
+	fmt.Fprint(w, Redact(fmt.Sprintf(level+" "+modePrefix()+format+"\n", args...)))
+}
+
 // modePrefix 返回模式前缀字符串
 func modePrefix() string {
 	return "[" + string(currentLogMode) + "] "
@@ -166,28 +235,28 @@ func modePrefix() string {
 // Error 输出错误日志
 func Error(format string, args ...interface{}) {
 	if currentLogLevel >= ERROR {
-		fmt.Fprintf(errorOutput, "ERROR "+modePrefix()+format+"\n", args...)
+		emit(errorOutput, "ERROR", format, args...)
 	}
 }
 
 // Warn 输出警告日志
 func Warn(format string, args ...interface{}) {
 	if currentLogLevel >= WARN {
-		fmt.Fprintf(logOutput, "Warn "+modePrefix()+format+"\n", args...)
+		emit(logOutput, "Warn", format, args...)
 	}
 }
 
 // Info 输出信息日志
 func Info(format string, args ...interface{}) {
 	if currentLogLevel >= INFO {
-		fmt.Fprintf(logOutput, "Info "+modePrefix()+format+"\n", args...)
+		emit(logOutput, "Info", format, args...)
 	}
 }
 
 // Debug 输出调试日志
 func Debug(format string, args ...interface{}) {
 	if currentLogLevel >= DEBUG {
-		fmt.Fprintf(logOutput, "DEBUG "+modePrefix()+format+"\n", args...)
+		emit(logOutput, "DEBUG", format, args...)
 	}
 }
 
@@ -0,0 +1,13 @@
+package logger
+
+import "testing"
+
+func TestRedactShortAndOverlappingSecrets(t *testing.T) {
+	RegisterSecret("ab")
+	RegisterSecret("xabcx")
+	RegisterSecret("")
+	got := Redact("p1=ab p2=xabcx")
+	if want := "p1=****** p2=******"; got != want {
+		t.Fatalf("Redact = %q，期望 %q", got, want)
+	}
+}